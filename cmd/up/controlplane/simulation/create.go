@@ -17,75 +17,39 @@ package simulation
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"slices"
-	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
-	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
-	diffv3 "github.com/r3labs/diff/v3"
-	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/resource"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/e2e-framework/klient/wait"
 
-	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
 	spacesv1beta1 "github.com/upbound/up-sdk-go/apis/spaces/v1beta1"
-	upctx "github.com/upbound/up/cmd/up/ctx"
 	"github.com/upbound/up/internal/diff"
 	"github.com/upbound/up/internal/kube"
-	"github.com/upbound/up/internal/profile"
+	upsim "github.com/upbound/up/internal/simulation"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/upterm"
 )
 
 const (
-	// controlPlaneReadyTimeout is the time to wait for a simulated control
-	// plane to start and be ready to accept changes.
-	controlPlaneReadyTimeout = 5 * time.Minute
-
 	// fieldManagerName is the name used to server side apply changes to the
 	// simulated control plan.
 	fieldManagerName = "up-cli"
-
-	// annotationKeyClonedState is the annotation key storing the JSON
-	// representation of the state of the resource at control plane clone time.
-	annotationKeyClonedState = "simulation.spaces.upbound.io/cloned-state"
-
-	// simulationCompleteReason is the value present in the `reason` field of
-	// the `AcceptingChanges` condition (on a Simulation) once the results have
-	// been published.
-	simulationCompleteReason = "SimulationComplete"
 )
 
 var stepSpinner = upterm.CheckmarkSuccessSpinner.WithShowTimer(true)
 
-// failOnCondition is the simulation condition that signals a failure in the
-// simulation command.
-type failOnCondition string
-
-var (
-	// failOnNone signals that the command should never return a failure exit
-	// code regardless of the results of the simulation.
-	failOnNone failOnCondition = "none"
-	// failOnDifference signals that the command should return a failure exit
-	// code when any difference was detected.
-	failOnDifference failOnCondition = "difference"
-)
-
 // CreateCmd creates a control plane simulation and outputs the differences
 // detected.
 type CreateCmd struct {
@@ -98,12 +62,17 @@ type CreateCmd struct {
 	Recursive     bool           `short:"r" help:"Process the directory used in -f, --changeset recursively." default:"false"`
 	CompleteAfter *time.Duration `help:"The maximum amount of time the simulated control plane should run before ending the simulation" default:"60s"`
 
-	FailOn            failOnCondition `help:"Fail and exit with a code of '1' if a certain condition is met" default:"none" enum:"none, difference"`
-	Output            string          `short:"o" help:"Output the results of the simulation to the provided file. Defaults to standard out if not specified"`
-	Wait              bool            `default:"true" help:"Wait for the simulation to complete. If set to false, the command will exit immediately after the changeset is applied"`
-	TerminateOnFinish bool            `default:"false" help:"Terminate the simulation after the completion criteria is met"`
+	FailOn            upsim.FailOnCondition `help:"Fail and exit with a code of '1' if a certain condition is met" default:"none" enum:"none, difference"`
+	Redact            []string              `help:"Field paths whose values are masked in the simulation results, for example 'spec.forProvider.password'. Core Secrets and fields marked as sensitive in CRD schemas are always masked"`
+	Policy            string                `type:"existingfile" help:"Path to a file of policy rules that the simulation results are evaluated against. Fails and exits with a code of '1' if any rule is violated"`
+	Output            string                `short:"o" help:"Output the results of the simulation to the provided file. Defaults to standard out if not specified"`
+	OutputFormat      diff.OutputFormat     `help:"The format of the simulation results. One of: pretty, json, markdown" default:"pretty" enum:"pretty, json, markdown"`
+	Wait              bool                  `default:"true" help:"Wait for the simulation to complete. If set to false, the command will exit immediately after the changeset is applied"`
+	TerminateOnFinish bool                  `default:"false" help:"Terminate the simulation after the completion criteria is met"`
 
 	Flags upbound.Flags `embed:""`
+
+	policy *diff.Policy
 }
 
 // Validate performs custom argument validation for the create command.
@@ -141,6 +110,14 @@ func (c *CreateCmd) AfterApply(kongCtx *kong.Context, upCtx *upbound.Context) er
 		fmt.Fprintf(kongCtx.Stderr, "debug logging enabled\n")
 	}
 
+	if c.Policy != "" {
+		policy, err := diff.LoadPolicy(c.Policy)
+		if err != nil {
+			return err
+		}
+		c.policy = policy
+	}
+
 	return nil
 }
 
@@ -162,7 +139,10 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 		totalSteps += 1
 	}
 
-	sim, err := c.createSimulation(ctx, spacesClient)
+	sim, err := upsim.Create(ctx, spacesClient, types.NamespacedName{Namespace: c.Group, Name: c.SourceName}, upsim.CreateOptions{
+		Name:          c.SimulationName,
+		CompleteAfter: c.CompleteAfter,
+	})
 	if err != nil {
 		return err
 	}
@@ -172,12 +152,12 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 	if err := upterm.WrapWithSuccessSpinner(
 		upterm.StepCounter("Waiting for simulated control plane to start", 1, totalSteps),
 		upterm.CheckmarkSuccessSpinner,
-		c.waitForSimulationAcceptingChangesStep(ctx, sim, spacesClient),
+		func() error { return upsim.WaitForAcceptingChanges(ctx, spacesClient, sim) },
 	); err != nil {
 		return err
 	}
 
-	simConfig, err := getControlPlaneConfig(ctx, upCtx, types.NamespacedName{Namespace: c.Group, Name: *sim.Status.SimulatedControlPlaneName})
+	simConfig, err := upsim.ControlPlaneConfig(ctx, upCtx, types.NamespacedName{Namespace: c.Group, Name: *sim.Status.SimulatedControlPlaneName})
 	if err != nil {
 		return err
 	}
@@ -200,7 +180,7 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 	if err := upterm.WrapWithSuccessSpinner(
 		upterm.StepCounter("Waiting for simulation to complete", 3, totalSteps),
 		stepSpinner,
-		c.waitForSimulationCompleteStep(ctx, sim, spacesClient),
+		func() error { return upsim.WaitForComplete(ctx, spacesClient, sim) },
 	); err != nil {
 		return err
 	}
@@ -212,7 +192,15 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 		fmt.Fprintf(kongCtx.Stderr, "total changes on the Simulation object: %d\n", len(sim.Status.Changes))
 	}
 
-	diffSet, err := c.createResourceDiffSet(ctx, kongCtx, simConfig, sim.Status.Changes)
+	debug := io.Discard
+	if c.Flags.Debug > 0 {
+		debug = kongCtx.Stderr
+	}
+	diffSet, err := upsim.ComputeDiffSet(ctx, simConfig, sim.Status.Changes, debug)
+	if err != nil {
+		return err
+	}
+	redactor, err := upsim.NewRedactor(ctx, simConfig, diffSet, c.Redact)
 	if err != nil {
 		return err
 	}
@@ -227,20 +215,24 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 		if err := upterm.WrapWithSuccessSpinner(
 			upterm.StepCounter("Terminating simulation", 5, totalSteps),
 			stepSpinner,
-			c.terminateSimulation(ctx, sim, spacesClient),
+			func() error { return upsim.Terminate(ctx, spacesClient, sim) },
 		); err != nil {
 			return err
 		}
 	}
 
-	if err := c.outputDiff(diffSet); err != nil {
+	violations := upsim.EvaluatePolicy(c.policy, diffSet)
+	if err := upsim.WriteDiff(diffSet, c.OutputFormat, c.Output, redactor, violations); err != nil {
 		return errors.Wrap(err, "failed to write diff to output")
 	}
+	if err := upsim.ReportViolations(kongCtx.Stderr, violations); err != nil {
+		return err
+	}
 
 	switch c.FailOn {
-	case failOnNone:
+	case upsim.FailOnNone:
 		break
-	case failOnDifference:
+	case upsim.FailOnDifference:
 		if len(diffSet) > 0 {
 			return errors.New("failing since differences were detected")
 		}
@@ -249,83 +241,6 @@ func (c *CreateCmd) Run(ctx context.Context, kongCtx *kong.Context, p pterm.Text
 	return nil
 }
 
-// createSimulation creates a new simulation object.
-func (c *CreateCmd) createSimulation(ctx context.Context, client client.Client) (*spacesv1alpha1.Simulation, error) {
-	sim := &spacesv1alpha1.Simulation{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      c.SimulationName,
-			Namespace: c.Group,
-		},
-		Spec: spacesv1alpha1.SimulationSpec{
-			ControlPlaneName: c.SourceName,
-			DesiredState:     spacesv1alpha1.SimulationStateAcceptingChanges,
-		},
-	}
-
-	if sim.Name == "" {
-		sim.GenerateName = c.SourceName + "-"
-	}
-
-	if c.CompleteAfter != nil {
-		sim.Spec.CompletionCriteria = []spacesv1alpha1.CompletionCriterion{{
-			Type:     spacesv1alpha1.CompletionCriterionTypeDuration,
-			Duration: metav1.Duration{Duration: *c.CompleteAfter},
-		}}
-	}
-
-	if err := client.Create(ctx, sim); err != nil {
-		return nil, errors.Wrap(err, "error creating simulation")
-	}
-
-	return sim, nil
-}
-
-// waitForSimulationAcceptingChangesStep pauses until the given simulation is
-// able to accept changes, or times out.
-func (c *CreateCmd) waitForSimulationAcceptingChangesStep(ctx context.Context, sim *spacesv1alpha1.Simulation, client client.Client) func() error {
-	return func() error {
-		if err := wait.For(func(ctx context.Context) (bool, error) {
-			if err := client.Get(ctx, types.NamespacedName{Name: sim.Name, Namespace: sim.Namespace}, sim); err != nil {
-				return false, err
-			}
-			return sim.Status.GetCondition(spacesv1alpha1.TypeAcceptingChanges).Status == corev1.ConditionTrue, nil
-		}, wait.WithImmediate(), wait.WithInterval(time.Second*2), wait.WithTimeout(controlPlaneReadyTimeout), wait.WithContext(ctx)); err != nil {
-			return errors.Wrap(err, "timed out before simulation could accept changes")
-		}
-		return nil
-	}
-}
-
-// waitForSimulationCompleteStep pauses until the given simulation has been
-// marked as complete.
-func (c *CreateCmd) waitForSimulationCompleteStep(ctx context.Context, sim *spacesv1alpha1.Simulation, client client.Client) func() error {
-	return func() error {
-		if err := wait.For(func(ctx context.Context) (bool, error) {
-			if err := client.Get(ctx, types.NamespacedName{Name: sim.Name, Namespace: sim.Namespace}, sim); err != nil {
-				return false, err
-			}
-			if sim.Spec.DesiredState != spacesv1alpha1.SimulationStateComplete {
-				return false, nil
-			}
-			return sim.Status.GetCondition(spacesv1alpha1.TypeAcceptingChanges).Reason == simulationCompleteReason, nil
-		}, wait.WithImmediate(), wait.WithInterval(time.Second*2), wait.WithContext(ctx)); err != nil {
-			return errors.Wrap(err, "error while waiting for simulation to complete")
-		}
-		return nil
-	}
-}
-
-// terminateSimulation marks the simulation as terminated.
-func (c *CreateCmd) terminateSimulation(ctx context.Context, sim *spacesv1alpha1.Simulation, client client.Client) func() error {
-	return func() error {
-		sim.Spec.DesiredState = spacesv1alpha1.SimulationStateTerminated
-		if err := client.Update(ctx, sim); err != nil {
-			return errors.Wrap(err, "unable to terminate simulation")
-		}
-		return nil
-	}
-}
-
 // applyChangesetStep loads the changeset resources specified in the argument
 // and applies them to the control plane.
 func (c *CreateCmd) applyChangesetStep(config *rest.Config) func() error {
@@ -347,228 +262,6 @@ func (c *CreateCmd) applyChangesetStep(config *rest.Config) func() error {
 	}
 }
 
-// removeFieldsForDiff removes any fields that should be excluded from the diff.
-func (c *CreateCmd) removeFieldsForDiff(u *unstructured.Unstructured) error {
-	// based on the filters in the simulation preprocessor
-	// https://github.com/upbound/spaces/blob/v1.8.0/internal/controller/mxe/simulation/preprocess.go#L100-L108
-	trim := []string{
-		"metadata.generateName",
-		"metadata.uid",
-		"metadata.resourceVersion",
-		"metadata.generation",
-		"metadata.creationTimestamp",
-		"metadata.ownerReferences",
-		"metadata.managedFields",
-		"metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']",
-		fmt.Sprintf("metadata.annotations['%s']", annotationKeyClonedState),
-		"spec.compositionRevisionRef",
-	}
-
-	wildcards := []string{
-		"status.conditions[*].lastTransitionTime",
-	}
-
-	p := fieldpath.Pave(u.UnstructuredContent())
-
-	// expand each wildcard path and add to list to trim
-	for _, wildcard := range wildcards {
-		if expanded, err := p.ExpandWildcards(wildcard); err != nil {
-			return errors.Wrap(err, "unable to expand wildcards in ignored fields")
-		} else {
-			trim = append(trim, expanded...)
-		}
-	}
-
-	for _, path := range trim {
-		if err := p.DeleteField(path); err != nil {
-			return errors.Wrap(err, "cannot delete field")
-		}
-	}
-
-	return nil
-}
-
-// createResourceDiffSet reads through all of the changes from the simulation
-// status and looks up the difference between the initial version of the
-// resource and the version currently in the API server (at the time of the
-// function call).
-func (c *CreateCmd) createResourceDiffSet(ctx context.Context, kongCtx *kong.Context, config *rest.Config, changes []spacesv1alpha1.SimulationChange) ([]diff.ResourceDiff, error) { // nolint:gocyclo
-	lookup, err := kube.NewDiscoveryResourceLookup(config)
-	if err != nil {
-		return []diff.ResourceDiff{}, errors.Wrap(err, "unable to create resource lookup client")
-	}
-
-	dyn, err := dynamic.NewForConfig(config)
-	if err != nil {
-		return []diff.ResourceDiff{}, errors.Wrap(err, "unable to create dynamic client")
-	}
-
-	diffSet := make([]diff.ResourceDiff, 0, len(changes))
-
-	if c.Flags.Debug > 0 {
-		fmt.Fprintf(kongCtx.Stderr, "iterating over %d changes\n", len(changes))
-	}
-
-	// stores a list of resources that we want to filter in the diff, that
-	// aren't being filtered in the reconciler
-	trimKind := []schema.GroupVersionKind{
-		{Group: "apiextensions.crossplane.io", Version: "v1", Kind: "CompositionRevision"},
-		{Group: "pkg.crossplane.io", Version: "v1beta1", Kind: "DeploymentRuntimeConfig"},
-	}
-
-	for _, change := range changes {
-		gvk := schema.FromAPIVersionAndKind(change.ObjectReference.APIVersion, change.ObjectReference.Kind)
-
-		// todo(redbackthomson): Remove this logic once we have done a better
-		// job of filtering in the reconciler
-		if slices.Contains(trimKind, gvk) {
-			if c.Flags.Debug > 0 {
-				fmt.Fprintf(kongCtx.Stderr, "skipping gvk %+v\n", gvk)
-			}
-			continue
-		}
-
-		rs, err := lookup.Get(gvk)
-		if err != nil {
-			if c.Flags.Debug > 0 {
-				fmt.Fprintf(kongCtx.Stderr, "unable to find gvk from lookup %q\n", gvk)
-			}
-			return []diff.ResourceDiff{}, err
-		}
-
-		switch change.Change { //nolint:exhaustive
-		case spacesv1alpha1.SimulationChangeTypeCreate:
-			diffSet = append(diffSet, diff.ResourceDiff{
-				SimulationChange: change,
-			})
-			if c.Flags.Debug > 0 {
-				fmt.Fprintf(kongCtx.Stderr, "appended create to diff set for %v\n", change.ObjectReference)
-			}
-			continue
-		case spacesv1alpha1.SimulationChangeTypeDelete:
-			diffSet = append(diffSet, diff.ResourceDiff{
-				SimulationChange: change,
-			})
-			if c.Flags.Debug > 0 {
-				fmt.Fprintf(kongCtx.Stderr, "appended delete to diff set for %v\n", change.ObjectReference)
-			}
-			continue
-		}
-
-		var cl dynamic.ResourceInterface
-		ncl := dyn.Resource(schema.GroupVersionResource{
-			Group:    rs.Group,
-			Version:  rs.Version,
-			Resource: rs.Name,
-		})
-		if change.ObjectReference.Namespace != nil {
-			cl = ncl.Namespace(*change.ObjectReference.Namespace)
-		} else {
-			cl = ncl
-		}
-
-		after, err := cl.Get(ctx, change.ObjectReference.Name, metav1.GetOptions{})
-		if err != nil {
-			return []diff.ResourceDiff{}, errors.Wrap(err, "unable to get object from simulated control plane")
-		}
-
-		beforeRaw, ok := after.GetAnnotations()[annotationKeyClonedState]
-		if !ok {
-			if c.Flags.Debug > 0 {
-				fmt.Fprintf(kongCtx.Stderr, "object %v is missing the previous cloned state annotation\n", change.ObjectReference)
-			}
-			continue
-		}
-		beforeObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, []byte(beforeRaw))
-		if err != nil {
-			return []diff.ResourceDiff{}, errors.Wrapf(err, "previous cloned state annotation on %v could not be decoded", change.ObjectReference)
-		}
-
-		before := beforeObj.(*unstructured.Unstructured)
-		if err := c.removeFieldsForDiff(after); err != nil {
-			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to remove fields before diff")
-		}
-
-		if err := c.removeFieldsForDiff(before); err != nil {
-			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to remove fields before diff")
-		}
-
-		diffd, err := diffv3.Diff(before.UnstructuredContent(), after.UnstructuredContent())
-		if err != nil {
-			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to calculate diff for object %v", change.ObjectReference)
-		}
-
-		// we filtered out all of the changes
-		if len(diffd) == 0 {
-			continue
-		}
-
-		diffSet = append(diffSet, diff.ResourceDiff{
-			SimulationChange: change,
-			Diff:             diffd,
-		})
-		if c.Flags.Debug > 0 {
-			fmt.Fprintf(kongCtx.Stderr, "appended update to diff set for %v\n", change.ObjectReference)
-		}
-	}
-	return diffSet, nil
-}
-
-// outputDiff outputs the diff to the location, and in the format, specified by
-// the command line arguments.
-func (c *CreateCmd) outputDiff(diffSet []diff.ResourceDiff) error {
-	stdout := c.Output == ""
-
-	// todo(redbackthomson): Use a different printer for JSON or YAML output
-	buf := &strings.Builder{}
-	writer := diff.NewPrettyPrintWriter(buf, stdout)
-	_ = writer.Write(diffSet)
-
-	if stdout {
-		fmt.Printf("\n\n")
-		fmt.Print(buf.String())
-		return nil
-	}
-
-	return os.WriteFile(c.Output, []byte(buf.String()), 0o644) // nolint:gosec // nothing system sensitive in the file
-}
-
-// getControlPlaneConfig gets a REST config for a given control plane within
-// the space.
-func getControlPlaneConfig(ctx context.Context, upCtx *upbound.Context, ctp types.NamespacedName) (*rest.Config, error) {
-	po := clientcmd.NewDefaultPathOptions()
-	var err error
-
-	conf, err := po.GetStartingConfig()
-	if err != nil {
-		return nil, err
-	}
-	state, err := upctx.DeriveState(ctx, upCtx, conf, profile.GetIngressHost)
-	if err != nil {
-		return nil, err
-	}
-
-	var ok bool
-	var space *upctx.Space
-
-	if space, ok = state.(*upctx.Space); !ok {
-		if group, ok := state.(*upctx.Group); ok {
-			space = &group.Space
-		} else if ctp, ok := state.(*upctx.ControlPlane); ok {
-			space = &ctp.Group.Space
-		} else {
-			return nil, errors.New("current kubeconfig is not pointed at a space cluster")
-		}
-	}
-
-	spaceClient, err := space.BuildClient(upCtx, ctp)
-	if err != nil {
-		return nil, err
-	}
-
-	return spaceClient.ClientConfig()
-}
-
 // loadResources builds a list of resources from the given path.
 func loadResources(getter resource.RESTClientGetter, paths []string, recursive bool) ([]*resource.Info, error) {
 	return resource.NewBuilder(getter).
@@ -21,10 +21,13 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
 
 	"github.com/upbound/up/internal/project"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/xpkg"
 	"github.com/upbound/up/internal/xpkg/dep"
 	"github.com/upbound/up/internal/xpkg/dep/cache"
@@ -68,6 +71,8 @@ type addCmd struct {
 	// can result in broken behavior between xpls and dep. CacheDir should
 	// only be supplied by the Config.
 	CacheDir string `short:"d" help:"Directory used for caching package images." default:"~/.up/cache/" env:"CACHE_DIR" type:"path"`
+
+	Verify signature.VerifyFlags `embed:""`
 }
 
 // AfterApply constructs and binds Upbound-specific context to any subcommands
@@ -98,7 +103,11 @@ func (c *addCmd) AfterApply(kongCtx *kong.Context, p pterm.TextPrinter) error {
 		return err
 	}
 
-	r := image.NewResolver()
+	verifier, err := c.Verify.Verifier(remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+	r := image.NewResolver(image.WithVerifier(verifier))
 
 	m, err := manager.New(
 		manager.WithCacheModels(c.modelsFS),
@@ -23,9 +23,12 @@ import (
 	"github.com/alecthomas/kong"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
 
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/xpkg/dep/cache"
 	"github.com/upbound/up/internal/xpkg/dep/manager"
 	"github.com/upbound/up/internal/xpkg/dep/resolver/image"
@@ -48,6 +51,8 @@ type updateCacheCmd struct {
 	// can result in broken behavior between xpls and dep. CacheDir should
 	// only be supplied by the Config.
 	CacheDir string `short:"d" help:"Directory used for caching package images." default:"~/.up/cache/" env:"CACHE_DIR" type:"path"`
+
+	Verify signature.VerifyFlags `embed:""`
 }
 
 func (c *updateCacheCmd) AfterApply(kongCtx *kong.Context, p pterm.TextPrinter) error {
@@ -72,7 +77,11 @@ func (c *updateCacheCmd) AfterApply(kongCtx *kong.Context, p pterm.TextPrinter)
 
 	c.c = cache
 
-	r := image.NewResolver()
+	verifier, err := c.Verify.Verifier(remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+	r := image.NewResolver(image.WithVerifier(verifier))
 
 	m, err := manager.New(
 		manager.WithCacheModels(c.modelsFS),
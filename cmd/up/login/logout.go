@@ -25,6 +25,7 @@ import (
 
 	"github.com/upbound/up-sdk-go"
 
+	"github.com/upbound/up/internal/tokencache"
 	"github.com/upbound/up/internal/upbound"
 )
 
@@ -33,6 +34,7 @@ const (
 
 	errLogoutFailed      = "unable to logout"
 	errRemoveTokenFailed = "failed to remove token"
+	errClearTokenCache   = "failed to remove cached organization tokens"
 )
 
 // AfterApply sets default values in login after assignment and validation.
@@ -80,6 +82,15 @@ func (c *LogoutCmd) Run(ctx context.Context, p pterm.TextPrinter, upCtx *upbound
 	if err := upCtx.CfgSrc.UpdateConfig(upCtx.Cfg); err != nil {
 		return errors.Wrap(err, errUpdateConfig)
 	}
+	// Org-scoped tokens of the session remain valid until they expire, so
+	// they are removed with it.
+	dir, err := tokencache.DefaultDir()
+	if err != nil {
+		return errors.Wrap(err, errClearTokenCache)
+	}
+	if err := tokencache.New(dir).Clear(upCtx.ProfileName); err != nil {
+		return errors.Wrap(err, errClearTokenCache)
+	}
 
 	p.Printfln("%s logged out", upCtx.Profile.ID)
 	return nil
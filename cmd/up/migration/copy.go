@@ -0,0 +1,199 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/kube"
+	"github.com/upbound/up/internal/upterm"
+	"github.com/upbound/up/pkg/migration"
+	"github.com/upbound/up/pkg/migration/exporter"
+	"github.com/upbound/up/pkg/migration/importer"
+)
+
+type copyCmd struct {
+	Yes bool `help:"When set to true, automatically accepts any confirmation prompts that may appear during the copy process." default:"false"`
+
+	FromKubeconfig string `type:"existingfile" help:"Specifies the kubeconfig of the source control plane. Defaults to the kubeconfig of the target control plane."`
+	FromContext    string `help:"Specifies the kubeconfig context of the source control plane. Defaults to the current context of the source kubeconfig."`
+	ToContext      string `help:"Specifies the kubeconfig context of the target control plane. Defaults to the current context."`
+
+	IncludeExtraResources []string `help:"A list of extra resource types to include in the copy in \"resource.group\" format in addition to all Crossplane resources. By default, it includes namespaces, configmaps, secrets." default:"namespaces,configmaps,secrets"`
+	ExcludeResources      []string `help:"A list of resource types to exclude from the copy in \"resource.group\" format. No resources are excluded by default."`
+	IncludeNamespaces     []string `help:"A list of specific namespaces to include in the copy. If not specified, all namespaces are included by default."`
+	ExcludeNamespaces     []string `help:"A list of specific namespaces to exclude from the copy. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
+
+	PauseBeforeExport  bool `help:"When set to true, pauses the managed resources in the export scope on the source control plane before copying. This can help ensure a consistent state for the copy. Defaults to false." default:"false"`
+	UnpauseAfterImport bool `help:"When set to true, automatically unpauses all managed resources on the target control plane after copying. Defaults to false, requiring manual unpausing of resources if needed." default:"false"`
+
+	Transform string `type:"existingfile" help:"Specifies the file path of transformation rules applied to each copied resource, e.g. to rename resources or swap package sources."`
+}
+
+func (c *copyCmd) Help() string {
+	return `
+The copy command reads the state of the source control plane and imports it into the target control plane in one go.
+Resources are imported in the same order, paused and waited for in the same way as with the import command, but no
+archive is written in between, so the secrets of the control plane never touch the disk.
+
+Examples:
+    migration copy --from-context=kind-crossplane --to-context=upbound
+        Copies the control plane state from the 'kind-crossplane' context to the 'upbound' context of the default kubeconfig.
+
+    migration copy --from-kubeconfig=source.yaml --unpause-after-import
+        Copies the control plane state from the current context of 'source.yaml' to the current context of the default
+        kubeconfig, and unpauses all managed resources after the import.
+`
+}
+
+func (c *copyCmd) Run(ctx context.Context, path kubeconfigPath) error { //nolint:gocyclo // Just a lot of error handling.
+	fromPath := c.FromKubeconfig
+	if fromPath == "" {
+		fromPath = string(path)
+	}
+	from, err := kube.GetKubeConfigForContext(fromPath, c.FromContext)
+	if err != nil {
+		return errors.Wrap(err, "cannot get kubeconfig of the source control plane")
+	}
+	to, err := kube.GetKubeConfigForContext(string(path), c.ToContext)
+	if err != nil {
+		return errors.Wrap(err, "cannot get kubeconfig of the target control plane")
+	}
+	if from.Host == to.Host {
+		return errors.New("source and target are the same control plane, use --from-context or --to-context to select different ones")
+	}
+	if !isAllowedImportTarget(to.Host) {
+		return errors.New("not a local or managed control plane, import not supported!")
+	}
+
+	e, err := c.exporter(from)
+	if err != nil {
+		return err
+	}
+
+	pterm.EnableStyling()
+	upterm.DefaultObjPrinter.Pretty = true
+
+	migration.DefaultSpinner = &spinner{upterm.CheckmarkSuccessSpinner}
+
+	pterm.Println("Reading control plane state...")
+
+	// The state is kept in memory only, just like the importer does with an
+	// unarchived export.
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := e.ExportTo(ctx, fs, "/"); err != nil {
+		return err
+	}
+
+	i, err := c.importer(to, fs)
+	if err != nil {
+		return err
+	}
+
+	errs := i.PreflightChecks(ctx)
+	if len(errs) > 0 {
+		fmt.Println("Preflight checks failed:")
+		for _, err := range errs {
+			fmt.Println("- " + err.Error())
+		}
+		if !c.Yes {
+			pterm.Println() // Blank line
+			confirm := pterm.DefaultInteractiveConfirm
+			confirm.DefaultText = "Do you still want to proceed?"
+			confirm.DefaultValue = false
+			result, _ := confirm.Show()
+			pterm.Println() // Blank line
+			if !result {
+				pterm.Error.Println("Preflight checks must pass in order to proceed with the copy.")
+				return nil
+			}
+		}
+	}
+
+	pterm.Println("\nImporting control plane state...")
+
+	if err := i.Import(ctx); err != nil {
+		return err
+	}
+	pterm.Println("\nSuccessfully copied control plane state!")
+	warnDanglingOwners(i.DanglingOwnerReferences())
+	return nil
+}
+
+func (c *copyCmd) exporter(cfg *rest.Config) (*exporter.ControlPlaneStateExporter, error) {
+	crdClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	appsClient, err := appsv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	return exporter.NewControlPlaneStateExporter(crdClient, dynamicClient, discoveryClient, appsClient, mapper, exporter.Options{
+		IncludeNamespaces:     c.IncludeNamespaces,
+		ExcludeNamespaces:     c.ExcludeNamespaces,
+		IncludeExtraResources: c.IncludeExtraResources,
+		ExcludeResources:      c.ExcludeResources,
+
+		PauseBeforeExport: c.PauseBeforeExport,
+
+		TransformRules: c.Transform,
+	}), nil
+}
+
+func (c *copyCmd) importer(cfg *rest.Config, fs afero.Afero) (*importer.ControlPlaneStateImporter, error) {
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	appsClient, err := appsv1.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transformation rules were already applied while reading the state.
+	return importer.NewControlPlaneStateImporter(dynamicClient, discoveryClient, appsClient, mapper, importer.Options{
+		UnpauseAfterImport: c.UnpauseAfterImport,
+	}, importer.WithState(fs)), nil
+}
@@ -16,7 +16,10 @@ package migration
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 
+	"github.com/alecthomas/kong"
 	"github.com/pterm/pterm"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/discovery"
@@ -25,6 +28,8 @@ import (
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 	"k8s.io/client-go/restmapper"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
 	"github.com/upbound/up/internal/input"
 	"github.com/upbound/up/internal/upterm"
 	"github.com/upbound/up/pkg/migration"
@@ -45,14 +50,24 @@ type exportCmd struct {
 
 	Yes bool `help:"When set to true, automatically accepts any confirmation prompts that may appear during the export process." default:"false"`
 
-	Output string `short:"o" help:"Specifies the file path where the exported archive will be saved. Defaults to 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	Output string `short:"o" help:"Specifies the file path or URL where the exported archive will be saved. Supports oci://<registry>/<repository>:<tag>, s3://<bucket>/<key>, gs://<bucket>/<object> and azblob://<storage account>/<container>/<blob> URLs. Defaults to 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
 
 	IncludeExtraResources []string `help:"A list of extra resource types to include in the export in \"resource.group\" format in addition to all Crossplane resources. By default, it includes namespaces, configmaps, secrets." default:"namespaces,configmaps,secrets"`
 	ExcludeResources      []string `help:"A list of resource types to exclude from the export in \"resource.group\" format. No resources are excluded by default."`
 	IncludeNamespaces     []string `help:"A list of specific namespaces to include in the export. If not specified, all namespaces are included by default."`
 	ExcludeNamespaces     []string `help:"A list of specific namespaces to exclude from the export. Defaults to 'kube-system', 'kube-public', 'kube-node-lease', and 'local-path-storage'." default:"kube-system,kube-public,kube-node-lease,local-path-storage"`
 
-	PauseBeforeExport bool `help:"When set to true, pauses all managed resources before starting the export process. This can help ensure a consistent state for the export. Defaults to false." default:"false"`
+	PauseBeforeExport bool `help:"When set to true, pauses the managed resources in the export scope before starting the export process. This can help ensure a consistent state for the export. Defaults to false." default:"false"`
+
+	EncryptRecipient  string `xor:"encrypt" help:"Encrypts the exported secrets to the given recipient. Either an age public key, or the path to a file containing age public keys or an armored PGP public key."`
+	EncryptPassphrase string `xor:"encrypt" env:"UP_MIGRATION_PASSPHRASE" help:"Encrypts the exported secrets with the given passphrase."`
+
+	Transform string `type:"existingfile" help:"Specifies the file path of transformation rules applied to each exported resource, e.g. to rename resources or swap package sources."`
+
+	Claim     string `xor:"subtree" help:"Limits the export to the claim in \"namespace/name\" format, its composite resource, all composed resources and the secrets, ProviderConfigs, XRDs, Compositions and packages they need."`
+	Composite string `xor:"subtree" help:"Limits the export to the composite resource with the given name, all composed resources and the secrets, ProviderConfigs, XRDs, Compositions and packages they need."`
+
+	Storage storageFlags `embed:""`
 }
 
 func (c *exportCmd) Help() string {
@@ -69,6 +84,21 @@ Examples:
 
     migration export --include-extra-resources="customresource.group" --include-namespaces="crossplane-system,team-a,team-b"
         Exports the control plane state to a default file 'xp-state.tar.gz', with the additional resource specified and only using provided namespaces.
+
+    migration export --encrypt-recipient=age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+        Exports the control plane state to the default archive file, with the exported secrets encrypted to the given age public key.
+
+    migration export --transform=rules.yaml
+        Exports the control plane state to the default archive file, applying the transformation rules in 'rules.yaml' to each exported resource.
+
+    migration export --output=s3://my-bucket/migrations/xp-state.tar.gz
+        Exports the control plane state and uploads the archive to the S3 bucket 'my-bucket', without keeping a local copy.
+
+    migration export --output=s3://my-bucket/xp-state.tar.gz --endpoint=https://minio.example.com --path-style --region=us-east-1
+        Exports the control plane state and uploads the archive to the bucket 'my-bucket' of the S3-compatible storage at 'minio.example.com'.
+
+    migration export --claim=team-a/my-database
+        Exports only the claim 'my-database' in namespace 'team-a' and everything it needs to the default archive file.
 `
 }
 
@@ -78,6 +108,11 @@ func (c *exportCmd) BeforeApply() error {
 	return nil
 }
 
+// AfterApply loads the kubeconfig of the control plane to export from.
+func (c *exportCmd) AfterApply(kongCtx *kong.Context, path kubeconfigPath) error {
+	return bindContext(kongCtx, path)
+}
+
 func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 	cfg := migCtx.Kubeconfig
 
@@ -100,8 +135,26 @@ func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
 
+	output := c.Output
+	remoteOut, err := parseRemoteArchive(c.Output)
+	if err != nil {
+		return err
+	}
+	if err := c.Storage.validate(remoteOut); err != nil {
+		return err
+	}
+	if remoteOut != nil {
+		// Export to a temporary archive first, and upload it once complete.
+		tmpDir, err := os.MkdirTemp("", "up")
+		if err != nil {
+			return errors.Wrap(err, "cannot create temporary directory")
+		}
+		defer os.RemoveAll(tmpDir) //nolint:errcheck // Nothing to do on error.
+		output = filepath.Join(tmpDir, remoteOut.name())
+	}
+
 	e := exporter.NewControlPlaneStateExporter(crdClient, dynamicClient, discoveryClient, appsClient, mapper, exporter.Options{
-		OutputArchive: c.Output,
+		OutputArchive: output,
 
 		IncludeNamespaces:     c.IncludeNamespaces,
 		ExcludeNamespaces:     c.ExcludeNamespaces,
@@ -109,9 +162,18 @@ func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 		ExcludeResources:      c.ExcludeResources,
 
 		PauseBeforeExport: c.PauseBeforeExport,
+
+		EncryptRecipient:  c.EncryptRecipient,
+		EncryptPassphrase: c.EncryptPassphrase,
+
+		TransformRules: c.Transform,
+
+		Claim:     c.Claim,
+		Composite: c.Composite,
 	})
 
-	if !c.Yes && e.IncludedExtraResource("secrets") {
+	encrypted := c.EncryptRecipient != "" || c.EncryptPassphrase != ""
+	if !c.Yes && !encrypted && e.IncludedExtraResource("secrets") {
 		confirm := pterm.DefaultInteractiveConfirm
 		confirm.DefaultText = secretsWarning
 		confirm.DefaultValue = true
@@ -132,6 +194,12 @@ func (c *exportCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 	if err = e.Export(ctx); err != nil {
 		return err
 	}
+	if remoteOut != nil {
+		pterm.Printfln("Uploading archive to %s...", remoteOut)
+		if err := remoteOut.upload(ctx, output, c.Storage); err != nil {
+			return err
+		}
+	}
 	pterm.Println("\nSuccessfully exported control plane state!")
 	return nil
 }
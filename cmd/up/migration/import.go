@@ -17,8 +17,12 @@ package migration
 import (
 	"context"
 	"fmt"
+	"os"
 
+	"github.com/alecthomas/kong"
 	"github.com/pterm/pterm"
+	diffv3 "github.com/r3labs/diff/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
@@ -27,6 +31,8 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+	"github.com/upbound/up/internal/diff"
 	"github.com/upbound/up/internal/input"
 	"github.com/upbound/up/internal/profile"
 	"github.com/upbound/up/internal/upterm"
@@ -38,15 +44,28 @@ type importCmd struct {
 	prompter input.Prompter
 	Yes      bool `help:"When set to true, automatically accepts any confirmation prompts that may appear during the import process." default:"false"`
 
-	Input string `short:"i" help:"Specifies the file path of the archive to be imported. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+	Input string `short:"i" help:"Specifies the file path or URL of the archive to be imported. Supports oci://<registry>/<repository>:<tag>, s3://<bucket>/<key>, gs://<bucket>/<object> and azblob://<storage account>/<container>/<blob> URLs. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
 
 	UnpauseAfterImport bool `help:"When set to true, automatically unpauses all managed resources that were paused during the import process. This helps in resuming normal operations post-import. Defaults to false, requiring manual unpausing of resources if needed." default:"false"`
+
+	DecryptKey        string `type:"existingfile" help:"Specifies the file path of the key used to decrypt an encrypted archive. Either a file containing age identities or an armored PGP private key."`
+	DecryptPassphrase string `env:"UP_MIGRATION_PASSPHRASE" help:"Specifies the passphrase used to decrypt an archive encrypted with a passphrase, or to unlock an encrypted PGP private key."`
+
+	Resume       bool   `help:"When set to true, resumes an interrupted import from its progress file. Resource types that were already imported are skipped, and their resources are applied again only if their spec differs from the archive." default:"false"`
+	ProgressFile string `help:"Specifies the file path where the import progress is recorded. Defaults to the input archive path with a '.progress.yaml' suffix."`
+
+	Transform string `type:"existingfile" help:"Specifies the file path of transformation rules applied to each imported resource, e.g. to rename resources or swap package sources."`
+
+	OutputFormat diff.OutputFormat `help:"The format of the report printed with --dry-run. One of: pretty, json, markdown." enum:"pretty, json, markdown" default:"pretty"`
+
+	Storage storageFlags `embed:""`
 }
 
 func (c *importCmd) Help() string {
 	return `
 By default, all managed resources will be paused during the import process for possible manual inspection/validation.
 You can use the --unpause-after-import flag to automatically unpause all managed resources after the import process completes.
+If the archive was exported with encrypted secrets, use the --decrypt-key or --decrypt-passphrase flags to decrypt them.
 
 Examples:
     migration import --input=my-export.tar.gz
@@ -54,6 +73,21 @@ Examples:
 
     migration import --unpause-after-import
         Imports and automatically unpauses managed resources after import.
+
+    migration import --resume
+        Resumes an interrupted import of 'xp-state.tar.gz' from its progress file 'xp-state.tar.gz.progress.yaml'.
+
+    migration import --transform=rules.yaml
+        Imports the control plane state, applying the transformation rules in 'rules.yaml' to each imported resource.
+
+    migration import --dry-run
+        Reports what importing 'xp-state.tar.gz' would create, change or conflict with on the target control plane, without importing it.
+
+    migration import --decrypt-key=key.txt
+        Imports the control plane state from an archive whose secrets were encrypted to the age public key of the identity in 'key.txt'.
+
+    migration import --input=oci://registry.example.com/migrations/prod:v1
+        Pulls the archive from the OCI registry and imports the control plane state.
 `
 }
 
@@ -63,7 +97,12 @@ func (c *importCmd) BeforeApply() error {
 	return nil
 }
 
-func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error { //nolint:gocyclo // Just a lot of error handling.
+// AfterApply loads the kubeconfig of the control plane to import into.
+func (c *importCmd) AfterApply(kongCtx *kong.Context, path kubeconfigPath) error {
+	return bindContext(kongCtx, path)
+}
+
+func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context, printer upterm.ObjectPrinter) error { //nolint:gocyclo // Just a lot of error handling.
 	cfg := migCtx.Kubeconfig
 
 	if !isAllowedImportTarget(cfg.Host) {
@@ -85,10 +124,31 @@ func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 		return err
 	}
 
+	input, remoteIn, cleanup, err := openArchive(ctx, c.Input, c.Storage)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	progressFile := c.ProgressFile
+	if remoteIn != nil && progressFile == "" {
+		// Keep the progress next to where the import is run, so that it can
+		// be resumed after the temporary archive is gone.
+		progressFile = remoteIn.name() + ".progress.yaml"
+	}
+
 	i := importer.NewControlPlaneStateImporter(dynamicClient, discoveryClient, appsClient, mapper, importer.Options{
-		InputArchive: c.Input,
+		InputArchive: input,
 
 		UnpauseAfterImport: c.UnpauseAfterImport,
+
+		DecryptKey:        c.DecryptKey,
+		DecryptPassphrase: c.DecryptPassphrase,
+
+		TransformRules: c.Transform,
+
+		ProgressFile: progressFile,
+		Resume:       c.Resume,
 	})
 
 	errs := i.PreflightChecks(ctx)
@@ -97,7 +157,7 @@ func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 		for _, err := range errs {
 			fmt.Println("- " + err.Error())
 		}
-		if !c.Yes {
+		if !c.Yes && !printer.DryRun {
 			pterm.Println() // Blank line
 			confirm := pterm.DefaultInteractiveConfirm
 			confirm.DefaultText = "Do you still want to proceed?"
@@ -114,17 +174,120 @@ func (c *importCmd) Run(ctx context.Context, migCtx *migration.Context) error {
 	pterm.EnableStyling()
 	upterm.DefaultObjPrinter.Pretty = true
 
-	pterm.Println("Importing control plane state...")
 	migration.DefaultSpinner = &spinner{upterm.CheckmarkSuccessSpinner}
 
+	if printer.DryRun {
+		// With the global --dry-run flag, compare the archive with the target
+		// control plane instead of importing it.
+		return c.dryRun(ctx, i)
+	}
+
+	pterm.Println("Importing control plane state...")
+
 	if err = i.Import(ctx); err != nil {
 		return err
 	}
 	pterm.Println("\nfully imported control plane state!")
+	warnDanglingOwners(i.DanglingOwnerReferences())
+
+	return nil
+}
+
+// warnDanglingOwners summarizes the owner references that could not be
+// restored, which need to be restored manually.
+func warnDanglingOwners(dangling []importer.DanglingOwnerReference) {
+	if len(dangling) == 0 {
+		return
+	}
+	pterm.Warning.Printfln("%d owner references could not be restored since the owner or the owned resource was not imported:", len(dangling))
+	for _, d := range dangling {
+		pterm.Println("- " + d.String())
+	}
+}
+
+// dryRun reports the changes importing the archive would make to the target
+// control plane.
+func (c *importCmd) dryRun(ctx context.Context, i *importer.ControlPlaneStateImporter) error {
+	pterm.Println("Comparing control plane state...")
+
+	changes, err := i.DryRun(ctx)
+	if err != nil {
+		return err
+	}
+	diffSet, conflicts, err := dryRunDiffSet(changes)
+	if err != nil {
+		return err
+	}
+
+	pretty := c.OutputFormat == diff.OutputFormatPretty
+	w, err := diff.NewWriter(c.OutputFormat, os.Stdout, pretty)
+	if err != nil {
+		return err
+	}
+	if pretty {
+		pterm.Println()
+	}
+	if err := w.Write(diffSet); err != nil {
+		return err
+	}
 
+	if len(conflicts) > 0 {
+		fmt.Fprintln(os.Stderr, "\nConflicts:")
+		for _, ch := range conflicts {
+			fmt.Fprintf(os.Stderr, "- %s %s: %s\n", ch.Desired.GroupVersionKind().Kind, objectName(ch.Desired), ch.Reason)
+		}
+	}
 	return nil
 }
 
+// dryRunDiffSet converts the changes of a dry-run import into resource diffs,
+// returning the conflicting changes separately.
+func dryRunDiffSet(changes []importer.ResourceChange) ([]diff.ResourceDiff, []importer.ResourceChange, error) {
+	diffSet := make([]diff.ResourceDiff, 0, len(changes))
+	var conflicts []importer.ResourceChange
+	for _, ch := range changes {
+		if ch.Type == importer.ChangeConflict {
+			conflicts = append(conflicts, ch)
+			continue
+		}
+
+		ref := spacesv1alpha1.ChangedObjectReference{
+			APIVersion: ch.Desired.GetAPIVersion(),
+			Kind:       ch.Desired.GetKind(),
+			Name:       ch.Desired.GetName(),
+		}
+		if ns := ch.Desired.GetNamespace(); ns != "" {
+			ref.Namespace = &ns
+		}
+
+		rd := diff.ResourceDiff{
+			SimulationChange: spacesv1alpha1.SimulationChange{
+				Change:          spacesv1alpha1.SimulationChangeTypeCreate,
+				ObjectReference: ref,
+			},
+		}
+		if ch.Type == importer.ChangeUpdate {
+			d, err := diffv3.Diff(ch.Current.Object, ch.Desired.Object)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "cannot calculate diff for %s %s", ref.Kind, objectName(ch.Desired))
+			}
+			rd.SimulationChange.Change = spacesv1alpha1.SimulationChangeTypeUpdate
+			rd.Diff = d
+		}
+		diffSet = append(diffSet, rd)
+	}
+	return diffSet, conflicts, nil
+}
+
+// objectName returns the name of the object, prefixed with its namespace if
+// it has one.
+func objectName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
+
 func isAllowedImportTarget(host string) bool {
 	_, matches := profile.ParseMCPK8sURL(host)
 	if !matches {
@@ -16,6 +16,15 @@ package migration
 
 import (
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	diffv3 "github.com/r3labs/diff/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+	"github.com/upbound/up/internal/diff"
+	"github.com/upbound/up/pkg/migration/importer"
 )
 
 func TestIsAllowedImportTarget(t *testing.T) {
@@ -85,3 +94,73 @@ func TestIsAllowedImportTarget(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRunDiffSet(t *testing.T) {
+	ns := "default"
+	bucket := func(region string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.org/v1",
+			"kind":       "Bucket",
+			"metadata":   map[string]interface{}{"name": "b", "namespace": ns},
+			"spec":       map[string]interface{}{"region": region},
+		}}
+	}
+
+	type want struct {
+		diffSet   []diff.ResourceDiff
+		conflicts int
+	}
+	tests := map[string]struct {
+		reason  string
+		changes []importer.ResourceChange
+		want    want
+	}{
+		"Create": {
+			reason:  "Should report a created resource without field changes",
+			changes: []importer.ResourceChange{{Type: importer.ChangeCreate, Desired: bucket("us-east-1")}},
+			want: want{
+				diffSet: []diff.ResourceDiff{{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change:          spacesv1alpha1.SimulationChangeTypeCreate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b", Namespace: &ns},
+					},
+				}},
+			},
+		},
+		"Update": {
+			reason:  "Should report the changed fields of an updated resource",
+			changes: []importer.ResourceChange{{Type: importer.ChangeUpdate, Current: bucket("us-east-1"), Desired: bucket("us-west-2")}},
+			want: want{
+				diffSet: []diff.ResourceDiff{{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change:          spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b", Namespace: &ns},
+					},
+					Diff: diffv3.Changelog{{Type: diffv3.UPDATE, Path: []string{"spec", "region"}, From: "us-east-1", To: "us-west-2"}},
+				}},
+			},
+		},
+		"Conflict": {
+			reason:  "Should return conflicts separately from the diff set",
+			changes: []importer.ResourceChange{{Type: importer.ChangeConflict, Current: bucket("us-east-1"), Desired: bucket("us-west-2"), Reason: "conflict"}},
+			want: want{
+				diffSet:   []diff.ResourceDiff{},
+				conflicts: 1,
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diffSet, conflicts, err := dryRunDiffSet(tt.changes)
+			if err != nil {
+				t.Fatalf("\n%s\ndryRunDiffSet(...): unexpected error: %v", tt.reason, err)
+			}
+			if diff := cmp.Diff(tt.want.diffSet, diffSet, cmpopts.IgnoreUnexported(diffv3.Change{})); diff != "" {
+				t.Errorf("\n%s\ndryRunDiffSet(...): -want diff set, +got diff set:\n%s", tt.reason, diff)
+			}
+			if len(conflicts) != tt.want.conflicts {
+				t.Errorf("\n%s\ndryRunDiffSet(...): want %d conflicts, got %d", tt.reason, tt.want.conflicts, len(conflicts))
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/upbound/up/internal/kube"
+	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/importer"
+	"github.com/upbound/up/pkg/migration/inspector"
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+type inspectCmd struct {
+	Archive string `arg:"" optional:"" help:"Specifies the file path or URL of the archive to be inspected. Supports the same URLs as the import command. The default path is 'xp-state.tar.gz'." default:"xp-state.tar.gz"`
+
+	SkipTargetCheck bool `help:"When set to true, does not check whether the archive can be imported into the Crossplane of the current kubeconfig context." default:"false"`
+
+	Storage storageFlags `embed:""`
+}
+
+func (c *inspectCmd) Help() string {
+	return `
+The inspect command prints the metadata of an export archive, i.e. the exported Crossplane version and feature flags, the
+export options and the number of exported resources per type. It also validates the contents of the archive, and warns
+if the Crossplane of the current kubeconfig context is incompatible with the exported one.
+
+Examples:
+    migration inspect xp-state.tar.gz
+        Prints the metadata of 'xp-state.tar.gz', validates it and checks it against the current kubeconfig context.
+
+    migration inspect xp-state.tar.gz --skip-target-check
+        Prints the metadata of 'xp-state.tar.gz' and validates it, without connecting to a control plane.
+
+    migration inspect oci://registry.example.com/backups/xp-state:v1
+        Downloads the archive from an OCI registry, prints its metadata and validates it.
+`
+}
+
+func (c *inspectCmd) Run(ctx context.Context, path kubeconfigPath) error {
+	archive, _, cleanup, err := openArchive(ctx, c.Archive, c.Storage)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := importer.Unarchive(ctx, archive, fs); err != nil {
+		return errors.Wrap(err, "cannot unarchive export archive")
+	}
+	r, err := inspector.Inspect(fs)
+	if err != nil {
+		return err
+	}
+
+	printExportMeta(r.Meta)
+
+	if !c.SkipTargetCheck {
+		c.checkTarget(ctx, path, r.Meta)
+	}
+
+	if len(r.Problems) > 0 {
+		pterm.Println()
+		for _, p := range r.Problems {
+			pterm.Error.Println(p)
+		}
+		return errors.Errorf("archive %q is invalid, found %d problems", c.Archive, len(r.Problems))
+	}
+	pterm.Println()
+	pterm.Success.Printfln("Archive %q is valid.", c.Archive)
+	return nil
+}
+
+// checkTarget warns if the Crossplane of the target control plane is not
+// compatible with the exported one. The archive can be inspected without
+// access to a target, so failing to reach it is not an error.
+func (c *inspectCmd) checkTarget(ctx context.Context, path kubeconfigPath, em *v1alpha1.ExportMeta) {
+	pterm.Println()
+	cfg, err := kube.GetKubeConfig(string(path))
+	if err != nil {
+		pterm.Warning.Printfln("Cannot check target control plane: %v", err)
+		return
+	}
+	appsClient, err := appsv1.NewForConfig(cfg)
+	if err != nil {
+		pterm.Warning.Printfln("Cannot check target control plane: %v", err)
+		return
+	}
+	target, err := crossplane.CollectInfo(ctx, appsClient)
+	if err != nil {
+		pterm.Warning.Printfln("Cannot check target control plane: %v", err)
+		return
+	}
+	warnings := inspector.CheckCompatibility(em.Crossplane, *target)
+	for _, w := range warnings {
+		pterm.Warning.Println(w)
+	}
+	if len(warnings) == 0 {
+		pterm.Success.Printfln("Target control plane with Crossplane %s is compatible.", target.Version)
+	}
+}
+
+func printExportMeta(em *v1alpha1.ExportMeta) {
+	none := func(ss []string) string {
+		if len(ss) == 0 {
+			return "-"
+		}
+		return strings.Join(ss, ", ")
+	}
+
+	pterm.DefaultSection.Println("Export")
+	info := pterm.TableData{
+		{"Version", em.Version},
+		{"Exported At", em.ExportedAt.Format(time.RFC3339)},
+		{"Crossplane Distribution", em.Crossplane.Distribution},
+		{"Crossplane Version", em.Crossplane.Version},
+		{"Feature Flags", none(em.Crossplane.FeatureFlags)},
+		{"Included Namespaces", none(em.Options.IncludedNamespaces)},
+		{"Excluded Namespaces", none(em.Options.ExcludedNamespaces)},
+		{"Included Extra Resources", none(em.Options.IncludedExtraResources)},
+		{"Excluded Resources", none(em.Options.ExcludedResources)},
+		{"Paused Before Export", strconv.FormatBool(em.Options.PausedBeforeExport)},
+	}
+	if em.Options.Claim != "" {
+		info = append(info, []string{"Claim", em.Options.Claim})
+	}
+	if em.Options.Composite != "" {
+		info = append(info, []string{"Composite", em.Options.Composite})
+	}
+	if em.Encryption != nil {
+		info = append(info, []string{"Encryption", fmt.Sprintf("%s (%s)", em.Encryption.Scheme, none(em.Encryption.Resources))})
+	}
+	_ = pterm.DefaultTable.WithData(info).Render()
+
+	pterm.DefaultSection.Println("Resources")
+	stats := pterm.TableData{{"TYPE", "COUNT"}}
+	for _, counts := range []map[string]int{em.Stats.NativeResources, em.Stats.CustomResources} {
+		types := make([]string, 0, len(counts))
+		for t := range counts {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			stats = append(stats, []string{t, strconv.Itoa(counts[t])})
+		}
+	}
+	stats = append(stats, []string{"Total", strconv.Itoa(em.Stats.Total)})
+	_ = pterm.DefaultTable.WithHasHeader().WithData(stats).Render()
+}
@@ -21,10 +21,17 @@ import (
 	"github.com/upbound/up/pkg/migration"
 )
 
-// AfterApply constructs and binds Upbound specific context to any subcommands
-// that have Run() methods that receive it.
+// AfterApply binds the kubeconfig path to any subcommands that have Run()
+// methods that receive it.
 func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
-	cfg, err := kube.GetKubeConfig(c.Kubeconfig)
+	kongCtx.Bind(kubeconfigPath(c.Kubeconfig))
+	return nil
+}
+
+// bindContext constructs and binds the migration context for the subcommands
+// that work with the control plane of the kubeconfig.
+func bindContext(kongCtx *kong.Context, path kubeconfigPath) error {
+	cfg, err := kube.GetKubeConfig(string(path))
 	if err != nil {
 		return err
 	}
@@ -35,9 +42,15 @@ func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
 	return nil
 }
 
+// kubeconfigPath is the path of the kubeconfig given with --kubeconfig, for
+// commands that select other contexts than the current one.
+type kubeconfigPath string
+
 type Cmd struct {
-	Export exportCmd `cmd:"" help:"The 'export' command is used to export the current state of a Crossplane or Universal Crossplane (xp/uxp) control plane into an archive file. This file can then be used for migration to Upbound Managed Control Planes."`
-	Import importCmd `cmd:"" help:"The 'import' command imports a control plane state from an archive file into an Upbound managed control plane."`
+	Export  exportCmd  `cmd:"" help:"The 'export' command is used to export the current state of a Crossplane or Universal Crossplane (xp/uxp) control plane into an archive file. This file can then be used for migration to Upbound Managed Control Planes."`
+	Import  importCmd  `cmd:"" help:"The 'import' command imports a control plane state from an archive file into an Upbound managed control plane."`
+	Inspect inspectCmd `cmd:"" help:"The 'inspect' command prints the metadata of an export archive and validates its contents."`
+	Copy    copyCmd    `cmd:"" help:"The 'copy' command copies the state of a Crossplane or Universal Crossplane (xp/uxp) control plane directly into an Upbound managed control plane, without writing an archive file."`
 
 	Kubeconfig string `type:"existingfile" help:"Override default kubeconfig path."`
 }
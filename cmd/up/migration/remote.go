@@ -0,0 +1,346 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pterm/pterm"
+	gcpopt "google.golang.org/api/option"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	usageaws "github.com/upbound/up/internal/usage/aws"
+)
+
+const (
+	schemeOCI   = "oci"
+	schemeS3    = "s3"
+	schemeGCS   = "gs"
+	schemeAzure = "azblob"
+
+	// archiveMediaType is the media type of the layer holding the export
+	// archive in an OCI artifact.
+	archiveMediaType types.MediaType = "application/vnd.upbound.migration.archive.v1.tar+gzip"
+	// archiveConfigMediaType is the media type of the config of an export
+	// archive OCI artifact.
+	archiveConfigMediaType types.MediaType = "application/vnd.upbound.migration.config.v1+json"
+)
+
+// storageFlags configure the object storage clients used for s3:// and gs://
+// archive URLs, like the storage flags of 'space billing export'.
+type storageFlags struct {
+	Endpoint  string `env:"UP_MIGRATION_ENDPOINT" group:"Storage" help:"Custom storage endpoint for s3:// and gs:// archive URLs, e.g. of S3-compatible storage such as MinIO."`
+	Region    string `env:"UP_MIGRATION_REGION" group:"Storage" help:"Region of the bucket for s3:// archive URLs. Defaults to the region of the AWS environment, e.g. AWS_REGION."`
+	PathStyle bool   `env:"UP_MIGRATION_PATH_STYLE" group:"Storage" help:"Use path-style addressing for S3-compatible storage such as MinIO. Only supported for s3:// archive URLs."`
+	CABundle  string `type:"existingfile" env:"UP_MIGRATION_CA_BUNDLE" group:"Storage" help:"Path to a PEM encoded CA bundle used to verify the storage endpoint. Only supported for s3:// archive URLs."`
+}
+
+// validate returns an error if the storage flags are not supported by the
+// archive, which is nil for local files.
+func (s storageFlags) validate(a *remoteArchive) error {
+	scheme := ""
+	if a != nil {
+		scheme = a.scheme
+	}
+	if scheme != schemeS3 && (s.Region != "" || s.PathStyle || s.CABundle != "") {
+		return errors.New("--region, --path-style and --ca-bundle are only supported for s3:// archive URLs")
+	}
+	if scheme != schemeS3 && scheme != schemeGCS && s.Endpoint != "" {
+		return errors.New("--endpoint is only supported for s3:// and gs:// archive URLs")
+	}
+	return nil
+}
+
+// s3Client returns an S3 client configured by the storage flags.
+func (s storageFlags) s3Client() (*s3.S3, error) {
+	opts := usageaws.ClientOptions{
+		Endpoint:  s.Endpoint,
+		Region:    s.Region,
+		PathStyle: s.PathStyle,
+	}
+	if s.CABundle != "" {
+		ca, err := os.ReadFile(s.CABundle)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading CA bundle")
+		}
+		opts.CABundle = bytes.NewReader(ca)
+	}
+	return usageaws.NewClient(opts)
+}
+
+// gcsClient returns a GCS client configured by the storage flags.
+func (s storageFlags) gcsClient(ctx context.Context) (*storage.Client, error) {
+	opts := []gcpopt.ClientOption{}
+	if s.Endpoint != "" {
+		opts = append(opts, gcpopt.WithEndpoint(s.Endpoint))
+	}
+	cli, err := storage.NewClient(ctx, opts...)
+	return cli, errors.Wrap(err, "error creating storage client")
+}
+
+// remoteArchive is an export archive in an OCI registry or an object storage
+// bucket, i.e. one of:
+// - oci://<registry>/<repository>:<tag>
+// - s3://<bucket>/<key>
+// - gs://<bucket>/<object>
+// - azblob://<storage account>/<container>/<blob>
+type remoteArchive struct {
+	scheme string
+	// bucket is the bucket, or the Azure storage account.
+	bucket string
+	// key is the object key, or the Azure container and blob.
+	key string
+	// ref is the OCI reference.
+	ref name.Reference
+}
+
+// parseRemoteArchive parses the archive location. It returns nil if the
+// location is a local file.
+func parseRemoteArchive(location string) (*remoteArchive, error) {
+	scheme, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return nil, nil
+	}
+	if scheme == schemeOCI {
+		ref, err := name.ParseReference(rest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse OCI reference %q", rest)
+		}
+		return &remoteArchive{scheme: scheme, ref: ref}, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse archive URL %q", location)
+	}
+	a := &remoteArchive{scheme: u.Scheme, bucket: u.Host, key: strings.TrimPrefix(u.Path, "/")}
+	switch a.scheme {
+	case schemeS3, schemeGCS:
+	case schemeAzure:
+		if container, blob, ok := strings.Cut(a.key, "/"); !ok || container == "" || blob == "" {
+			return nil, errors.Errorf("invalid Azure blob URL %q, must be in azblob://<storage account>/<container>/<blob> format", location)
+		}
+	default:
+		return nil, errors.Errorf("unsupported archive URL scheme %q, must be one of oci, s3, gs or azblob", a.scheme)
+	}
+	if a.bucket == "" || a.key == "" {
+		return nil, errors.Errorf("invalid archive URL %q, must include a bucket and an object", location)
+	}
+	return a, nil
+}
+
+// openArchive returns the path of a local copy of the archive at the given
+// location, which is either a local file or one of the URLs supported by
+// parseRemoteArchive. Remote archives are downloaded into a temporary
+// directory that is removed by the returned function. The remote archive is
+// nil if the location is a local file.
+func openArchive(ctx context.Context, location string, s storageFlags) (string, *remoteArchive, func(), error) {
+	a, err := parseRemoteArchive(location)
+	if err == nil {
+		err = s.validate(a)
+	}
+	if err != nil || a == nil {
+		return location, nil, func() {}, err
+	}
+	tmpDir, err := os.MkdirTemp("", "up")
+	if err != nil {
+		return "", nil, nil, errors.Wrap(err, "cannot create temporary directory")
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+	}
+
+	file := filepath.Join(tmpDir, a.name())
+	pterm.Printfln("Downloading archive from %s...", a)
+	if err := a.download(ctx, file, s); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return file, a, cleanup, nil
+}
+
+// name returns the file name of the archive.
+func (a *remoteArchive) name() string {
+	if a.ref != nil {
+		return strings.ReplaceAll(a.ref.Context().RepositoryStr(), "/", "-") + "-" + a.ref.Identifier() + ".tar.gz"
+	}
+	return path.Base(a.key)
+}
+
+func (a *remoteArchive) String() string {
+	if a.ref != nil {
+		return fmt.Sprintf("%s://%s", a.scheme, a.ref)
+	}
+	return fmt.Sprintf("%s://%s/%s", a.scheme, a.bucket, a.key)
+}
+
+// upload uploads the local archive file.
+func (a *remoteArchive) upload(ctx context.Context, file string, s storageFlags) error {
+	f, err := os.Open(file) //nolint:gosec // The file is the archive we just exported.
+	if err != nil {
+		return errors.Wrap(err, "cannot open archive")
+	}
+	defer f.Close() //nolint:errcheck // Only read from.
+
+	switch a.scheme {
+	case schemeOCI:
+		// The archive is already gzipped, so it is streamed from the file
+		// as is instead of being read into memory.
+		l, err := tarball.LayerFromFile(file, tarball.WithMediaType(archiveMediaType))
+		if err != nil {
+			return errors.Wrap(err, "cannot read archive")
+		}
+		img, err := mutate.Append(empty.Image, mutate.Addendum{
+			Layer: l,
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot build archive artifact")
+		}
+		img = mutate.ConfigMediaType(mutate.MediaType(img, types.OCIManifestSchema1), archiveConfigMediaType)
+		return errors.Wrap(remote.Write(a.ref, img, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)), "cannot push archive artifact")
+	case schemeS3:
+		cli, err := s.s3Client()
+		if err != nil {
+			return err
+		}
+		_, err = s3manager.NewUploaderWithClient(cli).UploadWithContext(ctx, &s3manager.UploadInput{
+			Bucket: aws.String(a.bucket),
+			Key:    aws.String(a.key),
+			Body:   f,
+		})
+		return errors.Wrap(err, "cannot upload archive to S3")
+	case schemeGCS:
+		cli, err := s.gcsClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close() //nolint:errcheck // Nothing to do on error.
+		w := cli.Bucket(a.bucket).Object(a.key).NewWriter(ctx)
+		if _, err := io.Copy(w, f); err != nil {
+			_ = w.Close()
+			return errors.Wrap(err, "cannot upload archive to GCS")
+		}
+		return errors.Wrap(w.Close(), "cannot upload archive to GCS")
+	case schemeAzure:
+		cli, err := a.azureClient()
+		if err != nil {
+			return err
+		}
+		container, blob, _ := strings.Cut(a.key, "/")
+		_, err = cli.UploadFile(ctx, container, blob, f, nil)
+		return errors.Wrap(err, "cannot upload archive to Azure")
+	}
+	return nil
+}
+
+// download downloads the archive into the local file.
+func (a *remoteArchive) download(ctx context.Context, file string, s storageFlags) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600) //nolint:gosec // The file is a temporary file we created.
+	if err != nil {
+		return errors.Wrap(err, "cannot create archive file")
+	}
+	defer f.Close() //nolint:errcheck // Closed explicitly below.
+
+	switch a.scheme {
+	case schemeOCI:
+		img, err := remote.Image(a.ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		if err != nil {
+			return errors.Wrap(err, "cannot pull archive artifact")
+		}
+		layers, err := img.Layers()
+		if err != nil {
+			return errors.Wrap(err, "cannot get archive artifact layers")
+		}
+		if len(layers) != 1 {
+			return errors.Errorf("archive artifact must have exactly one layer, found %d", len(layers))
+		}
+		if mt, err := layers[0].MediaType(); err != nil || mt != archiveMediaType {
+			return errors.Errorf("%s is not a migration archive artifact", a)
+		}
+		rc, err := layers[0].Compressed()
+		if err != nil {
+			return errors.Wrap(err, "cannot read archive artifact")
+		}
+		defer rc.Close() //nolint:errcheck // Only read from.
+		if _, err := io.Copy(f, rc); err != nil {
+			return errors.Wrap(err, "cannot pull archive artifact")
+		}
+	case schemeS3:
+		cli, err := s.s3Client()
+		if err != nil {
+			return err
+		}
+		if _, err := s3manager.NewDownloaderWithClient(cli).DownloadWithContext(ctx, f, &s3.GetObjectInput{
+			Bucket: aws.String(a.bucket),
+			Key:    aws.String(a.key),
+		}); err != nil {
+			return errors.Wrap(err, "cannot download archive from S3")
+		}
+	case schemeGCS:
+		cli, err := s.gcsClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close() //nolint:errcheck // Nothing to do on error.
+		r, err := cli.Bucket(a.bucket).Object(a.key).NewReader(ctx)
+		if err != nil {
+			return errors.Wrap(err, "cannot download archive from GCS")
+		}
+		defer r.Close() //nolint:errcheck // Only read from.
+		if _, err := io.Copy(f, r); err != nil {
+			return errors.Wrap(err, "cannot download archive from GCS")
+		}
+	case schemeAzure:
+		cli, err := a.azureClient()
+		if err != nil {
+			return err
+		}
+		container, blob, _ := strings.Cut(a.key, "/")
+		if _, err := cli.DownloadFile(ctx, container, blob, f, nil); err != nil {
+			return errors.Wrap(err, "cannot download archive from Azure")
+		}
+	}
+	return errors.Wrap(f.Close(), "cannot write archive file")
+}
+
+func (a *remoteArchive) azureClient() (*azblob.Client, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", a.bucket), cred, nil)
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestParseRemoteArchive(t *testing.T) {
+	type want struct {
+		archive string
+		name    string
+		err     bool
+	}
+	cases := map[string]struct {
+		reason   string
+		location string
+		want     want
+	}{
+		"LocalFile": {
+			reason:   "A local file is not a remote archive.",
+			location: "xp-state.tar.gz",
+		},
+		"OCI": {
+			reason:   "An OCI reference should be parsed.",
+			location: "oci://registry.example.com/migrations/prod:v1",
+			want:     want{archive: "oci://registry.example.com/migrations/prod:v1", name: "migrations-prod-v1.tar.gz"},
+		},
+		"S3": {
+			reason:   "An S3 URL should be parsed.",
+			location: "s3://my-bucket/migrations/xp-state.tar.gz",
+			want:     want{archive: "s3://my-bucket/migrations/xp-state.tar.gz", name: "xp-state.tar.gz"},
+		},
+		"GCS": {
+			reason:   "A GCS URL should be parsed.",
+			location: "gs://my-bucket/xp-state.tar.gz",
+			want:     want{archive: "gs://my-bucket/xp-state.tar.gz", name: "xp-state.tar.gz"},
+		},
+		"Azure": {
+			reason:   "An Azure blob URL should be parsed.",
+			location: "azblob://myaccount/migrations/xp-state.tar.gz",
+			want:     want{archive: "azblob://myaccount/migrations/xp-state.tar.gz", name: "xp-state.tar.gz"},
+		},
+		"AzureWithoutContainer": {
+			reason:   "An Azure blob URL requires a container.",
+			location: "azblob://myaccount/xp-state.tar.gz",
+			want:     want{err: true},
+		},
+		"NoObject": {
+			reason:   "A bucket URL without an object is invalid.",
+			location: "s3://my-bucket",
+			want:     want{err: true},
+		},
+		"UnsupportedScheme": {
+			reason:   "Unsupported schemes should be rejected.",
+			location: "https://example.com/xp-state.tar.gz",
+			want:     want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a, err := parseRemoteArchive(tc.location)
+			if tc.want.err {
+				if err == nil {
+					t.Errorf("\n%s\nparseRemoteArchive(...): expected error", tc.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("\n%s\nparseRemoteArchive(...): unexpected error: %v", tc.reason, err)
+			}
+			got := want{}
+			if a != nil {
+				got = want{archive: a.String(), name: a.name()}
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nparseRemoteArchive(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRemoteArchiveOCI(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.tar.gz"), filepath.Join(dir, "out.tar.gz")
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	if _, err := gw.Write([]byte("not really a tarball")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := buf.Bytes()
+	if err := os.WriteFile(in, want, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := parseRemoteArchive("oci://" + u.Host + "/migrations/prod:v1")
+	if err != nil {
+		t.Fatalf("parseRemoteArchive(...): unexpected error: %v", err)
+	}
+	if err := a.upload(context.Background(), in, storageFlags{}); err != nil {
+		t.Fatalf("upload(...): unexpected error: %v", err)
+	}
+	if err := a.download(context.Background(), out, storageFlags{}); err != nil {
+		t.Fatalf("download(...): unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(out) //nolint:gosec // Test file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("download(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestRemoteArchiveS3Compatible(t *testing.T) {
+	// The region is set with --region instead of the environment.
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "minio")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minio123")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	objects := map[string][]byte{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			objects[r.URL.Path] = b
+		case http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(b)-1, len(b)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(b)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ca, in, out := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "in.tar.gz"), filepath.Join(dir, "out.tar.gz")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("not really a tarball")
+	if err := os.WriteFile(in, want, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := storageFlags{Endpoint: srv.URL, Region: "us-east-1", PathStyle: true, CABundle: ca}
+	a, err := parseRemoteArchive("s3://migrations/prod/xp-state.tar.gz")
+	if err != nil {
+		t.Fatalf("parseRemoteArchive(...): unexpected error: %v", err)
+	}
+	if err := s.validate(a); err != nil {
+		t.Fatalf("validate(...): unexpected error: %v", err)
+	}
+	if err := a.upload(context.Background(), in, s); err != nil {
+		t.Fatalf("upload(...): unexpected error: %v", err)
+	}
+	if _, ok := objects["/migrations/prod/xp-state.tar.gz"]; !ok {
+		t.Fatalf("upload(...): archive not uploaded with path-style addressing, got objects %v", objects)
+	}
+	if err := a.download(context.Background(), out, s); err != nil {
+		t.Fatalf("download(...): unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(out) //nolint:gosec // Test file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("download(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestStorageFlagsValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		location string
+		flags    storageFlags
+		err      bool
+	}{
+		"S3": {
+			reason:   "All storage flags are supported for S3.",
+			location: "s3://bucket/xp-state.tar.gz",
+			flags:    storageFlags{Endpoint: "https://minio.example.com", Region: "eu-west-1", PathStyle: true, CABundle: "ca.pem"},
+		},
+		"GCSEndpoint": {
+			reason:   "A custom endpoint is supported for GCS.",
+			location: "gs://bucket/xp-state.tar.gz",
+			flags:    storageFlags{Endpoint: "https://gcs.example.com"},
+		},
+		"GCSRegion": {
+			reason:   "The region is only supported for S3.",
+			location: "gs://bucket/xp-state.tar.gz",
+			flags:    storageFlags{Region: "eu-west-1"},
+			err:      true,
+		},
+		"AzureEndpoint": {
+			reason:   "A custom endpoint is not supported for Azure.",
+			location: "azblob://account/container/xp-state.tar.gz",
+			flags:    storageFlags{Endpoint: "https://azure.example.com"},
+			err:      true,
+		},
+		"LocalFile": {
+			reason:   "Storage flags are not supported for local files.",
+			location: "xp-state.tar.gz",
+			flags:    storageFlags{PathStyle: true},
+			err:      true,
+		},
+		"LocalFileNoFlags": {
+			reason:   "Local files are supported without storage flags.",
+			location: "xp-state.tar.gz",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a, err := parseRemoteArchive(tc.location)
+			if err != nil {
+				t.Fatalf("parseRemoteArchive(...): unexpected error: %v", err)
+			}
+			err = tc.flags.validate(a)
+			if tc.err != (err != nil) {
+				t.Errorf("\n%s\nvalidate(...): want error %t, got %v", tc.reason, tc.err, err)
+			}
+		})
+	}
+}
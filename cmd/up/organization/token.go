@@ -21,11 +21,13 @@ import (
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/pterm/pterm"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientauthentication "k8s.io/client-go/pkg/apis/clientauthentication/v1"
 
 	"github.com/upbound/up-sdk-go/service/auth"
+	"github.com/upbound/up/internal/tokencache"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/upterm"
 )
@@ -34,8 +36,9 @@ import (
 type tokenCmd struct {
 	Upbound upbound.Flags `embed:""`
 
-	Name  string `arg:"" env:"ORGANIZATION" required:"" help:"Name of organization." predictor:"orgs"`
-	Token string `short:"t" env:"UP_TOKEN" help:"Token used to execute command. Overrides the token present in the profile."`
+	Name    string `arg:"" env:"ORGANIZATION" required:"" help:"Name of organization." predictor:"orgs"`
+	Token   string `short:"t" env:"UP_TOKEN" help:"Token used to execute command. Overrides the token present in the profile."`
+	NoCache bool   `env:"UP_NO_TOKEN_CACHE" help:"Always get a new token instead of the token cached in ~/.up until shortly before it expires."`
 }
 
 // AfterApply sets default values in command after assignment and validation.
@@ -62,15 +65,50 @@ func (c *tokenCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pter
 		sessionToken = upCtx.Profile.Session
 	}
 
-	client := auth.NewClient(cfg)
-	orgToken, err := client.GetOrgScopedToken(ctx, c.Name, sessionToken)
+	fetch := func() (*clientauthentication.ExecCredential, error) {
+		return getCredential(ctx, auth.NewClient(cfg), c.Name, sessionToken)
+	}
+	var creds *clientauthentication.ExecCredential
+	if c.NoCache {
+		creds, err = fetch()
+	} else {
+		creds, err = getCachedCredential(upCtx.Log, upCtx.ProfileName, c.Name, sessionToken, fetch)
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(creds)
 	if err != nil {
 		return err
 	}
 
+	fmt.Print(string(out))
+	return nil
+}
+
+// getCachedCredential returns the credential of the organization cached for
+// the profile and session, or fetches it and caches it. The credential is
+// fetched without caching it if the cache cannot be used.
+func getCachedCredential(log logging.Logger, profile, org, session string, fetch tokencache.FetchFn) (*clientauthentication.ExecCredential, error) {
+	dir, err := tokencache.DefaultDir()
+	if err != nil {
+		log.Debug("failed to use token cache", "error", err)
+		return fetch()
+	}
+	return tokencache.New(dir, tokencache.WithLogger(log)).Get(profile, org, session, fetch)
+}
+
+// getCredential exchanges a session token for an org-scoped token.
+func getCredential(ctx context.Context, client *auth.Client, org, session string) (*clientauthentication.ExecCredential, error) {
+	orgToken, err := client.GetOrgScopedToken(ctx, org, session)
+	if err != nil {
+		return nil, err
+	}
+
 	exp := v1.NewTime(time.Now().Add(time.Duration(orgToken.ExpiresIn) * time.Second))
 
-	creds := clientauthentication.ExecCredential{
+	return &clientauthentication.ExecCredential{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "ExecCredential",
 			APIVersion: clientauthentication.SchemeGroupVersion.String(),
@@ -79,13 +117,5 @@ func (c *tokenCmd) Run(ctx context.Context, printer upterm.ObjectPrinter, p pter
 			ExpirationTimestamp: &exp,
 			Token:               orgToken.AccessToken,
 		},
-	}
-
-	out, err := json.Marshal(creds)
-	if err != nil {
-		return err
-	}
-
-	fmt.Print(string(out))
-	return nil
+	}, nil
 }
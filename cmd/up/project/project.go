@@ -19,6 +19,7 @@ import (
 	"github.com/upbound/up/cmd/up/project/move"
 	"github.com/upbound/up/cmd/up/project/push"
 	"github.com/upbound/up/cmd/up/project/run"
+	"github.com/upbound/up/cmd/up/project/simulate"
 )
 
 type Cmd struct {
@@ -27,4 +28,6 @@ type Cmd struct {
 	Push  push.Cmd  `cmd:"" help:"Push a project's packages to the Upbound Marketplace."`
 	Run   run.Cmd   `cmd:"" help:"Run a project on a development control plane for testing."`
 	Move  move.Cmd  `cmd:"" help:"Update the repository for a project"`
+
+	Simulate simulate.Cmd `cmd:"" help:"Simulate upgrading an existing control plane to a project build and show the differences."`
 }
@@ -23,13 +23,17 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/pterm/pterm"
 	"github.com/spf13/afero"
 
 	"github.com/upbound/up/internal/async"
+	"github.com/upbound/up/internal/credhelper"
 	"github.com/upbound/up/internal/project"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/upterm"
 )
@@ -43,6 +47,8 @@ type Cmd struct {
 	Public         bool          `help:"Create new repositories with public visibility."`
 	Flags          upbound.Flags `embed:""`
 
+	Sign signature.SignFlags `embed:""`
+
 	projFS    afero.Fs
 	packageFS afero.Fs
 	transport http.RoundTripper
@@ -112,10 +118,25 @@ func (c *Cmd) Run(ctx context.Context, upCtx *upbound.Context, p pterm.TextPrint
 		},
 	)
 
+	kc := authn.NewMultiKeychain(
+		authn.NewKeychainFromHelper(
+			credhelper.New(
+				credhelper.WithDomain(upCtx.Domain.Hostname()),
+				credhelper.WithProfile(upCtx.ProfileName),
+			),
+		),
+		authn.DefaultKeychain,
+	)
+	signer, err := c.Sign.Signer(remote.WithAuthFromKeychain(kc), remote.WithTransport(c.transport))
+	if err != nil {
+		return err
+	}
+
 	pusher := project.NewPusher(
 		project.PushWithUpboundContext(upCtx),
 		project.PushWithTransport(c.transport),
 		project.PushWithMaxConcurrency(c.MaxConcurrency),
+		project.PushWithSigner(signer),
 	)
 
 	err = async.WrapWithSuccessSpinners(func(ch async.EventChannel) error {
@@ -24,7 +24,6 @@ import (
 	"github.com/alecthomas/kong"
 	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
-	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	xpkgv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	xpkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -472,7 +471,7 @@ func (c *Cmd) installPackage(ctx context.Context, cl client.Client, proj *v1alph
 	err = upterm.WrapWithSuccessSpinner(
 		"Waiting for package to be ready",
 		upterm.CheckmarkSuccessSpinner,
-		waitForPackagesReady(ctx, cl, tag),
+		func() error { return project.WaitForPackagesReady(ctx, cl, tag) },
 	)
 	if err != nil {
 		return err
@@ -480,93 +479,3 @@ func (c *Cmd) installPackage(ctx context.Context, cl client.Client, proj *v1alph
 
 	return nil
 }
-
-func waitForPackagesReady(ctx context.Context, cl client.Client, tag name.Tag) func() error {
-	return func() error {
-		nn := types.NamespacedName{
-			Name: "lock",
-		}
-		var lock xpkgv1beta1.Lock
-		for {
-			time.Sleep(500 * time.Millisecond)
-			err := cl.Get(ctx, nn, &lock)
-			if err != nil {
-				return err
-			}
-
-			cfgPkg, cfgFound := lookupLockPackage(lock.Packages, tag.Repository.String(), "")
-			if !cfgFound {
-				// Configuration not in lock yet.
-				continue
-			}
-			healthy, err := packageIsHealthy(ctx, cl, cfgPkg)
-			if err != nil {
-				return err
-			}
-			if !healthy {
-				// Configuration is not healthy yet.
-				continue
-			}
-
-			healthy, err = allDepsHealthy(ctx, cl, lock, cfgPkg)
-			if err != nil {
-				return err
-			}
-			if healthy {
-				break
-			}
-		}
-		return nil
-	}
-}
-
-func allDepsHealthy(ctx context.Context, cl client.Client, lock xpkgv1beta1.Lock, pkg xpkgv1beta1.LockPackage) (bool, error) {
-	for _, dep := range pkg.Dependencies {
-		depPkg, found := lookupLockPackage(lock.Packages, dep.Package, dep.Constraints)
-		if !found {
-			// Dep is not in lock yet - no need to look at the rest.
-			break
-		}
-		healthy, err := packageIsHealthy(ctx, cl, depPkg)
-		if err != nil {
-			return false, err
-		}
-		if !healthy {
-			return false, nil
-		}
-	}
-
-	return true, nil
-}
-
-func lookupLockPackage(pkgs []xpkgv1beta1.LockPackage, source, version string) (xpkgv1beta1.LockPackage, bool) {
-	for _, pkg := range pkgs {
-		if pkg.Source == source {
-			if version == "" || pkg.Version == version {
-				return pkg, true
-			}
-		}
-	}
-	return xpkgv1beta1.LockPackage{}, false
-}
-
-func packageIsHealthy(ctx context.Context, cl client.Client, lpkg xpkgv1beta1.LockPackage) (bool, error) {
-	var pkg xpkgv1.PackageRevision
-	switch lpkg.Type {
-	case xpkgv1beta1.ConfigurationPackageType:
-		pkg = &xpkgv1.ConfigurationRevision{}
-
-	case xpkgv1beta1.ProviderPackageType:
-		pkg = &xpkgv1.ProviderRevision{}
-
-	case xpkgv1beta1.FunctionPackageType:
-		pkg = &xpkgv1.FunctionRevision{}
-	}
-
-	err := cl.Get(ctx, types.NamespacedName{Name: lpkg.Name}, pkg)
-	if err != nil {
-		return false, err
-	}
-
-	return resource.IsConditionTrue(pkg.GetCondition(commonv1.TypeHealthy)), nil
-}
@@ -0,0 +1,480 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	xpkgv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	xpkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1cache "github.com/google/go-containerregistry/pkg/v1/cache"
+	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+	spacesv1beta1 "github.com/upbound/up-sdk-go/apis/spaces/v1beta1"
+	"github.com/upbound/up/cmd/up/project/common"
+	"github.com/upbound/up/internal/async"
+	"github.com/upbound/up/internal/diff"
+	"github.com/upbound/up/internal/oci/cache"
+	"github.com/upbound/up/internal/project"
+	"github.com/upbound/up/internal/simulation"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+	xcache "github.com/upbound/up/internal/xpkg/dep/cache"
+	"github.com/upbound/up/internal/xpkg/dep/manager"
+	"github.com/upbound/up/internal/xpkg/dep/resolver/image"
+	"github.com/upbound/up/internal/xpkg/functions"
+	"github.com/upbound/up/internal/xpkg/schemarunner"
+	"github.com/upbound/up/pkg/apis/project/v1alpha1"
+)
+
+const (
+	// fieldManagerName is the name used to server side apply the
+	// configuration to the simulated control plane.
+	fieldManagerName = "up-project-simulate"
+
+	// packagesReadyTimeout is the time to wait for the configuration and its
+	// dependencies to become healthy in the simulated control plane.
+	packagesReadyTimeout = 10 * time.Minute
+
+	// simulationTagPrefix is the prefix of the temporary tags that simulated
+	// builds are pushed to, so that they can't be mistaken for releases.
+	simulationTagPrefix = "sim-"
+)
+
+var ctpSchemeBuilders = []*scheme.Builder{
+	xpkgv1.SchemeBuilder,
+	xpkgv1beta1.SchemeBuilder,
+}
+
+var stepSpinner = upterm.CheckmarkSuccessSpinner.WithShowTimer(true)
+
+func init() {
+	kruntime.Must(spacesv1alpha1.AddToScheme(kscheme.Scheme))
+	kruntime.Must(spacesv1beta1.AddToScheme(kscheme.Scheme))
+}
+
+// Cmd builds and pushes a project, then simulates upgrading an existing
+// control plane to it.
+//
+// The project is pushed to a temporary tag derived from the digest of its
+// configuration package, e.g. sim-0123456789ab, rather than a version. These
+// tags aren't removed after the simulation, since a release built from the same
+// sources shares their digest; they can be deleted from the repository once
+// they're no longer needed.
+type Cmd struct {
+	ControlPlaneName  string `arg:"" required:"" help:"Name of the control plane to simulate the project against."`
+	ControlPlaneGroup string `short:"g" help:"The control plane group that the control plane is contained in. This defaults to the group specified in the current context."`
+
+	ProjectFile    string `short:"f" help:"Path to project definition file." default:"upbound.yaml"`
+	Repository     string `optional:"" help:"Repository for the built package. Overrides the repository specified in the project file."`
+	NoBuildCache   bool   `help:"Don't cache image layers while building." default:"false"`
+	BuildCacheDir  string `help:"Path to the build cache directory." type:"path" default:"~/.up/build-cache"`
+	MaxConcurrency uint   `help:"Maximum number of functions to build and push at once." env:"UP_MAX_CONCURRENCY" default:"8"`
+	CacheDir       string `help:"Directory used for caching dependencies." default:"~/.up/cache/" env:"CACHE_DIR" type:"path"`
+	Public         bool   `help:"Create new repositories with public visibility."`
+
+	SimulationName    string                     `short:"n" help:"The name of the simulation resource"`
+	CompleteAfter     time.Duration              `help:"The amount of time the simulated control plane should run after the packages are ready before ending the simulation" default:"60s"`
+	Output            string                     `short:"o" help:"Output the results of the simulation to the provided file. Defaults to standard out if not specified"`
+	OutputFormat      diff.OutputFormat          `help:"The format of the simulation results. One of: pretty, json, markdown" default:"pretty" enum:"pretty, json, markdown"`
+	FailOn            simulation.FailOnCondition `help:"Fail and exit with a code of '1' if a certain condition is met" default:"none" enum:"none, difference"`
+	Redact            []string                   `help:"Field paths whose values are masked in the simulation results, for example 'spec.forProvider.password'. Core Secrets and fields marked as sensitive in CRD schemas are always masked"`
+	Policy            string                     `type:"existingfile" help:"Path to a file of policy rules that the simulation results are evaluated against. Fails and exits with a code of '1' if any rule is violated"`
+	TerminateOnFinish bool                       `default:"false" help:"Terminate the simulation after the completion criteria is met"`
+
+	Flags upbound.Flags `embed:""`
+
+	projFS             afero.Fs
+	modelsFS           afero.Fs
+	functionIdentifier functions.Identifier
+	schemaRunner       schemarunner.SchemaRunner
+	transport          http.RoundTripper
+	m                  *manager.Manager
+	policy             *diff.Policy
+}
+
+// Help returns the extended help of the simulate command.
+func (c *Cmd) Help() string {
+	return `
+The 'simulate' command builds the project and pushes it to a temporary tag of
+its repository, named after the digest of the configuration package, for
+example 'sim-0123456789ab'. It then upgrades the project's configuration to that
+tag in a simulation of the control plane and shows the differences.
+
+Temporary tags are not signed and are not removed after the simulation. Delete
+them from the repository once they are no longer needed.
+`
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *Cmd) AfterApply(kongCtx *kong.Context) error {
+	upCtx, err := upbound.NewFromFlags(c.Flags)
+	if err != nil {
+		return err
+	}
+	upCtx.SetupLogging()
+	kongCtx.Bind(upCtx)
+
+	// Read the project file.
+	projFilePath, err := filepath.Abs(c.ProjectFile)
+	if err != nil {
+		return err
+	}
+	// The location of the project file defines the root of the project.
+	projDirPath := filepath.Dir(projFilePath)
+	c.projFS = afero.NewBasePathFs(afero.NewOsFs(), projDirPath)
+	c.modelsFS = afero.NewBasePathFs(afero.NewOsFs(), filepath.Join(projDirPath, ".up"))
+
+	c.functionIdentifier = functions.DefaultIdentifier
+	c.schemaRunner = schemarunner.RealSchemaRunner{}
+	c.transport = http.DefaultTransport
+
+	cache, err := xcache.NewLocal(c.CacheDir, xcache.WithFS(afero.NewOsFs()))
+	if err != nil {
+		return err
+	}
+
+	m, err := manager.New(
+		manager.WithCacheModels(c.modelsFS),
+		manager.WithCache(cache),
+		manager.WithResolver(image.NewResolver()),
+	)
+	if err != nil {
+		return err
+	}
+	c.m = m
+
+	if c.ControlPlaneGroup == "" {
+		ns, _, err := upCtx.Kubecfg.Namespace()
+		if err != nil {
+			return err
+		}
+		c.ControlPlaneGroup = ns
+	}
+
+	if c.Policy != "" {
+		policy, err := diff.LoadPolicy(c.Policy)
+		if err != nil {
+			return err
+		}
+		c.policy = policy
+	}
+
+	pterm.EnableStyling()
+
+	return nil
+}
+
+// Run executes the simulate command.
+func (c *Cmd) Run(ctx context.Context, kongCtx *kong.Context, upCtx *upbound.Context, p pterm.TextPrinter) error { //nolint:gocyclo // Mostly a sequence of steps.
+	if c.MaxConcurrency == 0 {
+		c.MaxConcurrency = 1
+	}
+
+	spacesClient, err := upCtx.BuildCurrentContextClient()
+	if err != nil {
+		return errors.Wrap(err, "unable to get kube client")
+	}
+
+	var srcCtp spacesv1beta1.ControlPlane
+	if err := spacesClient.Get(ctx, types.NamespacedName{Name: c.ControlPlaneName, Namespace: c.ControlPlaneGroup}, &srcCtp); err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Errorf("control plane %q not found", c.ControlPlaneName)
+		}
+		return err
+	}
+
+	var proj *v1alpha1.Project
+	err = upterm.WrapWithSuccessSpinner(
+		"Parsing project metadata",
+		upterm.CheckmarkSuccessSpinner,
+		func() error {
+			projFilePath := filepath.Join("/", filepath.Base(c.ProjectFile))
+			lproj, err := project.Parse(c.projFS, projFilePath)
+			if err != nil {
+				return errors.Wrap(err, "failed to parse project metadata")
+			}
+			proj = lproj
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if c.Repository != "" {
+		proj.Spec.Repository = c.Repository
+	}
+
+	tag, err := c.buildAndPush(ctx, upCtx, proj)
+	if err != nil {
+		return err
+	}
+
+	sim, err := simulation.Create(ctx, spacesClient, types.NamespacedName{Namespace: c.ControlPlaneGroup, Name: c.ControlPlaneName}, simulation.CreateOptions{
+		Name: c.SimulationName,
+	})
+	if err != nil {
+		return err
+	}
+	p.Printfln("Simulation %q created", sim.Name)
+
+	if err := upterm.WrapWithSuccessSpinner(
+		"Waiting for simulated control plane to start",
+		stepSpinner,
+		func() error { return simulation.WaitForAcceptingChanges(ctx, spacesClient, sim) },
+	); err != nil {
+		return err
+	}
+
+	simConfig, err := simulation.ControlPlaneConfig(ctx, upCtx, types.NamespacedName{Namespace: c.ControlPlaneGroup, Name: *sim.Status.SimulatedControlPlaneName})
+	if err != nil {
+		return err
+	}
+	simClient, err := newControlPlaneClient(simConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := upterm.WrapWithSuccessSpinner(
+		fmt.Sprintf("Updating configuration to %s in the simulated control plane", tag),
+		stepSpinner,
+		func() error { return c.updateConfiguration(ctx, simClient, proj, tag) },
+	); err != nil {
+		return err
+	}
+
+	if err := upterm.WrapWithSuccessSpinner(
+		"Waiting for packages to be ready",
+		stepSpinner,
+		func() error {
+			ctx, cancel := context.WithTimeout(ctx, packagesReadyTimeout)
+			defer cancel()
+			return project.WaitForPackagesReady(ctx, simClient, tag)
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := upterm.WrapWithSuccessSpinner(
+		"Waiting for simulation to complete",
+		stepSpinner,
+		func() error {
+			// Give the control plane time to reconcile the upgraded
+			// configuration before publishing the results.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.CompleteAfter):
+			}
+			if err := simulation.Complete(ctx, spacesClient, sim); err != nil {
+				return err
+			}
+			return simulation.WaitForComplete(ctx, spacesClient, sim)
+		},
+	); err != nil {
+		return err
+	}
+
+	s, _ := stepSpinner.Start("Computing simulated differences")
+	debug := io.Discard
+	if c.Flags.Debug > 0 {
+		debug = kongCtx.Stderr
+	}
+	diffSet, err := simulation.ComputeDiffSet(ctx, simConfig, sim.Status.Changes, debug)
+	if err != nil {
+		return err
+	}
+	redactor, err := simulation.NewRedactor(ctx, simConfig, diffSet, c.Redact)
+	if err != nil {
+		return err
+	}
+	s.Success()
+
+	if c.TerminateOnFinish {
+		if err := upterm.WrapWithSuccessSpinner(
+			"Terminating simulation",
+			stepSpinner,
+			func() error { return simulation.Terminate(ctx, spacesClient, sim) },
+		); err != nil {
+			return err
+		}
+	}
+
+	violations := simulation.EvaluatePolicy(c.policy, diffSet)
+	if err := simulation.WriteDiff(diffSet, c.OutputFormat, c.Output, redactor, violations); err != nil {
+		return errors.Wrap(err, "failed to write diff to output")
+	}
+	if err := simulation.ReportViolations(kongCtx.Stderr, violations); err != nil {
+		return err
+	}
+
+	if c.FailOn == simulation.FailOnDifference && len(diffSet) > 0 {
+		return errors.New("failing since differences were detected")
+	}
+
+	return nil
+}
+
+// buildAndPush builds the project and pushes its packages, returning the tag
+// of the pushed configuration package.
+func (c *Cmd) buildAndPush(ctx context.Context, upCtx *upbound.Context, proj *v1alpha1.Project) (name.Tag, error) {
+	b := project.NewBuilder(
+		project.BuildWithMaxConcurrency(c.MaxConcurrency),
+		project.BuildWithFunctionIdentifier(c.functionIdentifier),
+		project.BuildWithSchemaRunner(c.schemaRunner),
+	)
+
+	var imgMap project.ImageTagMap
+	err := async.WrapWithSuccessSpinners(func(ch async.EventChannel) error {
+		var err error
+		imgMap, err = b.Build(ctx, proj, c.projFS,
+			project.BuildWithEventChannel(ch),
+			project.BuildWithImageLabels(common.ImageLabels(c)),
+			project.BuildWithDependencyManager(c.m),
+		)
+		return err
+	})
+	if err != nil {
+		return name.Tag{}, err
+	}
+
+	if !c.NoBuildCache {
+		cch := cache.NewValidatingCache(v1cache.NewFilesystemCache(c.BuildCacheDir))
+		for tag, img := range imgMap {
+			imgMap[tag] = v1cache.Image(img, cch)
+		}
+	}
+
+	simTag, err := simulationTag(proj, imgMap)
+	if err != nil {
+		return name.Tag{}, err
+	}
+
+	// The pusher isn't given a signer: temporary tags must not be signed, as
+	// that would make them indistinguishable from releases to verifiers.
+	pusher := project.NewPusher(
+		project.PushWithUpboundContext(upCtx),
+		project.PushWithTransport(c.transport),
+		project.PushWithMaxConcurrency(c.MaxConcurrency),
+	)
+
+	var tag name.Tag
+	err = async.WrapWithSuccessSpinners(func(ch async.EventChannel) error {
+		opts := []project.PushOption{
+			project.PushWithEventChannel(ch),
+			project.PushWithCreatePublicRepositories(c.Public),
+			project.PushWithTag(simTag),
+		}
+
+		var err error
+		tag, err = pusher.Push(ctx, proj, imgMap, opts...)
+		return err
+	})
+	return tag, err
+}
+
+// simulationTag returns the temporary tag to push the packages of a project to
+// for a simulation. It's derived from the digest of the configuration package
+// so that simulating the same build twice reuses the tag.
+func simulationTag(proj *v1alpha1.Project, imgMap project.ImageTagMap) (string, error) {
+	cfgTag, err := name.NewTag(fmt.Sprintf("%s:%s", proj.Spec.Repository, project.ConfigurationTag))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to construct configuration tag")
+	}
+	img, ok := imgMap[cfgTag]
+	if !ok {
+		return "", errors.New("project build did not produce a configuration package")
+	}
+	dgst, err := img.Digest()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get configuration package digest")
+	}
+	return simulationTagPrefix + dgst.Hex[:12], nil
+}
+
+// updateConfiguration points the Configuration installed from the project's
+// repository at the given tag. If the project is not installed yet, a new
+// Configuration is created for it.
+func (c *Cmd) updateConfiguration(ctx context.Context, cl client.Client, proj *v1alpha1.Project, tag name.Tag) error {
+	cfgName := proj.Name
+
+	var cfgs xpkgv1.ConfigurationList
+	if err := cl.List(ctx, &cfgs); err != nil {
+		return errors.Wrap(err, "failed to list configurations")
+	}
+	for _, cfg := range cfgs.Items {
+		ref, err := name.ParseReference(cfg.Spec.Package)
+		if err != nil {
+			continue
+		}
+		if ref.Context().String() == tag.Repository.String() {
+			cfgName = cfg.Name
+			break
+		}
+	}
+
+	cfg := &xpkgv1.Configuration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: xpkgv1.SchemeGroupVersion.String(),
+			Kind:       xpkgv1.ConfigurationKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cfgName,
+		},
+		Spec: xpkgv1.ConfigurationSpec{
+			PackageSpec: xpkgv1.PackageSpec{
+				Package: tag.String(),
+			},
+		},
+	}
+
+	return cl.Patch(ctx, cfg, client.Apply, client.ForceOwnership, client.FieldOwner(fieldManagerName))
+}
+
+// newControlPlaneClient builds a client for a control plane that knows about
+// Crossplane package types.
+func newControlPlaneClient(config *rest.Config) (client.Client, error) {
+	cl, err := client.New(config, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bld := range ctpSchemeBuilders {
+		if err := bld.AddToScheme(cl.Scheme()); err != nil {
+			return nil, err
+		}
+	}
+
+	return cl, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"gotest.tools/v3/assert"
+
+	"github.com/upbound/up/internal/project"
+	"github.com/upbound/up/pkg/apis/project/v1alpha1"
+)
+
+func TestSimulationTag(t *testing.T) {
+	proj := &v1alpha1.Project{
+		Spec: &v1alpha1.ProjectSpec{
+			Repository: "xpkg.upbound.io/example/project",
+		},
+	}
+	cfgTag := name.MustParseReference("xpkg.upbound.io/example/project:" + project.ConfigurationTag).(name.Tag)
+	fnTag := name.MustParseReference("xpkg.upbound.io/example/project_fn:arm64").(name.Tag)
+	dgst, err := empty.Image.Digest()
+	assert.NilError(t, err)
+
+	tag, err := simulationTag(proj, project.ImageTagMap{cfgTag: empty.Image, fnTag: empty.Image})
+	assert.NilError(t, err)
+	assert.Equal(t, tag, "sim-"+dgst.Hex[:12])
+
+	// A build without a configuration package has nothing to simulate.
+	_, err = simulationTag(proj, project.ImageTagMap{fnTag: empty.Image})
+	assert.ErrorContains(t, err, "configuration package")
+}
@@ -15,5 +15,6 @@
 package billing
 
 type Cmd struct {
-	Export exportCmd `cmd:"" help:"Export a billing report for submission to Upbound."`
+	Export  exportCmd  `cmd:"" help:"Export a billing report for submission to Upbound."`
+	Summary summaryCmd `cmd:"" help:"Summarize the peak managed resource counts of a billing period."`
 }
@@ -27,20 +27,10 @@ import (
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/alecthomas/kong"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
-	gcpopt "google.golang.org/api/option"
 
-	usageaws "github.com/upbound/up/internal/usage/aws"
-	"github.com/upbound/up/internal/usage/azure"
-	"github.com/upbound/up/internal/usage/event"
-	"github.com/upbound/up/internal/usage/gcp"
+	"github.com/upbound/up/internal/usage/aggregate"
 	"github.com/upbound/up/internal/usage/report"
 	reporttar "github.com/upbound/up/internal/usage/report/file/tar"
 	usagetime "github.com/upbound/up/internal/usage/time"
@@ -50,6 +40,7 @@ const (
 	providerAWS   = "aws"
 	providerGCP   = "gcp"
 	providerAzure = "azure"
+	providerFile  = "file"
 
 	errFmtProviderNotSupported = "%q is not supported"
 )
@@ -87,12 +78,17 @@ type provider string
 
 func (p provider) Validate() error {
 	switch p {
+	case "":
+		// Read from the Spaces installation if not set.
+		return nil
 	case providerGCP:
 		return nil
 	case providerAWS:
 		return nil
 	case providerAzure:
 		return nil
+	case providerFile:
+		return nil
 	default:
 		return fmt.Errorf(errFmtProviderNotSupported, p)
 	}
@@ -101,17 +97,15 @@ func (p provider) Validate() error {
 type exportCmd struct {
 	Out string `optional:"" short:"o" env:"UP_BILLING_OUT" default:"upbound_billing_report.tgz" help:"Name of the output file."`
 
-	// TODO(branden): Make storage params optional and fetch missing values from spaces cluster.
-	Provider            provider `required:"" enum:"aws,gcp,azure," env:"UP_BILLING_PROVIDER" group:"Storage" help:"Storage provider. Must be one of: aws, gcp, azure."`
-	Bucket              string   `required:"" env:"UP_BILLING_BUCKET" group:"Storage" help:"Storage bucket."`
-	Endpoint            string   `env:"UP_BILLING_ENDPOINT" group:"Storage" help:"Custom storage endpoint."`
-	Account             string   `required:"" env:"UP_BILLING_ACCOUNT" group:"Storage" help:"Name of the Upbound account whose billing report is being collected."`
-	AzureStorageAccount string   `optional:"" env:"UP_AZURE_STORAGE_ACCOUNT" group:"Storage" help:"Name of the Azure storage account. Required for --provider=azure."`
+	storageFlags
+	Account string `required:"" env:"UP_BILLING_ACCOUNT" group:"Storage" help:"Name of the Upbound account whose billing report is being collected."`
 
 	BillingMonth    time.Time  `format:"2006-01" required:"" xor:"billingperiod" env:"UP_BILLING_MONTH" group:"Billing period" help:"Export a report for a billing period of one calendar month. Format: 2006-01."`
 	BillingCustom   *dateRange `required:"" xor:"billingperiod" env:"UP_BILLING_CUSTOM" group:"Billing period" help:"Export a report for a custom billing period. Date range is inclusive. Format: 2006-01-02/2006-01-02."`
 	ForceIncomplete bool       `env:"UP_BILLING_FORCE_INCOMPLETE" group:"Billing period" help:"Export a report for an incomplete billing period."`
 
+	Aggregation []string `default:"max_resource_count_per_gvk_per_mxp" env:"UP_BILLING_AGGREGATION" help:"Aggregations of usage events to include in the report. Must be one or more of: average_resource_count_per_gvk_per_mxp, max_resource_count_per_account, max_resource_count_per_gvk_per_mxp, resource_hours_per_gvk_per_mxp."`
+
 	outAbs        string
 	billingPeriod usagetime.Range
 }
@@ -124,13 +118,8 @@ func (c *exportCmd) Help() string {
 }
 
 func (c *exportCmd) Validate() error {
-	if c.Provider == providerAzure {
-		if c.AzureStorageAccount == "" {
-			return fmt.Errorf("--azure-storage-account must be set for --provider=azure")
-		}
-		if c.Endpoint != "" {
-			return fmt.Errorf("--endpoint is not supported for --provider=azure")
-		}
+	if err := validateAggregations(c.Aggregation); err != nil {
+		return err
 	}
 
 	// Get billing period.
@@ -158,6 +147,15 @@ func (c *exportCmd) Validate() error {
 	return nil
 }
 
+// AfterApply reads storage flags that were not set from the Spaces
+// installation and validates them.
+func (c *exportCmd) AfterApply() error {
+	if err := c.complete(); err != nil {
+		return err
+	}
+	return c.storageFlags.validate()
+}
+
 func (c *exportCmd) Run() error {
 	fmt.Printf(
 		"Exporting billing report for Upbound account %s from %s to %s.\n",
@@ -167,11 +165,7 @@ func (c *exportCmd) Run() error {
 	)
 	fmt.Printf("\n")
 	fmt.Printf("Reading usage data from storage...\n")
-	fmt.Printf("Provider: %s\n", c.Provider)
-	fmt.Printf("Bucket: %s\n", c.Bucket)
-	if c.Endpoint != "" {
-		fmt.Printf("Endpoint: %s\n", c.Endpoint)
-	}
+	c.storageFlags.print()
 
 	if err := c.collectReport(); err != nil {
 		c.cleanupOnError()
@@ -194,19 +188,7 @@ func (c *exportCmd) collectReport() error {
 	defer stop()
 
 	// Make event window iterator.
-	window := time.Hour
-	var iter event.WindowIterator
-	var err error
-	switch c.Provider {
-	case providerGCP:
-		iter, err = c.getGCPIter(ctx, window)
-	case providerAWS:
-		iter, err = c.getAWSIter(window)
-	case providerAzure:
-		iter, err = c.getAzureIter(window)
-	default:
-		return fmt.Errorf(errFmtProviderNotSupported, c.Provider)
-	}
+	iter, err := c.windowIterator(ctx, c.Account, c.billingPeriod, time.Hour)
 	if err != nil {
 		return err
 	}
@@ -223,13 +205,14 @@ func (c *exportCmd) collectReport() error {
 		UpboundAccount: c.Account,
 		TimeRange:      c.billingPeriod,
 		CollectedAt:    time.Now(),
+		Aggregations:   c.Aggregation,
 	})
 	if err != nil {
 		return errors.Wrap(err, "error creating report")
 	}
 
 	// Write report.
-	if err := report.MaxResourceCountPerGVKPerMXP(ctx, iter, rw); err != nil {
+	if err := report.Aggregate(ctx, iter, rw, c.Aggregation...); err != nil {
 		return err
 	}
 	if err := rw.Close(); err != nil {
@@ -241,62 +224,27 @@ func (c *exportCmd) collectReport() error {
 	return gw.Close()
 }
 
-func (c *exportCmd) getGCPIter(ctx context.Context, window time.Duration) (event.WindowIterator, error) {
-	opts := []gcpopt.ClientOption{}
-	if c.Endpoint != "" {
-		opts = append(opts, gcpopt.WithEndpoint(c.Endpoint))
-	}
-	gcsCli, err := storage.NewClient(ctx, opts...)
-	if err != nil {
-		return nil, errors.Wrap(err, "error creating storage client")
-	}
-	bkt := gcsCli.Bucket(c.Bucket)
-	return gcp.NewWindowIterator(bkt, c.Account, c.billingPeriod, window)
-}
-
-func (c *exportCmd) getAWSIter(window time.Duration) (event.WindowIterator, error) {
-	sess, err := session.NewSession(&aws.Config{})
-	if err != nil {
-		return nil, errors.Wrap(err, "error creating aws session")
-	}
-	config := &aws.Config{}
-	if c.Endpoint != "" {
-		config = &aws.Config{
-			Endpoint: aws.String(c.Endpoint),
-		}
-	}
-	s3client := s3.New(sess, config)
-	return usageaws.NewWindowIterator(s3client, c.Bucket, c.Account, c.billingPeriod, window)
-}
-
-func (c *exportCmd) getAzureIter(window time.Duration) (event.WindowIterator, error) {
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		return nil, err
-	}
-	cli, err := azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", c.AzureStorageAccount), cred, nil)
-	if err != nil {
-		return nil, err
-	}
-	containerCli := cli.ServiceClient().NewContainerClient(c.Bucket)
-	return azure.NewWindowIterator(containerCli, c.Account, c.billingPeriod, window)
+func (c *exportCmd) getBillingPeriod() (usagetime.Range, error) {
+	return billingPeriod(c.BillingMonth, c.BillingCustom)
 }
 
-func (c *exportCmd) getBillingPeriod() (usagetime.Range, error) {
-	if !c.BillingMonth.IsZero() {
-		start := time.Date(c.BillingMonth.Year(), c.BillingMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
+// billingPeriod returns the time range of a billing period of one calendar
+// month or of an inclusive custom date range.
+func billingPeriod(month time.Time, custom *dateRange) (usagetime.Range, error) {
+	if !month.IsZero() {
+		start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
 		return usagetime.Range{
 			Start: start,
 			End:   start.AddDate(0, 1, 0),
 		}, nil
 	}
 
-	if c.BillingCustom != nil {
+	if custom != nil {
 		return usagetime.Range{
 			Start: time.Date(
-				c.BillingCustom.Start.Year(),
-				c.BillingCustom.Start.Month(),
-				c.BillingCustom.Start.Day(),
+				custom.Start.Year(),
+				custom.Start.Month(),
+				custom.Start.Day(),
 				0,
 				0,
 				0,
@@ -304,9 +252,9 @@ func (c *exportCmd) getBillingPeriod() (usagetime.Range, error) {
 				time.UTC,
 			),
 			End: time.Date(
-				c.BillingCustom.End.Year(),
-				c.BillingCustom.End.Month(),
-				c.BillingCustom.End.Day(),
+				custom.End.Year(),
+				custom.End.Month(),
+				custom.End.Day(),
 				0,
 				0,
 				0,
@@ -319,6 +267,29 @@ func (c *exportCmd) getBillingPeriod() (usagetime.Range, error) {
 	return usagetime.Range{}, fmt.Errorf("billing period is not set")
 }
 
+// validateAggregations makes sure all aggregations are registered and none is
+// repeated.
+func validateAggregations(aggregations []string) error {
+	if len(aggregations) == 0 {
+		return fmt.Errorf("at least one aggregation must be set")
+	}
+	registered := map[string]bool{}
+	for _, n := range aggregate.Names() {
+		registered[n] = true
+	}
+	seen := map[string]bool{}
+	for _, a := range aggregations {
+		if !registered[a] {
+			return fmt.Errorf("--aggregation: %q is not supported, must be one of: %s", a, strings.Join(aggregate.Names(), ", "))
+		}
+		if seen[a] {
+			return fmt.Errorf("--aggregation: %q is set more than once", a)
+		}
+		seen[a] = true
+	}
+	return nil
+}
+
 func formatTimestamp(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
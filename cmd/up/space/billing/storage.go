@@ -0,0 +1,252 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package billing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	gcpopt "google.golang.org/api/option"
+
+	"github.com/upbound/up/internal/install/helm"
+	"github.com/upbound/up/internal/upbound"
+	usageaws "github.com/upbound/up/internal/usage/aws"
+	"github.com/upbound/up/internal/usage/azure"
+	"github.com/upbound/up/internal/usage/event"
+	"github.com/upbound/up/internal/usage/file"
+	"github.com/upbound/up/internal/usage/gcp"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+const (
+	spacesChart     = "spaces"
+	spacesNamespace = "upbound-system"
+)
+
+// storageFlags are the flags locating the usage data of a Space. Flags that are
+// not set are read from the Spaces installation in the kubeconfig context.
+type storageFlags struct {
+	Kube upbound.KubeFlags `embed:""`
+
+	Provider            provider `env:"UP_BILLING_PROVIDER" group:"Storage" help:"Storage provider. Must be one of: aws, gcp, azure, file."`
+	Bucket              string   `env:"UP_BILLING_BUCKET" group:"Storage" help:"Storage bucket."`
+	Endpoint            *string  `env:"UP_BILLING_ENDPOINT" group:"Storage" help:"Custom storage endpoint."`
+	AzureStorageAccount string   `optional:"" env:"UP_AZURE_STORAGE_ACCOUNT" group:"Storage" help:"Name of the Azure storage account. Required for --provider=azure."`
+	Region              string   `env:"UP_BILLING_REGION" group:"Storage" help:"Region of the storage bucket. Defaults to the region of the AWS environment, e.g. AWS_REGION. Only supported for --provider=aws."`
+	PathStyle           bool     `env:"UP_BILLING_PATH_STYLE" group:"Storage" help:"Use path-style addressing for S3-compatible storage such as MinIO. Only supported for --provider=aws."`
+	CABundle            string   `type:"existingfile" env:"UP_BILLING_CA_BUNDLE" group:"Storage" help:"Path to a PEM encoded CA bundle used to verify the storage endpoint. Only supported for --provider=aws."`
+	Dir                 string   `type:"existingdir" env:"UP_BILLING_DIR" group:"Storage" help:"Local directory containing usage data in the same layout as a storage bucket. Required for --provider=file."`
+}
+
+// complete sets the storage flags that were not set to the billing storage
+// settings of the Spaces installation in the kubeconfig context. Set
+// --endpoint="" to use the default endpoint without reading the installation.
+func (s *storageFlags) complete() error {
+	if s.Provider == providerFile || (s.Endpoint != nil && !s.missing()) {
+		return nil
+	}
+	values, err := s.spaceValues()
+	if err != nil {
+		if !s.missing() {
+			// Only the endpoint is missing, use the default endpoint.
+			return nil
+		}
+		return errors.Wrap(err, "cannot read billing storage settings from the Spaces installation, set --provider and --bucket")
+	}
+	s.setDefaults(storageFromValues(values))
+	return nil
+}
+
+// missing returns true if required storage flags are not set.
+func (s *storageFlags) missing() bool {
+	return s.Provider == "" || s.Bucket == "" || (s.Provider == providerAzure && s.AzureStorageAccount == "")
+}
+
+// setDefaults sets the storage flags that were not set to the values of from.
+// Settings for a different provider than the one set are ignored.
+func (s *storageFlags) setDefaults(from storageFlags) {
+	if s.Provider != "" && s.Provider != from.Provider {
+		return
+	}
+	s.Provider = from.Provider
+	if s.Bucket == "" {
+		s.Bucket = from.Bucket
+	}
+	if s.Endpoint == nil {
+		s.Endpoint = from.Endpoint
+	}
+	if s.AzureStorageAccount == "" {
+		s.AzureStorageAccount = from.AzureStorageAccount
+	}
+}
+
+// spaceValues returns the Helm release values of the Spaces installation in
+// the kubeconfig context.
+func (s *storageFlags) spaceValues() (map[string]any, error) {
+	if err := s.Kube.AfterApply(); err != nil {
+		return nil, err
+	}
+	mgr, err := helm.NewManager(s.Kube.GetConfig(), spacesChart, nil, helm.WithNamespace(spacesNamespace), helm.IsOCI())
+	if err != nil {
+		return nil, err
+	}
+	return mgr.GetCurrentValues()
+}
+
+// storageFromValues returns the billing storage settings of the Spaces Helm
+// release values.
+func storageFromValues(values map[string]any) storageFlags {
+	p := fieldpath.Pave(values)
+	get := func(path string) string {
+		v, _ := p.GetString(path)
+		return v
+	}
+
+	s := storageFlags{Provider: provider(get("billing.storage.provider"))}
+	switch s.Provider {
+	case providerAWS, providerGCP:
+		s.Bucket = get(fmt.Sprintf("billing.storage.%s.bucket", s.Provider))
+		if e, err := p.GetString(fmt.Sprintf("billing.storage.%s.endpoint", s.Provider)); err == nil {
+			s.Endpoint = &e
+		}
+	case providerAzure:
+		s.Bucket = get("billing.storage.azure.container")
+		s.AzureStorageAccount = get("billing.storage.azure.storageAccount")
+	}
+	return s
+}
+
+func (s *storageFlags) validate() error {
+	if s.Provider == "" {
+		return fmt.Errorf("--provider must be set")
+	}
+	if s.Provider == providerFile {
+		if s.Dir == "" {
+			return fmt.Errorf("--dir must be set for --provider=file")
+		}
+		if s.Bucket != "" || s.endpoint() != "" {
+			return fmt.Errorf("--bucket and --endpoint are not supported for --provider=file")
+		}
+	} else {
+		if s.Bucket == "" {
+			return fmt.Errorf("--bucket must be set for --provider=%s", s.Provider)
+		}
+		if s.Dir != "" {
+			return fmt.Errorf("--dir is only supported for --provider=file")
+		}
+	}
+	if s.Provider != providerAWS && (s.Region != "" || s.PathStyle || s.CABundle != "") {
+		return fmt.Errorf("--region, --path-style and --ca-bundle are only supported for --provider=aws")
+	}
+	if s.Provider == providerAzure {
+		if s.AzureStorageAccount == "" {
+			return fmt.Errorf("--azure-storage-account must be set for --provider=azure")
+		}
+		if s.endpoint() != "" {
+			return fmt.Errorf("--endpoint is not supported for --provider=azure")
+		}
+	}
+	return nil
+}
+
+func (s *storageFlags) print() {
+	fmt.Printf("Provider: %s\n", s.Provider)
+	if s.Provider == providerFile {
+		fmt.Printf("Directory: %s\n", s.Dir)
+		return
+	}
+	fmt.Printf("Bucket: %s\n", s.Bucket)
+	if s.endpoint() != "" {
+		fmt.Printf("Endpoint: %s\n", s.endpoint())
+	}
+}
+
+func (s *storageFlags) endpoint() string {
+	if s.Endpoint == nil {
+		return ""
+	}
+	return *s.Endpoint
+}
+
+// windowIterator returns an iterator over the usage events of the account in
+// the time range.
+func (s *storageFlags) windowIterator(ctx context.Context, account string, tr usagetime.Range, window time.Duration) (event.WindowIterator, error) {
+	switch s.Provider {
+	case providerGCP:
+		return s.getGCPIter(ctx, account, tr, window)
+	case providerAWS:
+		return s.getAWSIter(account, tr, window)
+	case providerAzure:
+		return s.getAzureIter(account, tr, window)
+	case providerFile:
+		return file.NewWindowIterator(s.Dir, account, tr, window)
+	default:
+		return nil, fmt.Errorf(errFmtProviderNotSupported, s.Provider)
+	}
+}
+
+func (s *storageFlags) getGCPIter(ctx context.Context, account string, tr usagetime.Range, window time.Duration) (event.WindowIterator, error) {
+	opts := []gcpopt.ClientOption{}
+	if s.endpoint() != "" {
+		opts = append(opts, gcpopt.WithEndpoint(s.endpoint()))
+	}
+	gcsCli, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating storage client")
+	}
+	bkt := gcsCli.Bucket(s.Bucket)
+	return gcp.NewWindowIterator(bkt, account, tr, window)
+}
+
+func (s *storageFlags) getAWSIter(account string, tr usagetime.Range, window time.Duration) (event.WindowIterator, error) {
+	opts := usageaws.ClientOptions{
+		Endpoint:  s.endpoint(),
+		Region:    s.Region,
+		PathStyle: s.PathStyle,
+	}
+	if s.CABundle != "" {
+		ca, err := os.ReadFile(s.CABundle)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading CA bundle")
+		}
+		opts.CABundle = bytes.NewReader(ca)
+	}
+	s3client, err := usageaws.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return usageaws.NewWindowIterator(s3client, s.Bucket, account, tr, window)
+}
+
+func (s *storageFlags) getAzureIter(account string, tr usagetime.Range, window time.Duration) (event.WindowIterator, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", s.AzureStorageAccount), cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	containerCli := cli.ServiceClient().NewContainerClient(s.Bucket)
+	return azure.NewWindowIterator(containerCli, account, tr, window)
+}
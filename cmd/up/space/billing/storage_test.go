@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package billing
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStorageFromValues(t *testing.T) {
+	endpoint := "https://minio.example.com"
+	empty := ""
+
+	type args struct {
+		flags  storageFlags
+		values map[string]any
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   storageFlags
+	}{
+		"AWS": {
+			reason: "Missing flags should be read from the AWS billing storage values.",
+			args: args{
+				values: map[string]any{"billing": map[string]any{"storage": map[string]any{
+					"provider": "aws",
+					"aws":      map[string]any{"bucket": "usage", "endpoint": endpoint},
+				}}},
+			},
+			want: storageFlags{Provider: providerAWS, Bucket: "usage", Endpoint: &endpoint},
+		},
+		"Azure": {
+			reason: "The Azure container and storage account should be read from the Azure billing storage values.",
+			args: args{
+				values: map[string]any{"billing": map[string]any{"storage": map[string]any{
+					"provider": "azure",
+					"azure":    map[string]any{"container": "usage", "storageAccount": "account"},
+				}}},
+			},
+			want: storageFlags{Provider: providerAzure, Bucket: "usage", AzureStorageAccount: "account"},
+		},
+		"FlagsTakePrecedence": {
+			reason: "Flags that are set should not be overridden.",
+			args: args{
+				flags: storageFlags{Bucket: "other", Endpoint: &empty},
+				values: map[string]any{"billing": map[string]any{"storage": map[string]any{
+					"provider": "gcp",
+					"gcp":      map[string]any{"bucket": "usage", "endpoint": endpoint},
+				}}},
+			},
+			want: storageFlags{Provider: providerGCP, Bucket: "other", Endpoint: &empty},
+		},
+		"DifferentProvider": {
+			reason: "Values for a different provider than the one set should be ignored.",
+			args: args{
+				flags: storageFlags{Provider: providerAWS},
+				values: map[string]any{"billing": map[string]any{"storage": map[string]any{
+					"provider": "gcp",
+					"gcp":      map[string]any{"bucket": "usage"},
+				}}},
+			},
+			want: storageFlags{Provider: providerAWS},
+		},
+		"NoBillingValues": {
+			reason: "Nothing should be set if the release has no billing values.",
+			args: args{
+				values: map[string]any{"account": "my-org"},
+			},
+			want: storageFlags{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.args.flags
+			got.setDefaults(storageFromValues(tc.args.values))
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(storageFlags{}), cmpopts.IgnoreFields(storageFlags{}, "Kube")); diff != "" {
+				t.Errorf("\n%s\nstorageFromValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/pterm/pterm"
+
+	"github.com/upbound/up/internal/usage/report"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+const (
+	outputText = "text"
+	outputCSV  = "csv"
+	outputJSON = "json"
+)
+
+type summaryCmd struct {
+	Output string `optional:"" short:"o" enum:"text,csv,json" default:"text" env:"UP_BILLING_SUMMARY_OUTPUT" help:"Output format. Must be one of: text, csv, json."`
+
+	storageFlags
+	Account string `required:"" env:"UP_BILLING_ACCOUNT" group:"Storage" help:"Name of the Upbound account whose usage is being summarized."`
+
+	BillingMonth    time.Time  `format:"2006-01" required:"" xor:"billingperiod" env:"UP_BILLING_MONTH" group:"Billing period" help:"Summarize a billing period of one calendar month. Format: 2006-01."`
+	BillingCustom   *dateRange `required:"" xor:"billingperiod" env:"UP_BILLING_CUSTOM" group:"Billing period" help:"Summarize a custom billing period. Date range is inclusive. Format: 2006-01-02/2006-01-02."`
+	ComparePrevious bool       `env:"UP_BILLING_COMPARE_PREVIOUS" group:"Billing period" help:"Compare with the previous billing period, i.e. the previous month for --billing-month or the preceding period of the same length for --billing-custom."`
+
+	billingPeriod usagetime.Range
+}
+
+func (c *summaryCmd) Help() string {
+	return `
+The summary command prints the peak number of managed resources per control
+plane, per GVK and per provider API group in a billing period, read from the
+same usage data as the billing export. Peaks are taken across hourly windows.
+
+The storage location of the usage data and the credentials of the storage
+provider are supplied as for the export command, see 'up space billing export
+--help'.
+
+Examples:
+    space billing summary --provider=aws --bucket=my-usage-bucket --account=my-org --billing-month=2024-05
+        Prints the peak managed resource counts of May 2024.
+
+    space billing summary --provider=aws --bucket=my-usage-bucket --account=my-org --billing-month=2024-05 --compare-previous -o csv
+        Prints the peak managed resource counts of May 2024 and April 2024 as CSV.
+`
+}
+
+func (c *summaryCmd) Validate() error {
+	var err error
+	c.billingPeriod, err = billingPeriod(c.BillingMonth, c.BillingCustom)
+	return errors.Wrap(err, "error getting billing period")
+}
+
+// AfterApply reads storage flags that were not set from the Spaces
+// installation and validates them.
+func (c *summaryCmd) AfterApply() error {
+	if err := c.complete(); err != nil {
+		return err
+	}
+	return c.storageFlags.validate()
+}
+
+// periodSummary is the summary of a billing period.
+type periodSummary struct {
+	TimeRange usagetime.Range `json:"time_range"`
+	report.Summary
+}
+
+// summaryOutput is the JSON output of the summary command.
+type summaryOutput struct {
+	UpboundAccount string         `json:"account"`
+	Current        periodSummary  `json:"current"`
+	Previous       *periodSummary `json:"previous,omitempty"`
+}
+
+func (c *summaryCmd) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Progress goes to stderr so that CSV and JSON output can be piped.
+	fmt.Fprintf(os.Stderr, "Reading usage data for Upbound account %s from storage...\n", c.Account)
+
+	out := summaryOutput{UpboundAccount: c.Account}
+	s, err := c.summarize(ctx, c.billingPeriod)
+	if err != nil {
+		return err
+	}
+	out.Current = periodSummary{TimeRange: c.billingPeriod, Summary: s}
+
+	if c.ComparePrevious {
+		prev := previousPeriod(c.billingPeriod, !c.BillingMonth.IsZero())
+		s, err := c.summarize(ctx, prev)
+		if err != nil {
+			return err
+		}
+		out.Previous = &periodSummary{TimeRange: prev, Summary: s}
+	}
+
+	switch c.Output {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case outputCSV:
+		return writeSummaryCSV(os.Stdout, out)
+	default:
+		return printSummary(out)
+	}
+}
+
+func (c *summaryCmd) summarize(ctx context.Context, tr usagetime.Range) (report.Summary, error) {
+	iter, err := c.windowIterator(ctx, c.Account, tr, time.Hour)
+	if err != nil {
+		return report.Summary{}, err
+	}
+	w := report.NewSummaryWriter()
+	if err := report.MaxResourceCountPerGVKPerMXP(ctx, iter, w); err != nil {
+		return report.Summary{}, err
+	}
+	return w.Summary(), nil
+}
+
+// previousPeriod returns the billing period preceding tr. The previous period
+// of a calendar month is the previous calendar month, otherwise it is the
+// period of the same length ending at the start of tr.
+func previousPeriod(tr usagetime.Range, month bool) usagetime.Range {
+	if month {
+		return usagetime.Range{Start: tr.Start.AddDate(0, -1, 0), End: tr.Start}
+	}
+	return usagetime.Range{Start: tr.Start.Add(-tr.End.Sub(tr.Start)), End: tr.Start}
+}
+
+// summaryRow is a row of a summary table.
+type summaryRow struct {
+	name     string
+	peak     int
+	previous int
+}
+
+// summaryTable is a section of the summary, e.g. the peaks per control plane.
+type summaryTable struct {
+	title  string
+	header string
+	rows   []summaryRow
+}
+
+// summaryTables returns the sections of the summary. Rows are sorted by
+// descending peak, and include names that only occur in the previous period.
+func summaryTables(out summaryOutput) []summaryTable {
+	var prev report.Summary
+	if out.Previous != nil {
+		prev = out.Previous.Summary
+	}
+	table := func(title, header string, cur, prev map[string]int) summaryTable {
+		t := summaryTable{title: title, header: header}
+		for k, v := range cur {
+			t.rows = append(t.rows, summaryRow{name: k, peak: v, previous: prev[k]})
+		}
+		for k, v := range prev {
+			if _, ok := cur[k]; !ok {
+				t.rows = append(t.rows, summaryRow{name: k, previous: v})
+			}
+		}
+		sort.Slice(t.rows, func(i, j int) bool {
+			if t.rows[i].peak != t.rows[j].peak {
+				return t.rows[i].peak > t.rows[j].peak
+			}
+			return t.rows[i].name < t.rows[j].name
+		})
+		return t
+	}
+	return []summaryTable{
+		table("Control planes", "CONTROL PLANE", out.Current.ControlPlanes, prev.ControlPlanes),
+		table("GVKs", "GVK", out.Current.GVKs, prev.GVKs),
+		table("API groups", "API GROUP", out.Current.APIGroups, prev.APIGroups),
+		{title: "Total", header: "TOTAL", rows: []summaryRow{{name: "all", peak: out.Current.Total, previous: prev.Total}}},
+	}
+}
+
+func formatChange(peak, previous int) string {
+	if previous == 0 {
+		if peak == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(peak-previous)/float64(previous)*100)
+}
+
+func printSummary(out summaryOutput) error {
+	pterm.Printfln("Peak managed resource counts for Upbound account %s from %s to %s.",
+		out.UpboundAccount, formatTimestamp(out.Current.TimeRange.Start), formatTimestamp(out.Current.TimeRange.End))
+	if out.Previous != nil {
+		pterm.Printfln("Compared with %s to %s.", formatTimestamp(out.Previous.TimeRange.Start), formatTimestamp(out.Previous.TimeRange.End))
+	}
+
+	for _, t := range summaryTables(out) {
+		pterm.DefaultSection.Println(t.title)
+		header := []string{t.header, "PEAK"}
+		if out.Previous != nil {
+			header = append(header, "PREVIOUS", "CHANGE")
+		}
+		data := pterm.TableData{header}
+		for _, r := range t.rows {
+			row := []string{r.name, strconv.Itoa(r.peak)}
+			if out.Previous != nil {
+				row = append(row, strconv.Itoa(r.previous), formatChange(r.peak, r.previous))
+			}
+			data = append(data, row)
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSummaryCSV(w io.Writer, out summaryOutput) error {
+	cw := csv.NewWriter(w)
+	header := []string{"section", "name", "peak"}
+	if out.Previous != nil {
+		header = append(header, "previous", "change")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, t := range summaryTables(out) {
+		for _, r := range t.rows {
+			row := []string{t.header, r.name, strconv.Itoa(r.peak)}
+			if out.Previous != nil {
+				row = append(row, strconv.Itoa(r.previous), formatChange(r.peak, r.previous))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package billing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/usage/report"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	type args struct {
+		tr    usagetime.Range
+		month bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   usagetime.Range
+	}{
+		"Month": {
+			reason: "The previous period of a calendar month is the previous calendar month.",
+			args: args{
+				tr: usagetime.Range{
+					Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+					End:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+				},
+				month: true,
+			},
+			want: usagetime.Range{
+				Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Custom": {
+			reason: "The previous period of a custom period has the same length.",
+			args: args{
+				tr: usagetime.Range{
+					Start: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+					End:   time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			want: usagetime.Range{
+				Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := previousPeriod(tc.args.tr, tc.args.month)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\npreviousPeriod(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWriteSummaryCSV(t *testing.T) {
+	current := report.Summary{
+		Total:         7,
+		ControlPlanes: map[string]int{"mxp1": 5, "mxp2": 2},
+		GVKs:          map[string]int{"Bucket.v1beta1.s3.aws.upbound.io": 7},
+		APIGroups:     map[string]int{"s3.aws.upbound.io": 7},
+	}
+	previous := report.Summary{
+		Total:         4,
+		ControlPlanes: map[string]int{"mxp1": 4, "mxp3": 1},
+		GVKs:          map[string]int{"Bucket.v1beta1.s3.aws.upbound.io": 4},
+		APIGroups:     map[string]int{"s3.aws.upbound.io": 4},
+	}
+
+	cases := map[string]struct {
+		reason string
+		out    summaryOutput
+		want   string
+	}{
+		"Current": {
+			reason: "Rows are sorted by descending peak.",
+			out:    summaryOutput{Current: periodSummary{Summary: current}},
+			want: `section,name,peak
+CONTROL PLANE,mxp1,5
+CONTROL PLANE,mxp2,2
+GVK,Bucket.v1beta1.s3.aws.upbound.io,7
+API GROUP,s3.aws.upbound.io,7
+TOTAL,all,7
+`,
+		},
+		"ComparePrevious": {
+			reason: "Rows include the previous peak and the change, and names only found in the previous period.",
+			out:    summaryOutput{Current: periodSummary{Summary: current}, Previous: &periodSummary{Summary: previous}},
+			want: `section,name,peak,previous,change
+CONTROL PLANE,mxp1,5,4,+25.0%
+CONTROL PLANE,mxp2,2,0,new
+CONTROL PLANE,mxp3,0,1,-100.0%
+GVK,Bucket.v1beta1.s3.aws.upbound.io,7,4,+75.0%
+API GROUP,s3.aws.upbound.io,7,4,+75.0%
+TOTAL,all,7,4,+75.0%
+`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := writeSummaryCSV(b, tc.out); err != nil {
+				t.Fatalf("\n%s\nwriteSummaryCSV(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("\n%s\nwriteSummaryCSV(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
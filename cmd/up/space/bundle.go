@@ -0,0 +1,361 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/cmd/up/space/mirror"
+	"github.com/upbound/up/cmd/up/space/prerequisites"
+	"github.com/upbound/up/internal/oci"
+)
+
+const (
+	// bundleChartsDir is the directory of a bundle that holds the chart
+	// archives of prerequisites, named <chart>-<version>.tgz.
+	bundleChartsDir = "charts"
+
+	errReadBundle          = "failed to read bundle"
+	errFmtReadArtifact     = "failed to read bundle artifact %s"
+	errFmtNoRepoTags       = "bundle artifact %s has no tag"
+	errFmtPushArtifact     = "failed to push %s"
+	errFmtDigestArtifact   = "failed to get digest of %s"
+	errFmtDigestMismatch   = "digest of %s is %s, expected %s"
+	errFmtOpenBundleChart  = "failed to open bundled chart %s"
+	errFmtChartLayers      = "chart %s has %d layers, expected 1"
+	errFmtTarballFile      = "file %s not found in %s"
+	errReadBundleManifest  = "failed to read bundle manifest"
+	errFmtFileDigest       = "digest of bundle file %s is %s, expected %s"
+	errFmtNoBundleManifest = "bundle has no %s manifest, export it again with 'up space mirror --to-dir'"
+	errFmtNoFileDigest     = "bundle file %s has no digest in the manifest"
+	errFmtUnlistedFile     = "bundle file %s is not listed in the %s manifest"
+
+	errFmtBundleChartVersions = "bundle has versions %[2]s of chart %[1]s, choose one with --prerequisite-versions"
+	errFmtBundleChartVersion  = "bundle does not have version %[2]s of chart %[1]s, only %[3]s"
+
+	errReadMirrorManifest      = "failed to read mirror manifest"
+	errNoMirroredCharts        = "mirror manifest does not list any chart mirrored to a registry, use a manifest written by 'up space mirror --to'"
+	errFmtMirroredChart        = "mirrored chart %s has unexpected destination %s"
+	errFmtMirroredToRegistries = "mirror manifest lists charts mirrored to both %s and %s"
+)
+
+// verifyBundle verifies the digests of the files of a bundle against its
+// manifest, and returns the manifest. Bundles without a manifest, files of the
+// manifest without a digest, and archives of the bundle that are not listed in
+// the manifest are rejected.
+func verifyBundle(dir string) (*mirror.Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, mirror.ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.Errorf(errFmtNoBundleManifest, mirror.ManifestFile)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errReadBundleManifest)
+	}
+	m := &mirror.Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, errors.Wrap(err, errReadBundleManifest)
+	}
+
+	listed := map[string]bool{}
+	for _, a := range m.Artifacts {
+		if a.File == "" {
+			continue
+		}
+		if a.FileDigest == "" {
+			return nil, errors.Errorf(errFmtNoFileDigest, a.File)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(a.File)) {
+			return nil, errors.Errorf(errFmtInvalidSource, a.File)
+		}
+		got, err := sha256File(filepath.Join(dir, filepath.FromSlash(a.File)))
+		if err != nil {
+			return nil, errors.Wrap(err, errReadBundle)
+		}
+		if got != a.FileDigest {
+			return nil, errors.Errorf(errFmtFileDigest, a.File, got, a.FileDigest)
+		}
+		listed[path.Clean(a.File)] = true
+	}
+
+	// Only the files of the manifest are pushed and installed, but archives
+	// that are not listed are rejected rather than silently ignored.
+	for _, pattern := range []string{"*.tgz", path.Join(bundleChartsDir, "*.tgz")} {
+		files, err := fs.Glob(os.DirFS(dir), pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, errReadBundle)
+		}
+		for _, f := range files {
+			if !listed[f] {
+				return nil, errors.Errorf(errFmtUnlistedFile, f, mirror.ManifestFile)
+			}
+		}
+	}
+	return m, nil
+}
+
+// mirroredRegistry returns the registry up space mirror --to mirrored the
+// artifacts of a manifest to, as given by the destinations of its charts.
+func mirroredRegistry(path string) (string, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", errors.Wrap(err, errReadMirrorManifest)
+	}
+	m := &mirror.Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return "", errors.Wrap(err, errReadMirrorManifest)
+	}
+
+	registry := ""
+	for _, a := range m.Artifacts {
+		// Charts are mirrored to <registry>/<name>:<version>, with their
+		// name and version as source.
+		if a.Repository == "" || a.Destination == "" {
+			continue
+		}
+		r, ok := strings.CutSuffix(a.Destination, "/"+a.Source)
+		if !ok || r == "" {
+			return "", errors.Errorf(errFmtMirroredChart, a.Source, a.Destination)
+		}
+		if registry != "" && r != registry {
+			return "", errors.Errorf(errFmtMirroredToRegistries, registry, r)
+		}
+		registry = r
+	}
+	if registry == "" {
+		return "", errors.New(errNoMirroredCharts)
+	}
+	return registry, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck // Only read from.
+	h, _, err := v1.SHA256(f)
+	if err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+// bundleArtifact is an OCI artifact of a bundle exported by up space mirror
+// --to-dir.
+type bundleArtifact struct {
+	// ref is the reference the artifact was exported from.
+	ref name.Tag
+	img v1.Image
+}
+
+// readBundle reads the OCI artifacts of a bundle listed in its manifest. Every
+// file of the manifest that is not a chart of a chart repository is expected
+// to be an image tarball written by up space mirror. Tarballs of Helm charts
+// are restored to Helm chart artifacts, as the tarball format does not retain
+// their media types.
+func readBundle(dir string, m *mirror.Manifest) ([]bundleArtifact, error) {
+	files := []string{}
+	for _, a := range m.Artifacts {
+		if a.File != "" && a.Repository == "" {
+			files = append(files, a.File)
+		}
+	}
+	sort.Strings(files)
+
+	artifacts := make([]bundleArtifact, 0, len(files))
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		a, err := readBundleArtifact(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtReadArtifact, p)
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, nil
+}
+
+func readBundleArtifact(path string) (bundleArtifact, error) {
+	m, err := tarball.LoadManifest(func() (io.ReadCloser, error) { return os.Open(filepath.Clean(path)) })
+	if err != nil {
+		return bundleArtifact{}, err
+	}
+	if len(m) != 1 || len(m[0].RepoTags) == 0 {
+		return bundleArtifact{}, errors.Errorf(errFmtNoRepoTags, path)
+	}
+	tag, err := name.NewTag(m[0].RepoTags[0])
+	if err != nil {
+		return bundleArtifact{}, err
+	}
+	img, err := tarball.ImageFromPath(path, &tag)
+	if err != nil {
+		return bundleArtifact{}, err
+	}
+
+	cfg, err := img.RawConfigFile()
+	if err != nil {
+		return bundleArtifact{}, err
+	}
+	if oci.IsChartConfig(cfg) {
+		// NOTE: the layers of tarballs are looked up by the diff IDs of the
+		// image config, which charts do not have.
+		if len(m[0].Layers) != 1 {
+			return bundleArtifact{}, errors.Errorf(errFmtChartLayers, tag, len(m[0].Layers))
+		}
+		b, err := readTarballFile(path, m[0].Layers[0])
+		if err != nil {
+			return bundleArtifact{}, err
+		}
+		img, err = oci.NewChartImage(cfg, b)
+		if err != nil {
+			return bundleArtifact{}, err
+		}
+	}
+	return bundleArtifact{ref: tag, img: img}, nil
+}
+
+// readTarballFile reads the file with the given name from a tarball.
+func readTarballFile(path, file string) ([]byte, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Only read from.
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Errorf(errFmtTarballFile, file, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == file {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// bundleDestination returns the reference an artifact is pushed to in the
+// registry to, which is the same as up space mirror --to uses.
+func bundleDestination(to string, a bundleArtifact) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(to, "/"), oci.RemoveDomainAndOrg(a.ref.String()))
+}
+
+// pushBundleArtifact pushes an artifact of a bundle to the registry to, and
+// verifies that the registry serves it with the digest of the artifact.
+func pushBundleArtifact(to string, a bundleArtifact, opts ...crane.Option) error {
+	dst := bundleDestination(to, a)
+	if err := crane.Push(a.img, dst, opts...); err != nil {
+		return errors.Wrapf(err, errFmtPushArtifact, dst)
+	}
+
+	want, err := a.img.Digest()
+	if err != nil {
+		return errors.Wrapf(err, errFmtDigestArtifact, a.ref)
+	}
+	got, err := crane.Digest(dst, opts...)
+	if err != nil {
+		return errors.Wrapf(err, errFmtDigestArtifact, dst)
+	}
+	if got != want.String() {
+		return errors.Errorf(errFmtDigestMismatch, dst, got, want)
+	}
+	return nil
+}
+
+// bundleCharts returns the chart archives of prerequisites listed in the
+// manifest of a bundle, by chart name. If the manifest lists several versions
+// of a chart, the one in versions is used. The version of a chart given in
+// versions must be in the bundle.
+func bundleCharts(dir string, m *mirror.Manifest, versions map[string]string) (charts map[string]prerequisites.Chart, err error) {
+	// Charts of chart repositories are exported as <name>:<version>.
+	files := map[string]map[string]string{}
+	for _, a := range m.Artifacts {
+		if a.File == "" || a.Repository == "" {
+			continue
+		}
+		n, v, ok := strings.Cut(a.Source, ":")
+		if !ok || n == "" || v == "" {
+			return nil, errors.Errorf(errFmtInvalidSource, a.Source)
+		}
+		if files[n] == nil {
+			files[n] = map[string]string{}
+		}
+		files[n][v] = a.File
+	}
+
+	charts = map[string]prerequisites.Chart{}
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, c := range charts {
+			_ = c.File.Close()
+		}
+	}()
+	for n, byVersion := range files {
+		v, err := bundleChartVersion(n, byVersion, versions)
+		if err != nil {
+			return nil, err
+		}
+		p := filepath.Join(dir, filepath.FromSlash(byVersion[v]))
+		f, err := os.Open(filepath.Clean(p))
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtOpenBundleChart, p)
+		}
+		charts[n] = prerequisites.Chart{File: f, Version: v}
+	}
+	return charts, nil
+}
+
+// bundleChartVersion returns the version of a chart to install among the
+// versions in a bundle.
+func bundleChartVersion(chart string, byVersion map[string]string, versions map[string]string) (string, error) {
+	bundled := make([]string, 0, len(byVersion))
+	for v := range byVersion {
+		bundled = append(bundled, v)
+	}
+	sort.Strings(bundled)
+
+	want, ok := versions[chart]
+	if !ok {
+		if len(bundled) > 1 {
+			return "", errors.Errorf(errFmtBundleChartVersions, chart, strings.Join(bundled, ", "))
+		}
+		return bundled[0], nil
+	}
+	for _, v := range bundled {
+		if strings.TrimPrefix(want, "v") == strings.TrimPrefix(v, "v") {
+			return v, nil
+		}
+	}
+	return "", errors.Errorf(errFmtBundleChartVersion, chart, want, strings.Join(bundled, ", "))
+}
@@ -0,0 +1,375 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	helmregistry "helm.sh/helm/v3/pkg/registry"
+
+	"github.com/upbound/up/cmd/up/space/mirror"
+	"github.com/upbound/up/internal/oci"
+)
+
+func TestPushBundle(t *testing.T) {
+	dir := t.TempDir()
+
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(t, img, "xpkg.upbound.io/spaces-artifacts/hyperspace:v1.9.0", filepath.Join(dir, "hyperspace-v1.9.0.tgz"))
+
+	chart, err := oci.NewChartImage([]byte(`{"apiVersion":"v2","name":"spaces","version":"1.9.0"}`), []byte("chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(t, chart, "xpkg.upbound.io/spaces-artifacts/spaces:1.9.0", filepath.Join(dir, "spaces-1.9.0.tgz"))
+
+	// Files that are not in the manifest, and charts of chart repositories,
+	// should not be pushed.
+	save(t, img, "xpkg.upbound.io/spaces-artifacts/stale:v1.8.0", filepath.Join(dir, "stale-v1.8.0.tgz"))
+	m := &mirror.Manifest{Artifacts: []mirror.Artifact{
+		{Source: "xpkg.upbound.io/spaces-artifacts/spaces:1.9.0", File: "spaces-1.9.0.tgz"},
+		{Source: "xpkg.upbound.io/spaces-artifacts/hyperspace:v1.9.0", File: "hyperspace-v1.9.0.tgz"},
+		{Source: "cert-manager:v1.11.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.11.0.tgz"},
+	}}
+
+	artifacts, err := readBundle(dir, m)
+	if err != nil {
+		t.Fatalf("readBundle(...): %v", err)
+	}
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	to := u.Host + "/mirror"
+
+	pushed := []string{}
+	for _, a := range artifacts {
+		pushed = append(pushed, a.ref.String())
+	}
+	if diff := cmp.Diff([]string{"xpkg.upbound.io/spaces-artifacts/hyperspace:v1.9.0", "xpkg.upbound.io/spaces-artifacts/spaces:1.9.0"}, pushed); diff != "" {
+		t.Errorf("\nOnly the artifacts of the manifest should be read.\nreadBundle(...): -want, +got:\n%s", diff)
+	}
+	for _, a := range artifacts {
+		if err := pushBundleArtifact(to, a); err != nil {
+			t.Fatalf("pushBundleArtifact(...): %v", err)
+		}
+	}
+
+	type artifact struct {
+		MediaType       types.MediaType
+		ConfigMediaType types.MediaType
+		LayerMediaTypes []types.MediaType
+	}
+	got := map[string]artifact{}
+	for _, ref := range []string{to + "/hyperspace:v1.9.0", to + "/spaces:1.9.0"} {
+		img, err := crane.Pull(ref)
+		if err != nil {
+			t.Fatalf("crane.Pull(%s): %v", ref, err)
+		}
+		m, err := img.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := artifact{MediaType: m.MediaType, ConfigMediaType: m.Config.MediaType}
+		for _, l := range m.Layers {
+			a.LayerMediaTypes = append(a.LayerMediaTypes, l.MediaType)
+		}
+		got[ref] = a
+	}
+
+	want := map[string]artifact{
+		to + "/hyperspace:v1.9.0": {
+			MediaType:       types.DockerManifestSchema2,
+			ConfigMediaType: types.DockerConfigJSON,
+			LayerMediaTypes: []types.MediaType{types.DockerLayer, types.DockerLayer},
+		},
+		to + "/spaces:1.9.0": {
+			MediaType:       types.OCIManifestSchema1,
+			ConfigMediaType: helmregistry.ConfigMediaType,
+			LayerMediaTypes: []types.MediaType{helmregistry.ChartLayerMediaType},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("\nImages should be pushed as is, and charts should be restored to Helm chart artifacts.\npushBundleArtifact(...): -want, +got:\n%s", diff)
+	}
+}
+
+// save writes img to a tarball at path, as up space mirror --to-dir does.
+func save(t *testing.T, img v1.Image, ref, path string) {
+	t.Helper()
+	tag, err := name.NewTag(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tarball.WriteToFile(path, tag, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	const (
+		content = "artifact"
+		digest  = "sha256:c7c5c1d70c5dec4416ab6158afd0b223ef40c29b1dc1f97ed9428b94d4cadb1c"
+	)
+
+	cases := map[string]struct {
+		reason   string
+		manifest string
+		want     error
+	}{
+		"NoManifest": {
+			reason: "Bundles without a manifest should be rejected.",
+			want:   errors.Errorf(errFmtNoBundleManifest, mirror.ManifestFile),
+		},
+		"NoFileDigest": {
+			reason: "Files without a digest in the manifest should be rejected.",
+			manifest: `artifacts:
+- source: xpkg.upbound.io/spaces-artifacts/spaces:1.9.0
+  digest: sha256:1234
+  file: spaces-1.9.0.tgz`,
+			want: errors.Errorf(errFmtNoFileDigest, "spaces-1.9.0.tgz"),
+		},
+		"Valid": {
+			reason: "Files with the digest in the manifest should be verified.",
+			manifest: `artifacts:
+- source: xpkg.upbound.io/spaces-artifacts/spaces:1.9.0
+  digest: sha256:1234
+  file: spaces-1.9.0.tgz
+  fileDigest: ` + digest + `
+- source: cert-manager:v1.11.0
+  repository: https://charts.jetstack.io
+  digest: sha256:1234
+  file: charts/cert-manager-v1.11.0.tgz
+  fileDigest: ` + digest,
+		},
+		"Unlisted": {
+			reason: "Archives that are not listed in the manifest should be rejected, as their digest cannot be verified.",
+			manifest: `artifacts:
+- source: xpkg.upbound.io/spaces-artifacts/spaces:1.9.0
+  digest: sha256:1234
+  file: spaces-1.9.0.tgz
+  fileDigest: ` + digest,
+			want: errors.Errorf(errFmtUnlistedFile, "charts/cert-manager-v1.11.0.tgz", mirror.ManifestFile),
+		},
+		"Mismatch": {
+			reason: "Files with another digest than in the manifest should be rejected.",
+			manifest: `artifacts:
+- source: cert-manager:v1.11.0
+  digest: sha256:0000
+  file: charts/cert-manager-v1.11.0.tgz
+  fileDigest: sha256:0000`,
+			want: errors.Errorf(errFmtFileDigest, "charts/cert-manager-v1.11.0.tgz", digest, "sha256:0000"),
+		},
+		"OutsideBundle": {
+			reason: "Files outside of the bundle should be rejected.",
+			manifest: `artifacts:
+- source: spaces:1.9.0
+  digest: sha256:0000
+  file: ../spaces-1.9.0.tgz
+  fileDigest: sha256:0000`,
+			want: errors.Errorf(errFmtInvalidSource, "../spaces-1.9.0.tgz"),
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range []string{"spaces-1.9.0.tgz", "charts/cert-manager-v1.11.0.tgz"} {
+				p := filepath.Join(dir, filepath.FromSlash(f))
+				if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.manifest != "" {
+				if err := os.WriteFile(filepath.Join(dir, mirror.ManifestFile), []byte(tc.manifest), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := verifyBundle(dir)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nverifyBundle(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestBundleCharts(t *testing.T) {
+	type want struct {
+		versions map[string]string
+		err      error
+	}
+	cases := map[string]struct {
+		reason    string
+		artifacts []mirror.Artifact
+		versions  map[string]string
+		want      want
+	}{
+		"Listed": {
+			reason: "Only the charts of chart repositories listed in the manifest should be returned.",
+			artifacts: []mirror.Artifact{
+				{Source: "cert-manager:v1.11.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.11.0.tgz"},
+				{Source: "ingress-nginx:4.7.1", Repository: "https://kubernetes.github.io/ingress-nginx", File: "charts/ingress-nginx-4.7.1.tgz"},
+				{Source: "xpkg.upbound.io/spaces-artifacts/spaces:1.9.0", File: "spaces-1.9.0.tgz"},
+			},
+			want: want{
+				versions: map[string]string{"cert-manager": "v1.11.0", "ingress-nginx": "4.7.1"},
+			},
+		},
+		"Override": {
+			reason: "The version given explicitly should be used among several versions of a chart, regardless of the v prefix.",
+			artifacts: []mirror.Artifact{
+				{Source: "cert-manager:v1.9.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.9.0.tgz"},
+				{Source: "cert-manager:v1.10.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.10.0.tgz"},
+			},
+			versions: map[string]string{"cert-manager": "1.10.0"},
+			want: want{
+				versions: map[string]string{"cert-manager": "v1.10.0"},
+			},
+		},
+		"SeveralVersions": {
+			reason: "Several versions of a chart should be rejected if none is given explicitly.",
+			artifacts: []mirror.Artifact{
+				{Source: "cert-manager:v1.9.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.9.0.tgz"},
+				{Source: "cert-manager:v1.10.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.10.0.tgz"},
+			},
+			want: want{
+				err: errors.Errorf(errFmtBundleChartVersions, "cert-manager", "v1.10.0, v1.9.0"),
+			},
+		},
+		"MissingVersion": {
+			reason: "A version given explicitly that is not in the bundle should be rejected.",
+			artifacts: []mirror.Artifact{
+				{Source: "cert-manager:v1.11.0", Repository: "https://charts.jetstack.io", File: "charts/cert-manager-v1.11.0.tgz"},
+			},
+			versions: map[string]string{"cert-manager": "v1.14.4"},
+			want: want{
+				err: errors.Errorf(errFmtBundleChartVersion, "cert-manager", "v1.14.4", "v1.11.0"),
+			},
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, a := range tc.artifacts {
+				p := filepath.Join(dir, filepath.FromSlash(a.File))
+				if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(a.Source), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			charts, err := bundleCharts(dir, &mirror.Manifest{Artifacts: tc.artifacts}, tc.versions)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nbundleCharts(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			got := map[string]string{}
+			for name, c := range charts {
+				got[name] = c.Version
+				if err := c.File.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.want.versions == nil {
+				tc.want.versions = map[string]string{}
+			}
+			if diff := cmp.Diff(tc.want.versions, got); diff != "" {
+				t.Errorf("\n%s\nbundleCharts(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMirroredRegistry(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		manifest string
+		want     string
+		err      error
+	}{
+		"Charts": {
+			reason: "The registry should be the one charts were mirrored to.",
+			manifest: `artifacts:
+- source: cert-manager:v1.14.4
+  repository: https://charts.jetstack.io
+  digest: sha256:0000
+  destination: registry.example.com/mirror/cert-manager:v1.14.4
+- source: quay.io/jetstack/cert-manager-controller:v1.14.4
+  digest: sha256:0000
+  destination: registry.example.com/mirror/cert-manager-controller:v1.14.4`,
+			want: "registry.example.com/mirror",
+		},
+		"ExportedCharts": {
+			reason: "Manifests of charts exported with --to-dir should be rejected.",
+			manifest: `artifacts:
+- source: cert-manager:v1.14.4
+  repository: https://charts.jetstack.io
+  digest: sha256:0000
+  file: charts/cert-manager-v1.14.4.tgz
+  fileDigest: sha256:0000`,
+			err: errors.New(errNoMirroredCharts),
+		},
+		"SeveralRegistries": {
+			reason: "Manifests of charts mirrored to several registries should be rejected.",
+			manifest: `artifacts:
+- source: cert-manager:v1.14.4
+  repository: https://charts.jetstack.io
+  digest: sha256:0000
+  destination: registry.example.com/mirror/cert-manager:v1.14.4
+- source: ingress-nginx:4.10.0
+  repository: https://kubernetes.github.io/ingress-nginx
+  digest: sha256:0000
+  destination: registry.example.com/other/ingress-nginx:4.10.0`,
+			err: errors.Errorf(errFmtMirroredToRegistries, "registry.example.com/mirror", "registry.example.com/other"),
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), mirror.ManifestFile)
+			if err := os.WriteFile(path, []byte(tc.manifest), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := mirroredRegistry(path)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nmirroredRegistry(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nmirroredRegistry(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -20,10 +20,14 @@ import (
 	"os"
 
 	"github.com/alecthomas/kong"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/pterm/pterm"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	spacefeature "github.com/upbound/up/cmd/up/space/features"
+	"github.com/upbound/up/cmd/up/space/prerequisites"
 	"github.com/upbound/up/internal/input"
 	"github.com/upbound/up/internal/install/helm"
 	"github.com/upbound/up/internal/upbound"
@@ -34,6 +38,8 @@ import (
 const (
 	confirmStr      = "CONFIRMED"
 	nsUpboundSystem = "upbound-system"
+
+	errOrphanWithPrerequisites = "--with-prerequisites cannot be used with --orphan"
 )
 
 // destroyCmd uninstalls Upbound.
@@ -44,6 +50,18 @@ type destroyCmd struct {
 
 	Confirmed bool `name:"yes-really-delete-space-and-all-data" type:"bool" help:"Bypass safety checks and destroy Spaces"`
 	Orphan    bool `name:"orphan" type:"bool" help:"Remove Space components but retain Control Planes and data"`
+
+	WithPrerequisites bool `name:"with-prerequisites" type:"bool" help:"Also uninstall the prerequisites of the Space, in reverse order of installation."`
+
+	prereqs *prerequisites.Manager
+}
+
+// Validate performs custom argument validation for the destroy command.
+func (c *destroyCmd) Validate() error {
+	if c.Orphan && c.WithPrerequisites {
+		return errors.New(errOrphanWithPrerequisites)
+	}
+	return nil
 }
 
 // AfterApply sets default values in command after assignment and validation.
@@ -89,6 +107,23 @@ func (c *destroyCmd) AfterApply(kongCtx *kong.Context) error {
 	}
 	kongCtx.Bind(mgr)
 
+	if c.WithPrerequisites {
+		// NOTE: the prerequisites depend on the version and the features of
+		// the installed Space, which are gone once it is uninstalled.
+		version, err := mgr.GetCurrentVersion()
+		if err != nil {
+			return errors.Wrap(err, errFailedGettingCurrentVersion)
+		}
+		features := &feature.Flags{}
+		if values, err := mgr.GetCurrentValues(); err == nil {
+			spacefeature.EnableFeatures(features, values)
+		}
+		c.prereqs, err = prerequisites.New(kubeconfig, nil, features, version)
+		if err != nil {
+			return err
+		}
+	}
+
 	// NOTE(tnthornton) we currently only have support for stylized output.
 	pterm.EnableStyling()
 	upterm.DefaultObjPrinter.Pretty = true
@@ -116,6 +151,9 @@ func (c *destroyCmd) confirm() {
 		pterm.Warning.Println("Destroying Spaces is a destructive command that will destroy data and orphan resources.")
 		pterm.Warning.Println("Before proceeding ensure that Managed Resources in Control Planes have been deleted.")
 		pterm.Warning.Println("All Spaces components including Control Planes will be destroyed.")
+		if c.WithPrerequisites {
+			pterm.Warning.Println("The prerequisites of Spaces, e.g. cert-manager and ingress-nginx, will be uninstalled.")
+		}
 		pterm.Println()
 		pterm.Warning.Println("If you want to retain data, abort and run 'up space destroy --orphan'")
 		pterm.Println()
@@ -145,5 +183,34 @@ func (c *destroyCmd) Run(ctx context.Context, kClient *kubernetes.Clientset, mgr
 		return nil
 	}
 
+	if c.prereqs != nil {
+		if err := uninstallPrereqs(c.prereqs.Prerequisites()); err != nil {
+			return err
+		}
+	}
+
 	return kClient.CoreV1().Namespaces().Delete(ctx, nsUpboundSystem, v1.DeleteOptions{})
 }
+
+// uninstallPrereqs uninstalls prerequisites in the reverse order of their
+// installation, so that no prerequisite is uninstalled before the ones that
+// depend on it.
+func uninstallPrereqs(prereqs []prerequisites.Prerequisite) error {
+	for i := range prereqs {
+		p := prereqs[len(prereqs)-1-i]
+		if err := upterm.WrapWithSuccessSpinner(
+			upterm.StepCounter(
+				fmt.Sprintf("Uninstalling %s", p.GetName()),
+				i+1,
+				len(prereqs),
+			),
+			upterm.CheckmarkSuccessSpinner,
+			p.Uninstall,
+		); err != nil {
+			fmt.Println()
+			fmt.Println()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,551 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/cmd/create"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/feature"
+
+	spacesv1beta1 "github.com/upbound/up-sdk-go/apis/spaces/v1beta1"
+	spacefeature "github.com/upbound/up/cmd/up/space/features"
+	"github.com/upbound/up/cmd/up/space/prerequisites"
+	"github.com/upbound/up/internal/install"
+	"github.com/upbound/up/internal/install/helm"
+	"github.com/upbound/up/internal/profile"
+	"github.com/upbound/up/internal/spaces"
+	"github.com/upbound/up/internal/upbound"
+	"github.com/upbound/up/internal/upterm"
+)
+
+const (
+	// helmReleaseNameAnnotation is set by Helm on every resource of a release.
+	helmReleaseNameAnnotation = "meta.helm.sh/release-name"
+
+	errChecksFailed = "one or more checks failed"
+
+	hintClusterAccess = "Check that the kubeconfig grants access to the cluster."
+)
+
+var doctorFieldNames = []string{"STATUS", "CHECK", "MESSAGE", "HINT"}
+
+// checkStatus is the status of a doctor check.
+type checkStatus string
+
+const (
+	checkPass checkStatus = "pass"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+// checkResult is the result of a doctor check. A hint on how to remediate the
+// problem is given for checks that do not pass.
+type checkResult struct {
+	Name    string      `json:"name"`
+	Status  checkStatus `json:"status"`
+	Message string      `json:"message"`
+	Hint    string      `json:"hint,omitempty"`
+}
+
+// namespacedPrerequisite is a Prerequisite that is installed as a Helm release
+// of the same name in a namespace.
+type namespacedPrerequisite interface {
+	prerequisites.Prerequisite
+	Namespace() string
+}
+
+// pingRegistryFn authenticates to the registry of a repository with pull
+// access to the repository.
+type pingRegistryFn func(ctx context.Context, repo name.Repository, auth authn.Authenticator) error
+
+// lookupHostFn resolves a host name.
+type lookupHostFn func(ctx context.Context, host string) ([]string, error)
+
+// doctorCmd checks the health of an Upbound Spaces deployment.
+type doctorCmd struct {
+	Kube     upbound.KubeFlags `embed:""`
+	Registry registryFlags     `embed:""`
+
+	Version string `arg:"" optional:"" help:"Upbound Spaces version whose prerequisites are checked. Defaults to the installed version."`
+
+	kClient      kubernetes.Interface
+	client       client.Client
+	helmMgr      install.Manager
+	newPrereqs   func(version string, features *feature.Flags) (*prerequisites.Manager, error)
+	pingRegistry pingRegistryFn
+	lookupHost   lookupHostFn
+}
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *doctorCmd) AfterApply() error {
+	if err := c.Kube.AfterApply(); err != nil {
+		return err
+	}
+	kubeconfig := c.Kube.GetConfig()
+
+	kClient, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	c.kClient = kClient
+
+	cl, err := client.New(kubeconfig, client.Options{})
+	if err != nil {
+		return err
+	}
+	c.client = cl
+
+	mgr, err := helm.NewManager(kubeconfig,
+		spacesChart,
+		c.Registry.Repository,
+		helm.WithNamespace(ns),
+		helm.IsOCI(),
+	)
+	if err != nil {
+		return err
+	}
+	c.helmMgr = mgr
+
+	c.newPrereqs = func(version string, features *feature.Flags) (*prerequisites.Manager, error) {
+		return prerequisites.New(kubeconfig, nil, features, version)
+	}
+	c.pingRegistry = pingRegistry
+	c.lookupHost = net.DefaultResolver.LookupHost
+	return nil
+}
+
+// Run executes the doctor command.
+func (c *doctorCmd) Run(ctx context.Context, printer upterm.Printer) error {
+	results := c.checks(ctx)
+	if err := printer.Print(results, doctorFieldNames, extractCheckFields); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Status == checkFail {
+			return errors.New(errChecksFailed)
+		}
+	}
+	return nil
+}
+
+func (c *doctorCmd) checks(ctx context.Context) []checkResult {
+	results := []checkResult{}
+
+	version, spacesResult := checkSpacesRelease(c.helmMgr)
+	results = append(results, spacesResult)
+	if c.Version != "" {
+		version = c.Version
+	}
+
+	if version == "" {
+		results = append(results, checkResult{
+			Name:    "prerequisites",
+			Status:  checkWarn,
+			Message: "cannot determine the prerequisites without a Spaces version",
+			Hint:    "Pass the Spaces version to check the prerequisites of, e.g. 'up space doctor v1.7.0'.",
+		})
+	} else {
+		results = append(results, c.checkPrerequisites(ctx, version)...)
+	}
+
+	if spacesResult.Status == checkPass {
+		results = append(results,
+			checkWorkloads(ctx, c.kClient, spacesChart, ns, spacesChart,
+				"Inspect the failing workloads with 'kubectl -n upbound-system describe'."),
+			checkControlPlanes(ctx, c.client),
+		)
+	}
+	results = append(results,
+		checkIngress(ctx, c.kClient, c.lookupHost),
+		checkPullSecret(ctx, c.kClient, c.Registry, c.pingRegistry),
+	)
+	return results
+}
+
+// checkSpacesRelease checks that Spaces is installed, and returns its version.
+func checkSpacesRelease(mgr install.Manager) (string, checkResult) {
+	r := checkResult{Name: spacesChart}
+	version, err := mgr.GetCurrentVersion()
+	if err != nil {
+		r.Status = checkFail
+		r.Message = err.Error()
+		r.Hint = "Install Upbound Spaces with 'up space init'."
+		return "", r
+	}
+	r.Status = checkPass
+	r.Message = fmt.Sprintf("version %s is installed", version)
+	return version, r
+}
+
+// checkPrerequisites checks that the prerequisites of a Spaces version are
+// installed, and that the workloads of those installed with Helm are ready.
+func (c *doctorCmd) checkPrerequisites(ctx context.Context, version string) []checkResult {
+	// The enabled features determine some of the prerequisites.
+	features := &feature.Flags{}
+	if values, err := c.helmMgr.GetCurrentValues(); err == nil {
+		spacefeature.EnableFeatures(features, values)
+	}
+	mgr, err := c.newPrereqs(version, features)
+	if err != nil {
+		return []checkResult{{
+			Name:    "prerequisites",
+			Status:  checkFail,
+			Message: err.Error(),
+		}}
+	}
+
+	results := []checkResult{}
+	for _, p := range mgr.Prerequisites() {
+		installed, err := p.IsInstalled()
+		switch {
+		case err != nil:
+			results = append(results, checkResult{
+				Name:    p.GetName(),
+				Status:  checkFail,
+				Message: err.Error(),
+				Hint:    hintClusterAccess,
+			})
+			continue
+		case !installed:
+			results = append(results, checkResult{
+				Name:    p.GetName(),
+				Status:  checkFail,
+				Message: "not installed",
+				Hint:    fmt.Sprintf("Install the missing prerequisites with 'up space init %s'.", version),
+			})
+			continue
+		}
+
+		np, ok := p.(namespacedPrerequisite)
+		if !ok {
+			results = append(results, checkResult{
+				Name:    p.GetName(),
+				Status:  checkPass,
+				Message: "installed",
+			})
+			continue
+		}
+		results = append(results, checkWorkloads(ctx, c.kClient, np.GetName(), np.Namespace(), np.GetName(),
+			fmt.Sprintf("Inspect the failing workloads with 'kubectl -n %s describe'.", np.Namespace())))
+	}
+	return results
+}
+
+// checkWorkloads checks that the deployments, stateful sets and daemon sets of
+// a Helm release are ready.
+func checkWorkloads(ctx context.Context, kClient kubernetes.Interface, check, namespace, release, hint string) checkResult { //nolint:gocyclo // Just a lot of listing.
+	r := checkResult{Name: check}
+	fail := func(err error) checkResult {
+		r.Status = checkFail
+		r.Message = err.Error()
+		r.Hint = hintClusterAccess
+		return r
+	}
+	inRelease := func(m metav1.ObjectMeta) bool {
+		return m.Annotations[helmReleaseNameAnnotation] == release
+	}
+
+	total := 0
+	var notReady []string
+	deploys, err := kClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, d := range deploys.Items {
+		if !inRelease(d.ObjectMeta) {
+			continue
+		}
+		total++
+		if msg, ready := deploymentReady(d); !ready {
+			notReady = append(notReady, fmt.Sprintf("deployment/%s %s", d.Name, msg))
+		}
+	}
+	sets, err := kClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, s := range sets.Items {
+		if !inRelease(s.ObjectMeta) {
+			continue
+		}
+		total++
+		want := int32(1)
+		if s.Spec.Replicas != nil {
+			want = *s.Spec.Replicas
+		}
+		if s.Status.ReadyReplicas < want {
+			notReady = append(notReady, fmt.Sprintf("statefulset/%s %d/%d ready", s.Name, s.Status.ReadyReplicas, want))
+		}
+	}
+	daemons, err := kClient.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fail(err)
+	}
+	for _, d := range daemons.Items {
+		if !inRelease(d.ObjectMeta) {
+			continue
+		}
+		total++
+		if d.Status.NumberReady < d.Status.DesiredNumberScheduled {
+			notReady = append(notReady, fmt.Sprintf("daemonset/%s %d/%d ready", d.Name, d.Status.NumberReady, d.Status.DesiredNumberScheduled))
+		}
+	}
+
+	switch {
+	case total == 0:
+		r.Status = checkWarn
+		r.Message = fmt.Sprintf("installed, but no workloads of release %q found in namespace %q", release, namespace)
+		r.Hint = "Check that the release was installed by up, or reinstall it."
+	case len(notReady) > 0:
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("%d/%d workloads ready: %s", total-len(notReady), total, strings.Join(notReady, "; "))
+		r.Hint = hint
+	default:
+		r.Status = checkPass
+		r.Message = fmt.Sprintf("%d/%d workloads ready", total, total)
+	}
+	return r
+}
+
+// deploymentReady returns whether a deployment is ready, and if not a message
+// with the number of ready replicas and the reason it is not available.
+func deploymentReady(d appsv1.Deployment) (string, bool) {
+	want := int32(1)
+	if d.Spec.Replicas != nil {
+		want = *d.Spec.Replicas
+	}
+	var cond *appsv1.DeploymentCondition
+	for i := range d.Status.Conditions {
+		if d.Status.Conditions[i].Type == appsv1.DeploymentAvailable {
+			cond = &d.Status.Conditions[i]
+		}
+	}
+	if d.Status.ReadyReplicas >= want && (cond == nil || cond.Status == corev1.ConditionTrue) {
+		return "", true
+	}
+	msg := fmt.Sprintf("%d/%d ready", d.Status.ReadyReplicas, want)
+	if cond != nil && cond.Status != corev1.ConditionTrue {
+		msg = fmt.Sprintf("%s (%s: %s)", msg, cond.Reason, cond.Message)
+	}
+	return msg, false
+}
+
+// checkControlPlanes checks the conditions of the control planes in all
+// groups. It fails if a control plane is not ready, and warns if one is still
+// being provisioned or is ready but not healthy.
+func checkControlPlanes(ctx context.Context, cl client.Client) checkResult {
+	r := checkResult{Name: "controlplanes"}
+	var l spacesv1beta1.ControlPlaneList
+	if err := cl.List(ctx, &l); err != nil {
+		r.Status = checkFail
+		r.Message = err.Error()
+		r.Hint = hintClusterAccess
+		if meta.IsNoMatchError(err) {
+			r.Message = "control plane API not found"
+			r.Hint = "Check that the Spaces CRDs are installed, or reinstall Spaces with 'up space upgrade'."
+		}
+		return r
+	}
+
+	var failed, warned []string
+	for _, ctp := range l.Items {
+		id := fmt.Sprintf("%s/%s", ctp.Namespace, ctp.Name)
+		ready := ctp.GetCondition(xpv1.TypeReady)
+		healthy := ctp.GetCondition(spacesv1beta1.ConditionTypeHealthy)
+		switch {
+		case ready.Status == corev1.ConditionFalse:
+			failed = append(failed, fmt.Sprintf("%s not ready (%s)", id, conditionMessage(ready, ctp.Status.Message)))
+		case ready.Status != corev1.ConditionTrue:
+			warned = append(warned, fmt.Sprintf("%s is being provisioned", id))
+		case healthy.Status == corev1.ConditionFalse:
+			warned = append(warned, fmt.Sprintf("%s not healthy (%s)", id, conditionMessage(healthy, ctp.Status.Message)))
+		}
+	}
+
+	total := len(l.Items)
+	switch {
+	case len(failed) > 0:
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("%d/%d control planes ready: %s", total-len(failed)-len(warned), total, strings.Join(append(failed, warned...), "; "))
+		r.Hint = "Inspect the failing control planes with 'kubectl -n <group> describe controlplane <name>'."
+	case len(warned) > 0:
+		r.Status = checkWarn
+		r.Message = fmt.Sprintf("%d/%d control planes ready: %s", total-len(warned), total, strings.Join(warned, "; "))
+		r.Hint = "Wait for the control planes to become ready, or inspect them with 'kubectl -n <group> describe controlplane <name>'."
+	default:
+		r.Status = checkPass
+		r.Message = fmt.Sprintf("%d/%d control planes ready", total, total)
+	}
+	return r
+}
+
+// conditionMessage returns the reason and message of a condition, falling back
+// to the message of the control plane if the condition has none.
+func conditionMessage(c xpv1.Condition, fallback string) string {
+	msg := c.Message
+	if msg == "" {
+		msg = fallback
+	}
+	if msg == "" {
+		return string(c.Reason)
+	}
+	return fmt.Sprintf("%s: %s", c.Reason, msg)
+}
+
+// checkIngress checks that the public ingress of Spaces has a host that
+// resolves and a valid CA.
+func checkIngress(ctx context.Context, kClient kubernetes.Interface, lookupHost lookupHostFn) checkResult {
+	r := checkResult{Name: "ingress"}
+	host, ca, err := profile.GetIngressHost(ctx, kClient.CoreV1())
+	switch {
+	case kerrors.IsNotFound(err):
+		r.Status = checkWarn
+		r.Message = "public ingress configuration not found"
+		r.Hint = "Control planes cannot be accessed through the public ingress. Upgrade Spaces with 'up space upgrade' if the installed version predates it."
+		return r
+	case err != nil:
+		r.Status = checkFail
+		r.Message = err.Error()
+		r.Hint = hintClusterAccess
+		return r
+	case host == "":
+		r.Status = checkFail
+		r.Message = "public ingress host is not set"
+		r.Hint = "Configure the ingress host in the Spaces values and apply it with 'up space upgrade'."
+		return r
+	}
+
+	if err := spaces.EnsureCertificateAuthorityData(string(ca)); err != nil {
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("public ingress CA of %s is invalid: %v", host, err)
+		r.Hint = "Check the certificate issuer of the ingress in the upbound-system namespace."
+		return r
+	}
+
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if net.ParseIP(hostname) == nil {
+		if _, err := lookupHost(ctx, hostname); err != nil {
+			r.Status = checkWarn
+			r.Message = fmt.Sprintf("public ingress host %s does not resolve: %v", host, err)
+			r.Hint = "Create a DNS record for the ingress host that points to the ingress load balancer."
+			return r
+		}
+	}
+
+	r.Status = checkPass
+	r.Message = fmt.Sprintf("public ingress host %s resolves and has a valid CA", host)
+	return r
+}
+
+// checkPullSecret checks that the registry pull secret of Spaces exists and
+// grants pull access to the Spaces artifacts.
+func checkPullSecret(ctx context.Context, kClient kubernetes.Interface, reg registryFlags, ping pingRegistryFn) checkResult {
+	r := checkResult{Name: "pull-secret"}
+	fail := func(msg, hint string) checkResult {
+		r.Status = checkFail
+		r.Message = msg
+		r.Hint = hint
+		return r
+	}
+	recreate := fmt.Sprintf("Recreate the %s secret with 'up space upgrade --token-file=<file>'.", defaultImagePullSecret)
+
+	s, err := kClient.CoreV1().Secrets(ns).Get(ctx, defaultImagePullSecret, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return fail(fmt.Sprintf("secret %s/%s not found", ns, defaultImagePullSecret), recreate)
+	}
+	if err != nil {
+		return fail(err.Error(), hintClusterAccess)
+	}
+
+	auth, err := pullSecretAuth(s, reg.Endpoint.Host)
+	if err != nil {
+		return fail(err.Error(), recreate)
+	}
+
+	repo, err := name.NewRepository(fmt.Sprintf("%s/%s", strings.TrimSuffix(reg.Repository.String(), "/"), spacesChart))
+	if err != nil {
+		return fail(err.Error(), "Check the --registry-repository flag.")
+	}
+	if err := ping(ctx, repo, auth); err != nil {
+		return fail(fmt.Sprintf("cannot pull from %s: %v", repo, err), recreate)
+	}
+
+	r.Status = checkPass
+	r.Message = fmt.Sprintf("secret grants pull access to %s", repo)
+	return r
+}
+
+// pullSecretAuth returns the credentials of a docker config pull secret for a
+// registry host.
+func pullSecretAuth(s *corev1.Secret, host string) (authn.Authenticator, error) {
+	cfg := &create.DockerConfigJSON{}
+	if err := json.Unmarshal(s.Data[corev1.DockerConfigJsonKey], cfg); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse secret %s/%s", s.Namespace, s.Name)
+	}
+	for reg, e := range cfg.Auths {
+		// Registries are keyed by host or by URL, e.g. https://xpkg.upbound.io.
+		reg = strings.TrimPrefix(strings.TrimPrefix(reg, "https://"), "http://")
+		if reg, _, _ = strings.Cut(reg, "/"); reg != host {
+			continue
+		}
+		if e.Username == "" && e.Auth != "" {
+			b, err := base64.StdEncoding.DecodeString(e.Auth)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot parse credentials of secret %s/%s", s.Namespace, s.Name)
+			}
+			e.Username, e.Password, _ = strings.Cut(string(b), ":")
+		}
+		return &authn.Basic{Username: e.Username, Password: e.Password}, nil
+	}
+	return nil, errors.Errorf("secret %s/%s has no credentials for %s", s.Namespace, s.Name, host)
+}
+
+// pingRegistry authenticates to the registry of a repository with pull access
+// to the repository.
+func pingRegistry(ctx context.Context, repo name.Repository, auth authn.Authenticator) error {
+	_, err := transport.NewWithContext(ctx, repo.Registry, auth, http.DefaultTransport, []string{repo.Scope(transport.PullScope)})
+	return err
+}
+
+func extractCheckFields(obj any) []string {
+	r, ok := obj.(checkResult)
+	if !ok {
+		return []string{"unknown", "unknown", "", ""}
+	}
+	return []string{strings.ToUpper(string(r.Status)), r.Name, r.Message, r.Hint}
+}
@@ -0,0 +1,365 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	spacesv1beta1 "github.com/upbound/up-sdk-go/apis/spaces/v1beta1"
+)
+
+func TestCheckWorkloads(t *testing.T) {
+	meta := func(name, release string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "cert-manager",
+			Annotations: map[string]string{helmReleaseNameAnnotation: release},
+		}
+	}
+	deploy := func(name, release string, ready int32, cond *appsv1.DeploymentCondition) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: meta(name, release),
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](1)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+		}
+		if cond != nil {
+			d.Status.Conditions = []appsv1.DeploymentCondition{*cond}
+		}
+		return d
+	}
+
+	cases := map[string]struct {
+		reason  string
+		objects []runtime.Object
+		want    checkResult
+	}{
+		"Ready": {
+			reason: "The check should pass if all workloads of the release are ready.",
+			objects: []runtime.Object{
+				deploy("cert-manager", "cert-manager", 1, nil),
+				deploy("other", "other", 0, nil),
+				&appsv1.DaemonSet{
+					ObjectMeta: meta("agent", "cert-manager"),
+					Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberReady: 2},
+				},
+			},
+			want: checkResult{Name: "check", Status: checkPass, Message: "2/2 workloads ready"},
+		},
+		"NotReady": {
+			reason: "The check should fail with the reason workloads of the release are not ready.",
+			objects: []runtime.Object{
+				deploy("cert-manager", "cert-manager", 0, &appsv1.DeploymentCondition{
+					Type:    appsv1.DeploymentAvailable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "MinimumReplicasUnavailable",
+					Message: "Deployment does not have minimum availability.",
+				}),
+				&appsv1.StatefulSet{
+					ObjectMeta: meta("db", "cert-manager"),
+					Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](3)},
+					Status:     appsv1.StatefulSetStatus{ReadyReplicas: 3},
+				},
+			},
+			want: checkResult{
+				Name:    "check",
+				Status:  checkFail,
+				Message: "1/2 workloads ready: deployment/cert-manager 0/1 ready (MinimumReplicasUnavailable: Deployment does not have minimum availability.)",
+				Hint:    "hint",
+			},
+		},
+		"NoWorkloads": {
+			reason: "The check should warn if the release has no workloads.",
+			objects: []runtime.Object{
+				deploy("other", "other", 1, nil),
+			},
+			want: checkResult{
+				Name:    "check",
+				Status:  checkWarn,
+				Message: `installed, but no workloads of release "cert-manager" found in namespace "cert-manager"`,
+				Hint:    "Check that the release was installed by up, or reinstall it.",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kClient := fake.NewSimpleClientset(tc.objects...)
+			got := checkWorkloads(context.Background(), kClient, "check", "cert-manager", "cert-manager", "hint")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ncheckWorkloads(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckControlPlanes(t *testing.T) {
+	ctp := func(name string, conds ...xpv1.Condition) client.Object {
+		c := &spacesv1beta1.ControlPlane{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		c.SetConditions(conds...)
+		return c
+	}
+	healthy := xpv1.Condition{Type: spacesv1beta1.ConditionTypeHealthy, Status: corev1.ConditionTrue}
+
+	cases := map[string]struct {
+		reason  string
+		objects []client.Object
+		want    checkResult
+	}{
+		"NoControlPlanes": {
+			reason: "The check should pass if there are no control planes.",
+			want:   checkResult{Name: "controlplanes", Status: checkPass, Message: "0/0 control planes ready"},
+		},
+		"Ready": {
+			reason:  "The check should pass if all control planes are ready and healthy.",
+			objects: []client.Object{ctp("a", xpv1.Available(), healthy)},
+			want:    checkResult{Name: "controlplanes", Status: checkPass, Message: "1/1 control planes ready"},
+		},
+		"Provisioning": {
+			reason:  "The check should warn if a control plane is still being provisioned.",
+			objects: []client.Object{ctp("a", xpv1.Available(), healthy), ctp("b")},
+			want: checkResult{
+				Name:    "controlplanes",
+				Status:  checkWarn,
+				Message: "1/2 control planes ready: default/b is being provisioned",
+				Hint:    "Wait for the control planes to become ready, or inspect them with 'kubectl -n <group> describe controlplane <name>'.",
+			},
+		},
+		"NotHealthy": {
+			reason: "The check should warn if a ready control plane is not healthy.",
+			objects: []client.Object{ctp("a", xpv1.Available(), xpv1.Condition{
+				Type: spacesv1beta1.ConditionTypeHealthy, Status: corev1.ConditionFalse, Reason: spacesv1beta1.ReasonUnhealthy, Message: "crossplane is not ready",
+			})},
+			want: checkResult{
+				Name:    "controlplanes",
+				Status:  checkWarn,
+				Message: "0/1 control planes ready: default/a not healthy (UnhealthyControlPlane: crossplane is not ready)",
+				Hint:    "Wait for the control planes to become ready, or inspect them with 'kubectl -n <group> describe controlplane <name>'.",
+			},
+		},
+		"NotReady": {
+			reason:  "The check should fail if a control plane is not ready.",
+			objects: []client.Object{ctp("a", xpv1.Unavailable().WithMessage("provisioning failed"))},
+			want: checkResult{
+				Name:    "controlplanes",
+				Status:  checkFail,
+				Message: "0/1 control planes ready: default/a not ready (Unavailable: provisioning failed)",
+				Hint:    "Inspect the failing control planes with 'kubectl -n <group> describe controlplane <name>'.",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			if err := spacesv1beta1.AddToScheme(s); err != nil {
+				t.Fatal(err)
+			}
+			cl := crfake.NewClientBuilder().WithScheme(s).WithObjects(tc.objects...).Build()
+			got := checkControlPlanes(context.Background(), cl)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ncheckControlPlanes(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckIngress(t *testing.T) {
+	ca := testCA(t)
+	ingress := func(host, ca string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress-public", Namespace: "upbound-system"},
+			Data:       map[string]string{"ingress-host": host, "ingress-ca": ca},
+		}
+	}
+	resolves := func(context.Context, string) ([]string, error) { return []string{"10.0.0.1"}, nil }
+
+	type args struct {
+		objects    []runtime.Object
+		lookupHost lookupHostFn
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   checkStatus
+	}{
+		"Valid": {
+			reason: "The check should pass if the host resolves and the CA is valid.",
+			args: args{
+				objects:    []runtime.Object{ingress("https://proxy.example.com", ca)},
+				lookupHost: resolves,
+			},
+			want: checkPass,
+		},
+		"NotConfigured": {
+			reason: "The check should warn if the public ingress is not configured.",
+			args: args{
+				lookupHost: resolves,
+			},
+			want: checkWarn,
+		},
+		"InvalidCA": {
+			reason: "The check should fail if the CA is not a PEM certificate.",
+			args: args{
+				objects:    []runtime.Object{ingress("proxy.example.com", "not a certificate")},
+				lookupHost: resolves,
+			},
+			want: checkFail,
+		},
+		"DoesNotResolve": {
+			reason: "The check should warn if the host does not resolve.",
+			args: args{
+				objects: []runtime.Object{ingress("proxy.example.com:8443", ca)},
+				lookupHost: func(context.Context, string) ([]string, error) {
+					return nil, errors.New("no such host")
+				},
+			},
+			want: checkWarn,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kClient := fake.NewSimpleClientset(tc.args.objects...)
+			got := checkIngress(context.Background(), kClient, tc.args.lookupHost)
+			if diff := cmp.Diff(tc.want, got.Status); diff != "" {
+				t.Errorf("\n%s\ncheckIngress(...): -want, +got:\n%s\n%s", tc.reason, diff, got.Message)
+			}
+		})
+	}
+}
+
+func TestCheckPullSecret(t *testing.T) {
+	repo, _ := url.Parse("xpkg.upbound.io/spaces-artifacts")
+	endpoint, _ := url.Parse("https://xpkg.upbound.io")
+	reg := registryFlags{Repository: repo, Endpoint: endpoint}
+	secret := func(cfg string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: defaultImagePullSecret, Namespace: "upbound-system"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(cfg)},
+		}
+	}
+
+	type want struct {
+		status checkStatus
+		auth   authn.Authenticator
+		repo   string
+	}
+	cases := map[string]struct {
+		reason  string
+		objects []runtime.Object
+		pingErr error
+		want    want
+	}{
+		"Valid": {
+			reason:  "The check should pass if the credentials for the registry endpoint grant pull access.",
+			objects: []runtime.Object{secret(`{"auths":{"https://xpkg.upbound.io":{"username":"id","password":"token"}}}`)},
+			want: want{
+				status: checkPass,
+				auth:   &authn.Basic{Username: "id", Password: "token"},
+				repo:   "xpkg.upbound.io/spaces-artifacts/spaces",
+			},
+		},
+		"AuthOnly": {
+			reason:  "Credentials should be read from the auth field if there is no username.",
+			objects: []runtime.Object{secret(`{"auths":{"xpkg.upbound.io":{"auth":"aWQ6dG9rZW4="}}}`)},
+			want: want{
+				status: checkPass,
+				auth:   &authn.Basic{Username: "id", Password: "token"},
+				repo:   "xpkg.upbound.io/spaces-artifacts/spaces",
+			},
+		},
+		"Denied": {
+			reason:  "The check should fail if the credentials do not grant pull access.",
+			objects: []runtime.Object{secret(`{"auths":{"https://xpkg.upbound.io":{"username":"id","password":"token"}}}`)},
+			pingErr: errors.New("unauthorized"),
+			want: want{
+				status: checkFail,
+				auth:   &authn.Basic{Username: "id", Password: "token"},
+				repo:   "xpkg.upbound.io/spaces-artifacts/spaces",
+			},
+		},
+		"OtherRegistry": {
+			reason:  "The check should fail if the secret has no credentials for the registry endpoint.",
+			objects: []runtime.Object{secret(`{"auths":{"https://registry.example.com":{"username":"id","password":"token"}}}`)},
+			want: want{
+				status: checkFail,
+			},
+		},
+		"NotFound": {
+			reason: "The check should fail if the secret does not exist.",
+			want: want{
+				status: checkFail,
+			},
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			var gotAuth authn.Authenticator
+			var gotRepo string
+			ping := func(_ context.Context, repo name.Repository, auth authn.Authenticator) error {
+				gotAuth, gotRepo = auth, repo.String()
+				return tc.pingErr
+			}
+			got := checkPullSecret(context.Background(), fake.NewSimpleClientset(tc.objects...), reg, ping)
+			if diff := cmp.Diff(tc.want, want{status: got.Status, auth: gotAuth, repo: gotRepo}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\ncheckPullSecret(...): -want, +got:\n%s\n%s", tc.reason, diff, got.Message)
+			}
+		})
+	}
+}
+
+// testCA returns a self-signed PEM encoded CA certificate.
+func testCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
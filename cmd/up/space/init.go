@@ -18,11 +18,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/alecthomas/kong"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
 	"golang.org/x/exp/maps"
 	"helm.sh/helm/v3/pkg/chart"
 	corev1 "k8s.io/api/core/v1"
@@ -41,15 +47,19 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
+	spacesv1beta1 "github.com/upbound/up-sdk-go/apis/spaces/v1beta1"
 	upboundv1alpha1 "github.com/upbound/up-sdk-go/apis/upbound/v1alpha1"
 	"github.com/upbound/up/cmd/up/space/defaults"
 	spacefeature "github.com/upbound/up/cmd/up/space/features"
+	"github.com/upbound/up/cmd/up/space/mirror"
 	"github.com/upbound/up/cmd/up/space/prerequisites"
 	"github.com/upbound/up/internal/config"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
 	"github.com/upbound/up/internal/kube"
+	"github.com/upbound/up/internal/oci"
 	"github.com/upbound/up/internal/resources"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/upterm"
 	"github.com/upbound/up/internal/version"
@@ -84,6 +94,13 @@ const (
 	errCreateImagePullSecret  = "failed to create image pull secret"
 	errFmtCreateNamespace     = "failed to create namespace %q"
 	errCreateSpace            = "failed to create Space"
+	errRenderToWithoutDryRun  = "--render-to can only be used with --dry-run"
+	errRenderSpace            = "failed to render Space"
+	errFromBundleWithoutTo    = "--from-bundle and --to must be used together"
+	errFromBundleWithDryRun   = "--from-bundle cannot be used with --dry-run"
+	errFromBundleWithVerify   = "--from-bundle cannot be used with --verify, bundles are verified against their manifest"
+	errEmptyBundle            = "bundle does not contain any artifacts"
+	errMirrorManifestBundle   = "--mirror-manifest cannot be used with --from-bundle, prerequisites of bundles are installed from --to"
 )
 
 // initCmd installs Upbound Spaces.
@@ -91,20 +108,29 @@ type initCmd struct {
 	Kube     upbound.KubeFlags       `embed:""`
 	Registry authorizedRegistryFlags `embed:""`
 	install.CommonParams
-	Upbound upbound.Flags `embed:""`
-
-	Version       string `arg:"" help:"Upbound Spaces version to install."`
-	Yes           bool   `name:"yes" type:"bool" help:"Answer yes to all questions"`
-	PublicIngress bool   `name:"public-ingress" type:"bool" help:"For AKS,EKS,GKE expose ingress publically"`
-
-	helmMgr    install.Manager
-	prereqs    *prerequisites.Manager
-	helmParams map[string]any
-	kClient    kubernetes.Interface
-	dClient    dynamic.Interface
-	pullSecret *kube.ImagePullApplicator
-	quiet      config.QuietFlag
-	features   *feature.Flags
+	Upbound upbound.Flags         `embed:""`
+	Verify  signature.VerifyFlags `embed:""`
+
+	Version              string            `arg:"" help:"Upbound Spaces version to install."`
+	Yes                  bool              `name:"yes" type:"bool" help:"Answer yes to all questions"`
+	PublicIngress        bool              `name:"public-ingress" type:"bool" help:"For AKS,EKS,GKE expose ingress publically"`
+	PrerequisiteVersions map[string]string `name:"prerequisite-versions" placeholder:"NAME=VERSION;..." help:"Install prerequisites at these versions instead of the defaults, e.g. cert-manager=v1.14.4."`
+	RenderTo             string            `name:"render-to" type:"path" placeholder:"DIR" help:"With --dry-run, write the rendered manifests and values of the Spaces chart to this directory instead of stdout."`
+	FromBundle           string            `name:"from-bundle" type:"existingdir" placeholder:"DIR" help:"Install from a bundle exported by 'up space mirror --to-dir'. Only the files listed in the manifest of the bundle are pushed and installed, after verifying their digests. Prerequisite charts are installed from the charts directory of the bundle. Requires --to."`
+	To                   string            `name:"to" placeholder:"REGISTRY" help:"With --from-bundle, the private registry to push the bundled artifacts to and to install Spaces and the images of prerequisites from."`
+	MirrorManifest       string            `name:"mirror-manifest" type:"existingfile" placeholder:"FILE" help:"Install prerequisites from the registry they were mirrored to by 'up space mirror --to', as listed in the manifest it wrote. Cannot be used with --from-bundle."`
+
+	helmMgr        install.Manager
+	prereqs        *prerequisites.Manager
+	helmParams     map[string]any
+	kClient        kubernetes.Interface
+	dClient        dynamic.Interface
+	pullSecret     *kube.ImagePullApplicator
+	quiet          config.QuietFlag
+	dryRun         bool
+	features       *feature.Flags
+	bundle         []bundleArtifact
+	bundleManifest *mirror.Manifest
 }
 
 func init() {
@@ -113,6 +139,7 @@ func init() {
 	kruntime.ErrorHandlers = []func(error){} //nolint:reassign
 
 	kruntime.Must(upboundv1alpha1.AddToScheme(scheme.Scheme))
+	kruntime.Must(spacesv1beta1.AddToScheme(scheme.Scheme))
 }
 
 // BeforeApply sets default values in login before assignment and validation.
@@ -122,7 +149,39 @@ func (c *initCmd) BeforeApply() error {
 }
 
 // AfterApply sets default values in command after assignment and validation.
-func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) error { //nolint:gocyclo
+func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag, printer upterm.ObjectPrinter) error { //nolint:gocyclo
+	c.dryRun = printer.DryRun
+	if c.RenderTo != "" && !c.dryRun {
+		return errors.New(errRenderToWithoutDryRun)
+	}
+	if c.dryRun && c.RenderTo == "" {
+		// NOTE: rendered manifests are written to stdout, so that they can be
+		// piped to a file or to kubectl. Everything else goes to stderr.
+		pterm.SetDefaultOutput(os.Stderr)
+	}
+
+	if (c.FromBundle == "") != (c.To == "") {
+		return errors.New(errFromBundleWithoutTo)
+	}
+	if c.FromBundle != "" && c.MirrorManifest != "" {
+		return errors.New(errMirrorManifestBundle)
+	}
+	if c.FromBundle != "" {
+		if c.dryRun {
+			return errors.New(errFromBundleWithDryRun)
+		}
+		if c.Verify.Verify != "" && c.Verify.Verify != signature.PolicyNone {
+			return errors.New(errFromBundleWithVerify)
+		}
+		// Spaces is installed from the registry the bundle is pushed to.
+		repo, err := url.Parse(c.To)
+		if err != nil {
+			return err
+		}
+		c.Registry.Repository = repo
+		c.Registry.Endpoint = &url.URL{Scheme: "https", Host: strings.SplitN(c.To, "/", 2)[0]}
+	}
+
 	if err := c.Kube.AfterApply(); err != nil {
 		return err
 	}
@@ -134,6 +193,49 @@ func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) erro
 	pterm.EnableStyling()
 	upterm.DefaultObjPrinter.Pretty = true
 
+	verifier, err := c.Verify.Verifier(remote.WithAuth(&authn.Basic{
+		Username: c.Registry.Username,
+		Password: c.Registry.Password,
+	}))
+	if err != nil {
+		return err
+	}
+
+	prereqOpts := []prerequisites.Option{
+		prerequisites.WithVersions(c.PrerequisiteVersions),
+	}
+	if c.FromBundle != "" {
+		manifest, err := verifyBundle(c.FromBundle)
+		if err != nil {
+			return err
+		}
+		bundle, err := readBundle(c.FromBundle, manifest)
+		if err != nil {
+			return err
+		}
+		if len(bundle) == 0 {
+			return errors.New(errEmptyBundle)
+		}
+		c.bundle = bundle
+		c.bundleManifest = manifest
+	}
+	if c.MirrorManifest != "" {
+		// Prerequisites are installed from the registry they were mirrored
+		// to, with the credentials of the Spaces registry.
+		registry, err := mirroredRegistry(c.MirrorManifest)
+		if err != nil {
+			return err
+		}
+		repo, err := url.Parse(registry)
+		if err != nil {
+			return err
+		}
+		prereqOpts = append(prereqOpts,
+			prerequisites.WithChartRepository(repo, c.Registry.Username, c.Registry.Password),
+			prerequisites.WithRegistry(registry),
+		)
+	}
+
 	upCtx, err := upbound.NewFromFlags(c.Upbound)
 	if err != nil {
 		return err
@@ -184,6 +286,7 @@ func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) erro
 		helm.WithBasicAuth(c.Registry.Username, c.Registry.Password),
 		helm.IsOCI(),
 		helm.WithChart(c.Bundle),
+		helm.WithVerifier(verifier),
 		helm.Wait(),
 	)
 	if err != nil {
@@ -213,8 +316,22 @@ func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) erro
 	c.features = &feature.Flags{}
 	spacefeature.EnableFeatures(c.features, c.helmParams)
 
-	prereqs, err := prerequisites.New(kubeconfig, defs, c.features, c.Version)
+	// Chart archives of the bundle are opened last, they are closed by the
+	// Manager once the prerequisites are installed.
+	charts := map[string]prerequisites.Chart{}
+	if c.FromBundle != "" {
+		charts, err = bundleCharts(c.FromBundle, c.bundleManifest, c.PrerequisiteVersions)
+		if err != nil {
+			return err
+		}
+		prereqOpts = append(prereqOpts, prerequisites.WithCharts(charts), prerequisites.WithRegistry(c.To))
+	}
+
+	prereqs, err := prerequisites.New(kubeconfig, defs, c.features, c.Version, prereqOpts...)
 	if err != nil {
+		for _, ch := range charts {
+			_ = ch.File.Close()
+		}
 		return err
 	}
 	c.prereqs = prereqs
@@ -225,9 +342,18 @@ func (c *initCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) erro
 
 // Run executes the install command.
 func (c *initCmd) Run(ctx context.Context, upCtx *upbound.Context) error { //nolint:gocyclo
+	defer c.prereqs.Close() //nolint:errcheck // Only read from.
+
 	overrideRegistry(c.Registry.Repository.String(), c.helmParams)
 	ensureAccount(upCtx, c.helmParams)
 
+	if c.dryRun {
+		if c.helmParams["account"] == defaultAcct {
+			pterm.Warning.Println("No account name was provided. Spaces initialized without an account name cannot be attached to the Upbound console!")
+		}
+		return c.renderSpace()
+	}
+
 	if c.helmParams["account"] == defaultAcct {
 		pterm.Warning.Println("No account name was provided. Spaces initialized without an account name cannot be attached to the Upbound console! This cannot be changed later.")
 		confirm := pterm.DefaultInteractiveConfirm
@@ -239,6 +365,10 @@ func (c *initCmd) Run(ctx context.Context, upCtx *upbound.Context) error { //nol
 		}
 	}
 
+	if err := c.pushBundle(ctx); err != nil {
+		return err
+	}
+
 	// check if required prerequisites are installed
 	status, err := c.prereqs.Check()
 	if err != nil {
@@ -288,6 +418,87 @@ func (c *initCmd) Run(ctx context.Context, upCtx *upbound.Context) error { //nol
 	return nil
 }
 
+// pushBundle pushes the artifacts of the bundle to the registry given with
+// --to and verifies their digests.
+func (c *initCmd) pushBundle(ctx context.Context) error {
+	if len(c.bundle) == 0 {
+		return nil
+	}
+
+	auth := crane.WithAuthFromKeychain(authn.DefaultKeychain)
+	if c.Registry.Username != "" || c.Registry.Password != "" {
+		auth = crane.WithAuth(&authn.Basic{Username: c.Registry.Username, Password: c.Registry.Password})
+	}
+	opts := []crane.Option{crane.WithContext(ctx), auth}
+
+	pterm.Info.Printfln("Pushing bundle to %s...", c.To)
+	for i, a := range c.bundle {
+		if err := upterm.WrapWithSuccessSpinner(
+			upterm.StepCounter(
+				fmt.Sprintf("Pushing %s", oci.RemoveDomainAndOrg(a.ref.String())),
+				i+1,
+				len(c.bundle),
+			),
+			upterm.CheckmarkSuccessSpinner,
+			func() error { return pushBundleArtifact(c.To, a, opts...) },
+		); err != nil {
+			fmt.Println()
+			fmt.Println()
+			return err
+		}
+	}
+	return nil
+}
+
+// renderSpace lists the missing prerequisites that would be installed and
+// renders the Spaces chart with the values it would be installed with, without
+// changing anything in the cluster.
+func (c *initCmd) renderSpace() error {
+	status, err := c.prereqs.Check()
+	if err != nil {
+		pterm.Error.Println("error checking prerequisites status")
+		return err
+	}
+	if len(status.NotInstalled) == 0 {
+		pterm.Info.Println("Required prerequisites met!")
+	} else {
+		pterm.Warning.Println("The following required prerequisites are not installed and would be installed:")
+		data := pterm.TableData{{"NAME", "VERSION"}}
+		for _, p := range status.NotInstalled {
+			data = append(data, []string{p.GetName(), p.Version()})
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+			return err
+		}
+	}
+
+	values, err := yaml.Marshal(c.helmParams)
+	if err != nil {
+		return errors.Wrap(err, errRenderSpace)
+	}
+	pterm.Info.Printfln("Upbound Spaces %s would be installed with the following values:", c.Version)
+	pterm.Println(string(values))
+
+	manifests, err := c.helmMgr.Render(strings.TrimPrefix(c.Version, "v"), c.helmParams, initVersionBounds, upVersionBounds)
+	if err != nil {
+		return errors.Wrap(err, errRenderSpace)
+	}
+	if c.RenderTo == "" {
+		_, err := fmt.Fprint(os.Stdout, manifests)
+		return err
+	}
+
+	fs := afero.NewOsFs()
+	if err := writeValues(fs, c.RenderTo, values); err != nil {
+		return err
+	}
+	if err := writeManifests(fs, c.RenderTo, manifests); err != nil {
+		return err
+	}
+	pterm.Info.Printfln("Rendered manifests and values written to %s", c.RenderTo)
+	return nil
+}
+
 func installPrereqs(status *prerequisites.Status) error {
 	for i, p := range status.NotInstalled {
 		if err := upterm.WrapWithSuccessSpinner(
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
@@ -27,10 +28,14 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pterm/pterm"
 	"gopkg.in/yaml.v3"
+	k8syaml "sigs.k8s.io/yaml"
 
 	"github.com/upbound/up/internal/oci"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/upterm"
 )
 
@@ -38,6 +43,14 @@ type Cmd struct {
 	ToDir   string `optional:"" help:"Specify the path to the local directory where images will be exported as .tgz files." short:"t"`
 	To      string `optional:"" help:"Specify the destination registry." short:"d"`
 	Version string `required:"" help:"Specify the specific Spaces version for which you want to mirror the images." short:"v"`
+
+	Manifest string `optional:"" type:"path" help:"Path of the manifest file that lists the mirrored artifacts with their digests. Defaults to manifest.yaml in the --to-dir directory, or in the current directory with --to. Pass it to 'up space init --mirror-manifest' to install prerequisites from --to."`
+
+	Verify signature.VerifyFlags `embed:""`
+
+	manifest Manifest
+	mirrored map[string]bool
+	verifier *signature.Verifier
 }
 
 type spinner struct {
@@ -100,13 +113,29 @@ func (c *Cmd) Run(ctx context.Context, printer upterm.ObjectPrinter) (rErr error
 		crane.WithAuthFromKeychain(authn.DefaultKeychain),
 	}
 
+	c.verifier, err = c.Verify.Verifier(remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+
+	c.manifest = Manifest{Version: c.Version}
+	c.mirrored = map[string]bool{}
+
 	for _, repo := range artifacts.OCI {
 		if err := c.mirrorWithExtraImages(ctx, printer, repo, craneOpts); err != nil {
 			return errors.Wrap(err, "mirror artifacts failed")
 		}
 	}
 
+	if err := c.mirrorPrerequisites(printer, craneOpts); err != nil {
+		return errors.Wrap(err, "mirror prerequisites failed")
+	}
+
 	if !printer.DryRun {
+		if err := c.writeManifest(); err != nil {
+			return err
+		}
+
 		if len(c.ToDir) > 1 {
 			pterm.Println("\nSuccessfully exported artifacts for Spaces!")
 		}
@@ -233,10 +262,21 @@ func (c *Cmd) mirrorWithExtraImages(ctx context.Context, printer upterm.ObjectPr
 	return nil
 }
 
-func (c *Cmd) mirrorArtifact(printer upterm.ObjectPrinter, artifact string, craneOpts []crane.Option) error {
+func (c *Cmd) mirrorArtifact(printer upterm.ObjectPrinter, artifact string, craneOpts []crane.Option) error { //nolint:gocyclo
+	if c.mirrored[artifact] {
+		return nil
+	}
+	c.mirrored[artifact] = true
 
-	path := fmt.Sprintf("%s%s.tgz", c.ToDir, oci.GetArtifactName(artifact))
-	rawArtifactName := oci.RemoveDomainAndOrg(artifact)
+	// NOTE: images of prerequisites may be pinned to a digest in addition
+	// to a tag. They are mirrored to the tag, and their digest is verified.
+	tagged := artifact
+	if i := strings.Index(artifact, "@"); i > 0 && strings.Contains(artifact[:i], ":") {
+		tagged = artifact[:i]
+	}
+	fileName := fmt.Sprintf("%s.tgz", oci.GetArtifactName(tagged))
+	path := c.ToDir + fileName
+	rawArtifactName := oci.RemoveDomainAndOrg(tagged)
 
 	if printer.DryRun {
 		if len(c.ToDir) > 1 {
@@ -248,26 +288,119 @@ func (c *Cmd) mirrorArtifact(printer upterm.ObjectPrinter, artifact string, cran
 		return nil
 	}
 
+	if err := c.verifyArtifact(artifact, craneOpts); err != nil {
+		return err
+	}
+
 	if len(c.ToDir) > 1 {
 		img, err := crane.Pull(artifact, craneOpts...)
 		if err != nil {
 			return fmt.Errorf("pulling image: %w", err)
 		}
+		digest, err := img.Digest()
+		if err != nil {
+			return fmt.Errorf("getting digest of %s: %w", artifact, err)
+		}
 		follow := fmt.Sprintf("save artifact %s ...", artifact)
 		s := logAndStartSpinner(printer, follow)
-		if err := crane.Save(img, artifact, path); err != nil {
+		if err := crane.Save(img, tagged, path); err != nil {
 			s.Fail(follow)
 			return fmt.Errorf("saving tarball %s: %w", path, err)
 		}
 		s.Success(follow)
+		fileDigest, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+		c.manifest.Artifacts = append(c.manifest.Artifacts, Artifact{
+			Source:     artifact,
+			Digest:     digest.String(),
+			File:       fileName,
+			FileDigest: fileDigest,
+		})
 	} else {
+		dst := fmt.Sprintf("%s/%s", c.To, rawArtifactName)
+		digest, err := crane.Digest(artifact, craneOpts...)
+		if err != nil {
+			return fmt.Errorf("getting digest of %s: %w", artifact, err)
+		}
 		follow := fmt.Sprintf("mirror artifact %s to %s", rawArtifactName, c.To)
 		s := logAndStartSpinner(printer, follow)
-		if err := crane.Copy(artifact, fmt.Sprintf("%s/%s", c.To, rawArtifactName), craneOpts...); err != nil {
+		if err := crane.Copy(artifact, dst, craneOpts...); err != nil {
 			s.Fail(follow)
 			return fmt.Errorf("copy/push failed %s: %w", artifact, err)
 		}
 		s.Success(follow)
+		if err := verifyDigest(dst, digest, craneOpts); err != nil {
+			return err
+		}
+		c.manifest.Artifacts = append(c.manifest.Artifacts, Artifact{
+			Source:      artifact,
+			Digest:      digest,
+			Destination: dst,
+		})
+	}
+	return nil
+}
+
+// verifyArtifact verifies the signature of an artifact before it is mirrored.
+func (c *Cmd) verifyArtifact(artifact string, craneOpts []crane.Option) error {
+	if c.verifier == nil {
+		return nil
+	}
+	ref, err := name.ParseReference(artifact)
+	if err != nil {
+		return fmt.Errorf("parsing reference %s: %w", artifact, err)
+	}
+	digest, err := crane.Digest(artifact, craneOpts...)
+	if err != nil {
+		return fmt.Errorf("getting digest of %s: %w", artifact, err)
+	}
+	h, err := v1.NewHash(digest)
+	if err != nil {
+		return fmt.Errorf("parsing digest of %s: %w", artifact, err)
+	}
+	return c.verifier.Verify(context.Background(), ref, h)
+}
+
+// verifyDigest verifies that the registry serves ref with the given digest.
+func verifyDigest(ref, digest string, craneOpts []crane.Option) error {
+	got, err := crane.Digest(ref, craneOpts...)
+	if err != nil {
+		return fmt.Errorf("getting digest of %s: %w", ref, err)
+	}
+	if got != digest {
+		return fmt.Errorf("digest of %s is %s, expected %s", ref, got, digest)
+	}
+	return nil
+}
+
+// fileDigest returns the sha256 digest of the file at path.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck // Only read from.
+	h, _, err := v1.SHA256(f)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return h.String(), nil
+}
+
+// writeManifest writes the manifest of the mirrored artifacts.
+func (c *Cmd) writeManifest() error {
+	path := c.Manifest
+	if path == "" {
+		path = filepath.Join(c.ToDir, ManifestFile)
+	}
+	b, err := k8syaml.Marshal(c.manifest)
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil { //nolint:gosec // The manifest is not sensitive.
+		return fmt.Errorf("writing manifest %s: %w", path, err)
 	}
 	return nil
 }
@@ -0,0 +1,286 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/feature"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/pterm/pterm"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/engine"
+	"k8s.io/client-go/rest"
+	k8syaml "sigs.k8s.io/yaml"
+
+	spacefeature "github.com/upbound/up/cmd/up/space/features"
+	"github.com/upbound/up/cmd/up/space/prerequisites"
+	"github.com/upbound/up/internal/oci"
+	"github.com/upbound/up/internal/upterm"
+)
+
+// chartsDir is the directory charts of prerequisites are exported to with
+// --to-dir, as expected by up space init --from-bundle.
+const chartsDir = "charts"
+
+// renderKubeVersion is the Kubernetes version charts of prerequisites are
+// rendered for to find their images.
+var renderKubeVersion = chartutil.KubeVersion{Version: "v1.29.0", Major: "1", Minor: "29"}
+
+// mirrorPrerequisites mirrors the prerequisites up space init installs for the
+// Spaces version: the charts of prerequisites with the images they deploy, and
+// the packages of providers.
+func (c *Cmd) mirrorPrerequisites(printer upterm.ObjectPrinter, craneOpts []crane.Option) error {
+	// NOTE: all features are enabled in order to include the prerequisites of
+	// optional features. The prerequisites are only used to look up their
+	// artifacts, so they are not connected to a cluster.
+	features := &feature.Flags{}
+	features.Enable(spacefeature.EnableAlphaSharedTelemetry)
+	features.Enable(spacefeature.EnableAlphaQueryAPI)
+	mgr, err := prerequisites.New(&rest.Config{}, nil, features, c.Version)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "up-mirror-")
+	if err != nil {
+		return fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp) //nolint:errcheck // Best effort.
+
+	for _, p := range mgr.Prerequisites() {
+		switch p := p.(type) {
+		case prerequisites.ChartPrerequisite:
+			if err := c.mirrorChart(printer, p, filepath.Join(tmp, p.GetName()), craneOpts); err != nil {
+				return fmt.Errorf("mirroring prerequisite %s: %w", p.GetName(), err)
+			}
+		case prerequisites.PackagePrerequisite:
+			if err := c.mirrorArtifact(printer, p.Package(), craneOpts); err != nil {
+				return fmt.Errorf("mirroring prerequisite %s: %w", p.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// mirrorChart mirrors the chart of a prerequisite and the images it deploys.
+func (c *Cmd) mirrorChart(printer upterm.ObjectPrinter, p prerequisites.ChartPrerequisite, tmp string, craneOpts []crane.Option) error {
+	// NOTE: charts of prerequisites are pulled from Helm repositories, which
+	// have no signatures, so they are exempt from --verify. Their images are
+	// verified when they are mirrored.
+	repo, values := p.Chart()
+
+	// NOTE: the chart is pulled in dry runs too, in order to find its images.
+	path, err := pullChart(repo, p.GetName(), p.Version(), tmp)
+	if err != nil {
+		return err
+	}
+	ch, err := loader.Load(path)
+	if err != nil {
+		return fmt.Errorf("loading chart %s: %w", path, err)
+	}
+
+	if err := c.exportChart(printer, repo, path, ch, craneOpts); err != nil {
+		return err
+	}
+
+	images, err := chartImages(ch, values)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if err := c.mirrorArtifact(printer, img, craneOpts); err != nil {
+			return fmt.Errorf("mirroring image %s: %w", img, err)
+		}
+	}
+	return nil
+}
+
+// exportChart exports a chart archive to the charts directory with --to-dir,
+// or pushes it as an OCI artifact to the registry with --to.
+func (c *Cmd) exportChart(printer upterm.ObjectPrinter, repo *url.URL, path string, ch *chart.Chart, craneOpts []crane.Option) error {
+	fileName := filepath.Base(path)
+	source := fmt.Sprintf("%s:%s", ch.Name(), ch.Metadata.Version)
+	dst := fmt.Sprintf("%s/%s", c.To, source)
+
+	if printer.DryRun {
+		if len(c.ToDir) > 1 {
+			pterm.Printfln("helm pull %s --repo %s --version %s -d %s", ch.Name(), repo, ch.Metadata.Version, filepath.Join(c.ToDir, chartsDir))
+		}
+		if len(c.To) > 1 {
+			pterm.Printfln("helm push %s oci://%s", fileName, c.To)
+		}
+		return nil
+	}
+
+	archive, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("reading chart %s: %w", path, err)
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		return err
+	}
+	a := Artifact{
+		Source:     source,
+		Repository: repo.String(),
+		Digest:     digest,
+	}
+
+	if len(c.ToDir) > 1 {
+		follow := fmt.Sprintf("save chart %s ...", source)
+		s := logAndStartSpinner(printer, follow)
+		dir := filepath.Join(c.ToDir, chartsDir)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			s.Fail(follow)
+			return fmt.Errorf("creating directory %s: %w", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fileName), archive, 0o644); err != nil { //nolint:gosec // Charts are not sensitive.
+			s.Fail(follow)
+			return fmt.Errorf("saving chart %s: %w", source, err)
+		}
+		s.Success(follow)
+		a.File = filepath.ToSlash(filepath.Join(chartsDir, fileName))
+		a.FileDigest = digest
+	} else {
+		cfg, err := json.Marshal(ch.Metadata)
+		if err != nil {
+			return fmt.Errorf("encoding metadata of chart %s: %w", source, err)
+		}
+		img, err := oci.NewChartImage(cfg, archive)
+		if err != nil {
+			return err
+		}
+		follow := fmt.Sprintf("mirror chart %s to %s", source, c.To)
+		s := logAndStartSpinner(printer, follow)
+		if err := crane.Push(img, dst, craneOpts...); err != nil {
+			s.Fail(follow)
+			return fmt.Errorf("pushing chart %s: %w", source, err)
+		}
+		s.Success(follow)
+		imgDigest, err := img.Digest()
+		if err != nil {
+			return fmt.Errorf("getting digest of chart %s: %w", source, err)
+		}
+		if err := verifyDigest(dst, imgDigest.String(), craneOpts); err != nil {
+			return err
+		}
+		a.Destination = dst
+	}
+	c.manifest.Artifacts = append(c.manifest.Artifacts, a)
+	return nil
+}
+
+// pullChart pulls a chart from a chart repository to dir, and returns the path
+// of the chart archive.
+func pullChart(repo *url.URL, name, version, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	p := action.NewPullWithOpts(action.WithConfig(&action.Configuration{}))
+	p.Settings = cli.New()
+	p.RepoURL = repo.String()
+	p.Version = version
+	p.DestDir = dir
+	if _, err := p.Run(name); err != nil {
+		return "", fmt.Errorf("pulling chart %s %s from %s: %w", name, version, repo, err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil || len(files) != 1 {
+		return "", fmt.Errorf("chart %s %s not found after pulling it", name, version)
+	}
+	return files[0], nil
+}
+
+// chartImages returns the images a chart deploys when it is installed with
+// values.
+func chartImages(ch *chart.Chart, values map[string]any) ([]string, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	caps.KubeVersion = renderKubeVersion
+	vals, err := chartutil.ToRenderValues(ch, values, chartutil.ReleaseOptions{
+		Name:      ch.Name(),
+		Namespace: ch.Name(),
+		IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, fmt.Errorf("computing values of chart %s: %w", ch.Name(), err)
+	}
+	rendered, err := engine.Render(ch, vals)
+	if err != nil {
+		return nil, fmt.Errorf("rendering chart %s: %w", ch.Name(), err)
+	}
+	return imagesFromManifests(rendered), nil
+}
+
+// imagesFromManifests returns the images referenced by rendered manifests,
+// i.e. the image of containers and images passed to them as --*-image= flags.
+// Tests of charts are not installed, so their images are ignored.
+func imagesFromManifests(manifests map[string]string) []string {
+	images := map[string]bool{}
+	for path, m := range manifests {
+		if strings.Contains(path, "/tests/") || !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			continue
+		}
+		for _, doc := range strings.Split(m, "\n---") {
+			var obj any
+			if err := k8syaml.Unmarshal([]byte(doc), &obj); err != nil {
+				continue
+			}
+			findImages(obj, images)
+		}
+	}
+
+	out := make([]string, 0, len(images))
+	for img := range images {
+		out = append(out, img)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func findImages(v any, images map[string]bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		if img, ok := v["image"].(string); ok && img != "" {
+			images[img] = true
+		}
+		for _, k := range []string{"args", "command"} {
+			args, _ := v[k].([]any)
+			for _, a := range args {
+				s, _ := a.(string)
+				if _, img, ok := strings.Cut(s, "-image="); ok && strings.HasPrefix(s, "--") && img != "" {
+					images[img] = true
+				}
+			}
+		}
+		for _, e := range v {
+			findImages(e, images)
+		}
+	case []any:
+		for _, e := range v {
+			findImages(e, images)
+		}
+	}
+}
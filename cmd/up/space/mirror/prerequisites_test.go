@@ -0,0 +1,106 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestChartImages(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: registry.example.com/init:v1
+      containers:
+        - name: controller
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+          args:
+            - --acme-solver-image=registry.example.com/solver:v1
+            - --leader-elect
+{{- if .Values.sidecar.enabled }}
+        - name: sidecar
+          image: registry.example.com/sidecar@sha256:0000000000000000000000000000000000000000000000000000000000000000
+{{- end }}
+`
+	test := `apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+    - name: test
+      image: registry.example.com/test:v1
+`
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: "v2", Name: "prereq", Version: "1.0.0"},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(deployment)},
+			{Name: "templates/tests/test.yaml", Data: []byte(test)},
+			{Name: "templates/NOTES.txt", Data: []byte("image: registry.example.com/notes:v1")},
+		},
+		Values: map[string]any{
+			"image":   map[string]any{"repository": "registry.example.com/controller", "tag": "v1"},
+			"sidecar": map[string]any{"enabled": false},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		values map[string]any
+		want   []string
+	}{
+		"Defaults": {
+			reason: "Images of containers and image flags should be found with the default values, ignoring tests and notes.",
+			want: []string{
+				"registry.example.com/controller:v1",
+				"registry.example.com/init:v1",
+				"registry.example.com/solver:v1",
+			},
+		},
+		"Values": {
+			reason: "Images should be found with the values the chart is installed with.",
+			values: map[string]any{
+				"image":   map[string]any{"tag": "v2"},
+				"sidecar": map[string]any{"enabled": true},
+			},
+			want: []string{
+				"registry.example.com/controller:v2",
+				"registry.example.com/init:v1",
+				"registry.example.com/sidecar@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+				"registry.example.com/solver:v1",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := chartImages(ch, tc.values)
+			if err != nil {
+				t.Fatalf("chartImages(...): %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nchartImages(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
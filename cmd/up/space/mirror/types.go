@@ -39,3 +39,32 @@ type SubResource struct {
 type config struct {
 	OCI []Repository `yaml:"oci"`
 }
+
+// ManifestFile is the default name of the manifest file written by mirror.
+const ManifestFile = "manifest.yaml"
+
+// Manifest lists the artifacts mirrored for a Spaces version with their
+// digests.
+type Manifest struct {
+	Version   string     `json:"version"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Artifact is a mirrored artifact.
+type Artifact struct {
+	// Source is the reference of an OCI artifact, or the name and version of
+	// a chart in Repository.
+	Source string `json:"source"`
+	// Repository is the URL of the chart repository of charts that are not
+	// OCI artifacts.
+	Repository string `json:"repository,omitempty"`
+	// Digest is the digest of the OCI artifact, or of the archive of a chart.
+	Digest string `json:"digest"`
+	// Destination is the reference the artifact was mirrored to with --to.
+	Destination string `json:"destination,omitempty"`
+	// File is the path of the file the artifact was exported to with
+	// --to-dir, relative to the directory.
+	File string `json:"file,omitempty"`
+	// FileDigest is the digest of File.
+	FileDigest string `json:"fileDigest,omitempty"`
+}
@@ -27,6 +27,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
 )
@@ -35,8 +36,8 @@ var (
 	chartName     = "cert-manager"
 	certMgrURL, _ = url.Parse("https://charts.jetstack.io")
 
-	// Chart version to be installed
-	version = "v1.11.0"
+	// Default chart version to be installed
+	defaultVersion = "v1.11.0"
 	// Ensure CRDs are installed for the chart.
 	values = map[string]any{
 		"installCRDs": "true",
@@ -54,15 +55,18 @@ type CertManager struct {
 	mgr       install.Manager
 	crdclient *apixv1client.ApiextensionsV1Client
 	kclient   kubernetes.Interface
+	version   string
+	pinned    bool
 }
 
 // New constructs a new CertManager instance that can used to install the
 // cert-manager chart.
-func New(config *rest.Config) (*CertManager, error) {
+// Additional modifiers are passed to the Helm manager of the chart.
+func New(config *rest.Config, opts ...helm.InstallerModifierFn) (*CertManager, error) {
 	mgr, err := helm.NewManager(config,
 		chartName,
 		certMgrURL,
-		helm.WithNamespace(chartName),
+		append([]helm.InstallerModifierFn{helm.WithNamespace(chartName)}, opts...)...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf(errFmtCreateHelmManager, chartName))
@@ -80,6 +84,7 @@ func New(config *rest.Config) (*CertManager, error) {
 		mgr:       mgr,
 		crdclient: crdclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -88,6 +93,37 @@ func (c *CertManager) GetName() string {
 	return chartName
 }
 
+// Version returns the version of the cert-manager chart that will be installed
+// or upgraded to.
+func (c *CertManager) Version() string {
+	return c.version
+}
+
+// InstalledVersion returns the version of the cert-manager release, or an empty
+// string if there is no release.
+func (c *CertManager) InstalledVersion() (string, error) {
+	return helmchart.InstalledVersion(c.mgr)
+}
+
+// SetVersion overrides the version of the chart that is installed or upgraded
+// to. Unlike the default version, an overridden version is upgraded to even if
+// it's older than the installed version.
+func (c *CertManager) SetVersion(v string) {
+	c.version = v
+	c.pinned = true
+}
+
+// Chart returns the repository URL of the cert-manager chart and the values it
+// is installed with.
+func (c *CertManager) Chart() (*url.URL, map[string]any) {
+	return certMgrURL, values
+}
+
+// Namespace returns the namespace the cert-manager chart is installed in.
+func (c *CertManager) Namespace() string {
+	return chartName
+}
+
 // Install performs a Helm install of the chart.
 func (c *CertManager) Install() error {
 	installed, err := c.IsInstalled()
@@ -112,7 +148,7 @@ func (c *CertManager) Install() error {
 		return errors.Wrap(err, fmt.Sprintf(errFmtCreateNamespace, chartName))
 	}
 
-	return c.mgr.Install(version, values)
+	return c.mgr.Install(c.version, values)
 }
 
 // IsInstalled checks if cert-manager has been installed in the target cluster.
@@ -132,3 +168,21 @@ func (c *CertManager) IsInstalled() (bool, error) {
 	}
 	return false, err
 }
+
+// Upgrade performs a Helm upgrade of the chart to its version, or installs it
+// if it is not installed.
+func (c *CertManager) Upgrade() error {
+	installed, err := c.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return c.Install()
+	}
+	return helmchart.Upgrade(c.mgr, c.version, c.pinned, values)
+}
+
+// Uninstall performs a Helm uninstall of the chart.
+func (c *CertManager) Uninstall() error {
+	return helmchart.Uninstall(c.mgr)
+}
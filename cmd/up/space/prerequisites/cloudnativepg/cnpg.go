@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/kubectl/pkg/util/podutils"
 
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
 )
@@ -40,8 +41,8 @@ var (
 	chartNamespace = "cnpg-system"
 	cnpgURL, _     = url.Parse("https://cloudnative-pg.github.io/charts")
 
-	// Chart version to be installed
-	version = "0.21.5"
+	// Default chart version to be installed
+	defaultVersion = "0.21.5"
 
 	values = map[string]any{}
 
@@ -57,15 +58,18 @@ type CNPGOperator struct {
 	mgr       install.Manager
 	crdclient *apixv1client.ApiextensionsV1Client
 	kclient   kubernetes.Interface
+	version   string
+	pinned    bool
 }
 
 // New constructs a new OpenTelemetryCollectorMgr instance that can used to install the
 // opentelemetry-operator chart.
-func New(config *rest.Config) (*CNPGOperator, error) {
+// Additional modifiers are passed to the Helm manager of the chart.
+func New(config *rest.Config, opts ...helm.InstallerModifierFn) (*CNPGOperator, error) {
 	mgr, err := helm.NewManager(config,
 		chartName,
 		cnpgURL,
-		helm.WithNamespace(chartNamespace),
+		append([]helm.InstallerModifierFn{helm.WithNamespace(chartNamespace)}, opts...)...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf(errFmtCreateHelmManager, chartName))
@@ -83,6 +87,7 @@ func New(config *rest.Config) (*CNPGOperator, error) {
 		mgr:       mgr,
 		crdclient: crdclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -91,6 +96,37 @@ func (o *CNPGOperator) GetName() string {
 	return chartName
 }
 
+// Version returns the version of the cnpg chart that will be installed or
+// upgraded to.
+func (o *CNPGOperator) Version() string {
+	return o.version
+}
+
+// InstalledVersion returns the version of the cnpg release, or an empty
+// string if there is no release.
+func (o *CNPGOperator) InstalledVersion() (string, error) {
+	return helmchart.InstalledVersion(o.mgr)
+}
+
+// SetVersion overrides the version of the chart that is installed or upgraded
+// to. Unlike the default version, an overridden version is upgraded to even if
+// it's older than the installed version.
+func (o *CNPGOperator) SetVersion(v string) {
+	o.version = v
+	o.pinned = true
+}
+
+// Chart returns the repository URL of the cloudnative-pg chart and the values
+// it is installed with.
+func (o *CNPGOperator) Chart() (*url.URL, map[string]any) {
+	return cnpgURL, values
+}
+
+// Namespace returns the namespace the cnpg chart is installed in.
+func (o *CNPGOperator) Namespace() string {
+	return chartNamespace
+}
+
 // Install performs a Helm install of the chart.
 func (o *CNPGOperator) Install() error {
 	installed, err := o.IsInstalled()
@@ -115,7 +151,7 @@ func (o *CNPGOperator) Install() error {
 		return errors.Wrap(err, fmt.Sprintf(errFmtCreateNamespace, chartNamespace))
 	}
 
-	if err = o.mgr.Install(version, values); err != nil {
+	if err = o.mgr.Install(o.version, values); err != nil {
 		return err
 	}
 
@@ -163,3 +199,21 @@ func (o *CNPGOperator) IsInstalled() (bool, error) {
 	}
 	return false, err
 }
+
+// Upgrade performs a Helm upgrade of the chart to its version, or installs it
+// if it is not installed.
+func (o *CNPGOperator) Upgrade() error {
+	installed, err := o.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return o.Install()
+	}
+	return helmchart.Upgrade(o.mgr, o.version, o.pinned, values)
+}
+
+// Uninstall performs a Helm uninstall of the chart.
+func (o *CNPGOperator) Uninstall() error {
+	return helmchart.Uninstall(o.mgr)
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package helmchart contains helpers for prerequisites that are installed as
+// Helm charts.
+package helmchart
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/upbound/up/internal/install"
+)
+
+// Upgrade upgrades the release of a chart to a version. Nothing is done if the
+// release is not outdated, or if there is no release because the prerequisite
+// was installed by other means than up. A pinned version is also downgraded
+// to.
+func Upgrade(mgr install.Manager, version string, pinned bool, values map[string]any) error {
+	current, err := mgr.GetCurrentVersion()
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !Outdated(current, version, pinned) {
+		return nil
+	}
+	return mgr.Upgrade(version, values)
+}
+
+// Outdated returns true if the installed version of a prerequisite should be
+// upgraded to a version, i.e. if the version is newer. Downgrades of charts
+// that ship CRDs are unsafe, so an older version is only upgraded to if it is
+// pinned, i.e. was given explicitly. Versions that cannot be compared are not
+// outdated.
+func Outdated(installed, version string, pinned bool) bool {
+	if pinned {
+		return strings.TrimPrefix(installed, "v") != strings.TrimPrefix(version, "v")
+	}
+	i, err := semver.NewVersion(installed)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	return i.LessThan(v)
+}
+
+// InstalledVersion returns the version of the release of a chart, or an empty
+// string if there is no release because the prerequisite was installed by
+// other means than up.
+func InstalledVersion(mgr install.Manager) (string, error) {
+	current, err := mgr.GetCurrentVersion()
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return "", nil
+	}
+	return current, err
+}
+
+// Uninstall uninstalls the release of a chart. Nothing is done if there is no
+// release.
+func Uninstall(mgr install.Manager) error {
+	if _, err := mgr.GetCurrentVersion(); err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil
+		}
+		return err
+	}
+	return mgr.Uninstall()
+}
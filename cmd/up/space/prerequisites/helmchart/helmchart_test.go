@@ -0,0 +1,208 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helmchart
+
+import (
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/upbound/up/internal/install"
+)
+
+var _ install.Manager = &mockManager{}
+
+type mockManager struct {
+	install.Manager
+
+	version string
+	err     error
+
+	upgraded    string
+	uninstalled bool
+}
+
+func (m *mockManager) GetCurrentVersion() (string, error) {
+	return m.version, m.err
+}
+
+func (m *mockManager) Upgrade(version string, _ map[string]any, _ ...install.UpgradeOption) error {
+	m.upgraded = version
+	return nil
+}
+
+func (m *mockManager) Uninstall() error {
+	m.uninstalled = true
+	return nil
+}
+
+func TestUpgrade(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type want struct {
+		upgraded string
+		err      error
+	}
+	cases := map[string]struct {
+		reason  string
+		mgr     *mockManager
+		version string
+		pinned  bool
+		want    want
+	}{
+		"Upgrade": {
+			reason:  "The release should be upgraded if it is at an older version.",
+			mgr:     &mockManager{version: "1.13.0"},
+			version: "v1.14.4",
+			want: want{
+				upgraded: "v1.14.4",
+			},
+		},
+		"NewerVersion": {
+			reason:  "The release should not be downgraded if it is at a newer version.",
+			mgr:     &mockManager{version: "1.14.4"},
+			version: "v1.11.0",
+		},
+		"PinnedNewerVersion": {
+			reason:  "The release should be downgraded to a pinned version.",
+			mgr:     &mockManager{version: "1.14.4"},
+			version: "v1.11.0",
+			pinned:  true,
+			want: want{
+				upgraded: "v1.11.0",
+			},
+		},
+		"SameVersion": {
+			reason:  "Nothing should be done if the release is at the version, regardless of the v prefix.",
+			mgr:     &mockManager{version: "1.14.4"},
+			version: "v1.14.4",
+		},
+		"NotFound": {
+			reason:  "Nothing should be done if there is no release.",
+			mgr:     &mockManager{err: driver.ErrReleaseNotFound},
+			version: "v1.14.4",
+		},
+		"Error": {
+			reason:  "Errors getting the release should be returned.",
+			mgr:     &mockManager{err: errBoom},
+			version: "v1.14.4",
+			want: want{
+				err: errBoom,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Upgrade(tc.mgr, tc.version, tc.pinned, nil)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUpgrade(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.upgraded, tc.mgr.upgraded); diff != "" {
+				t.Errorf("\n%s\nUpgrade(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type want struct {
+		uninstalled bool
+		err         error
+	}
+	cases := map[string]struct {
+		reason string
+		mgr    *mockManager
+		want   want
+	}{
+		"Uninstall": {
+			reason: "The release should be uninstalled if it exists.",
+			mgr:    &mockManager{version: "1.14.4"},
+			want: want{
+				uninstalled: true,
+			},
+		},
+		"NotFound": {
+			reason: "Nothing should be done if there is no release.",
+			mgr:    &mockManager{err: driver.ErrReleaseNotFound},
+		},
+		"Error": {
+			reason: "Errors getting the release should be returned.",
+			mgr:    &mockManager{err: errBoom},
+			want: want{
+				err: errBoom,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Uninstall(tc.mgr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUninstall(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.uninstalled, tc.mgr.uninstalled); diff != "" {
+				t.Errorf("\n%s\nUninstall(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestInstalledVersion(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type want struct {
+		version string
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		mgr    *mockManager
+		want   want
+	}{
+		"Installed": {
+			reason: "The version of the release should be returned.",
+			mgr:    &mockManager{version: "1.14.4"},
+			want: want{
+				version: "1.14.4",
+			},
+		},
+		"NotFound": {
+			reason: "An empty version should be returned if there is no release.",
+			mgr:    &mockManager{err: driver.ErrReleaseNotFound},
+		},
+		"Error": {
+			reason: "Errors getting the release should be returned.",
+			mgr:    &mockManager{err: errBoom},
+			want: want{
+				err: errBoom,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := InstalledVersion(tc.mgr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nInstalledVersion(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.version, got); diff != "" {
+				t.Errorf("\n%s\nInstalledVersion(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
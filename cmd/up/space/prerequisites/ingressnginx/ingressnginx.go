@@ -27,6 +27,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
 )
@@ -43,8 +44,8 @@ var (
 	chartName   = "ingress-nginx"
 	nginxURL, _ = url.Parse("https://kubernetes.github.io/ingress-nginx")
 
-	// Chart version to be installed
-	version                 = "4.7.1"
+	// Default chart version to be installed
+	defaultVersion          = "4.7.1"
 	errFmtCreateHelmManager = "failed to create helm manager for %s"
 	errFmtCreateK8sClient   = "failed to create kubernetes client for helm chart %s"
 	errFmtCreateNamespace   = "failed to create namespace %s"
@@ -56,15 +57,18 @@ type IngressNginx struct {
 	kclient kubernetes.Interface
 	dclient dynamic.Interface
 	values  map[string]any
+	version string
+	pinned  bool
 }
 
 // New constructs a new CertManager instance that can used to install the
 // cert-manager chart.
-func New(config *rest.Config, svc ServiceType) (*IngressNginx, error) {
+// Additional modifiers are passed to the Helm manager of the chart.
+func New(config *rest.Config, svc ServiceType, opts ...helm.InstallerModifierFn) (*IngressNginx, error) {
 	mgr, err := helm.NewManager(config,
 		chartName,
 		nginxURL,
-		helm.WithNamespace(chartName),
+		append([]helm.InstallerModifierFn{helm.WithNamespace(chartName)}, opts...)...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf(errFmtCreateHelmManager, chartName))
@@ -83,6 +87,7 @@ func New(config *rest.Config, svc ServiceType) (*IngressNginx, error) {
 		dclient: dclient,
 		kclient: kclient,
 		values:  getValues(svc),
+		version: defaultVersion,
 	}, nil
 }
 
@@ -91,6 +96,37 @@ func (c *IngressNginx) GetName() string {
 	return chartName
 }
 
+// Version returns the version of the ingress-nginx chart that will be installed
+// or upgraded to.
+func (c *IngressNginx) Version() string {
+	return c.version
+}
+
+// InstalledVersion returns the version of the ingress-nginx release, or an empty
+// string if there is no release.
+func (c *IngressNginx) InstalledVersion() (string, error) {
+	return helmchart.InstalledVersion(c.mgr)
+}
+
+// SetVersion overrides the version of the chart that is installed or upgraded
+// to. Unlike the default version, an overridden version is upgraded to even if
+// it's older than the installed version.
+func (c *IngressNginx) SetVersion(v string) {
+	c.version = v
+	c.pinned = true
+}
+
+// Chart returns the repository URL of the ingress-nginx chart and the values it
+// is installed with.
+func (c *IngressNginx) Chart() (*url.URL, map[string]any) {
+	return nginxURL, c.values
+}
+
+// Namespace returns the namespace the ingress-nginx chart is installed in.
+func (c *IngressNginx) Namespace() string {
+	return chartName
+}
+
 // Install performs a Helm install of the chart.
 func (c *IngressNginx) Install() error { //nolint:gocyclo
 	installed, err := c.IsInstalled()
@@ -115,7 +151,7 @@ func (c *IngressNginx) Install() error { //nolint:gocyclo
 		return errors.Wrap(err, fmt.Sprintf(errFmtCreateNamespace, chartName))
 	}
 
-	if err := c.mgr.Install(version, c.values); err != nil {
+	if err := c.mgr.Install(c.version, c.values); err != nil {
 		return err
 	}
 
@@ -219,3 +255,21 @@ func getValues(svc ServiceType) map[string]any {
 		},
 	}
 }
+
+// Upgrade performs a Helm upgrade of the chart to its version, or installs it
+// if it is not installed.
+func (c *IngressNginx) Upgrade() error {
+	installed, err := c.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return c.Install()
+	}
+	return helmchart.Upgrade(c.mgr, c.version, c.pinned, c.values)
+}
+
+// Uninstall performs a Helm uninstall of the chart.
+func (c *IngressNginx) Uninstall() error {
+	return helmchart.Uninstall(c.mgr)
+}
@@ -15,6 +15,10 @@
 package prerequisites
 
 import (
+	"net/url"
+	"os"
+	"strings"
+
 	"github.com/Masterminds/semver/v3"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
@@ -24,30 +28,76 @@ import (
 	spacefeature "github.com/upbound/up/cmd/up/space/features"
 	"github.com/upbound/up/cmd/up/space/prerequisites/certmanager"
 	"github.com/upbound/up/cmd/up/space/prerequisites/cloudnativepg"
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/cmd/up/space/prerequisites/ingressnginx"
 	"github.com/upbound/up/cmd/up/space/prerequisites/opentelemetrycollector"
-	"github.com/upbound/up/cmd/up/space/prerequisites/providers/helm"
+	providerhelm "github.com/upbound/up/cmd/up/space/prerequisites/providers/helm"
 	"github.com/upbound/up/cmd/up/space/prerequisites/providers/kubernetes"
 	"github.com/upbound/up/cmd/up/space/prerequisites/uxp"
+	"github.com/upbound/up/internal/install/helm"
 )
 
 var (
-	errCreatePrerequisite = "failed to instantiate prerequisite manager"
+	errCreatePrerequisite  = "failed to instantiate prerequisite manager"
+	errFmtUnknownPrereq    = "unknown prerequisite %q in version overrides, must be one of: %s"
+	errFmtPrereqVersion    = "version of prerequisite %q cannot be overridden"
+	errFmtInstalledVersion = "cannot get installed version of prerequisite %q"
+	errFmtCloseChart       = "cannot close chart archive of prerequisite %q"
 )
 
 // Prerequisite defines the API that is used to interogate an installation
 // prerequisite.
 type Prerequisite interface {
 	GetName() string
+	Version() string
 
 	Install() error
 	IsInstalled() (bool, error)
+	// InstalledVersion returns the version that is installed, or an empty
+	// string if it was installed by other means than up.
+	InstalledVersion() (string, error)
+	Upgrade() error
+	Uninstall() error
+}
+
+// ChartPrerequisite is a Prerequisite that is installed as a Helm chart.
+type ChartPrerequisite interface {
+	Prerequisite
+
+	// Chart returns the repository URL of the chart and the values it is
+	// installed with.
+	Chart() (*url.URL, map[string]any)
+}
+
+// PackagePrerequisite is a Prerequisite that is installed as a Crossplane
+// package.
+type PackagePrerequisite interface {
+	Prerequisite
+
+	// Package returns the fully qualified reference of the package.
+	Package() string
+}
+
+// versionSetter is implemented by Prerequisites whose version can be
+// overridden.
+type versionSetter interface {
+	SetVersion(v string)
+}
+
+// registrySetter is implemented by Prerequisites whose registry can be
+// overridden.
+type registrySetter interface {
+	SetRegistry(registry string)
 }
 
 // Manager provides APIs for interacting with Prerequisites within the target
 // cluster.
 type Manager struct {
 	prereqs []Prerequisite
+	charts  map[string]Chart
+	// pinned are the names of the Prerequisites whose version was given
+	// explicitly, which are upgraded to it even if it's older.
+	pinned map[string]bool
 }
 
 // Status represents the the overall status of the Prerequisite within the
@@ -56,8 +106,90 @@ type Status struct {
 	NotInstalled []Prerequisite
 }
 
+// Chart is a local chart archive of a Prerequisite.
+type Chart struct {
+	File    *os.File
+	Version string
+}
+
+type options struct {
+	versions map[string]string
+	charts   map[string]Chart
+	registry string
+
+	chartRepo *url.URL
+	username  string
+	password  string
+}
+
+// chart returns the Helm modifiers that install the Prerequisite with the
+// given name from its local chart archive if there is one, or from its chart
+// repository otherwise. Charts are pulled from the OCI chart repository of the
+// options if there is one, and their images are rewritten to the registry of
+// the options if there is one.
+//
+// NOTE: charts of Prerequisites are published to Helm repositories without
+// signatures, so they are exempt from signature verification, including when
+// they are pulled from the OCI repository they were mirrored to.
+func (o *options) chart(name string) []helm.InstallerModifierFn {
+	mods := []helm.InstallerModifierFn{}
+	if o.registry != "" {
+		mods = append(mods, helm.WithPostRenderer(&registryRewriter{registry: o.registry}))
+	}
+	if c, ok := o.charts[name]; ok {
+		return append(mods, helm.WithChart(c.File))
+	}
+	if o.chartRepo != nil {
+		mods = append(mods, helm.WithRepoURL(o.chartRepo), helm.IsOCI(), helm.WithBasicAuth(o.username, o.password))
+	}
+	return mods
+}
+
+// Option modifies how the Prerequisites of a Manager are constructed.
+type Option func(*options)
+
+// WithVersions overrides the version of Prerequisites by name.
+func WithVersions(versions map[string]string) Option {
+	return func(o *options) {
+		o.versions = versions
+	}
+}
+
+// WithCharts installs Prerequisites by name from local chart archives instead
+// of their chart repositories. Charts of Prerequisites that are not required
+// are ignored.
+func WithCharts(charts map[string]Chart) Option {
+	return func(o *options) {
+		o.charts = charts
+	}
+}
+
+// WithRegistry installs the charts of Prerequisites with their images, and the
+// packages of provider Prerequisites, pulled from the registry they were
+// mirrored to instead of their own registries.
+func WithRegistry(registry string) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithChartRepository pulls the charts of Prerequisites from the OCI
+// repository they were mirrored to instead of their chart repositories, with
+// the given credentials.
+func WithChartRepository(repo *url.URL, username, password string) Option {
+	return func(o *options) {
+		o.chartRepo = repo
+		o.username = username
+		o.password = password
+	}
+}
+
 // New constructs a new Manager for working with installation Prerequisites.
-func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flags, versionStr string) (*Manager, error) { // nolint:gocyclo
+func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flags, versionStr string, opts ...Option) (*Manager, error) { // nolint:gocyclo
+	o := &options{}
+	for _, fn := range opts {
+		fn(o)
+	}
 	prereqs := []Prerequisite{}
 
 	version, err := semver.NewVersion(versionStr)
@@ -72,7 +204,7 @@ func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flag
 
 	// Check if the version satisfies the constraint
 	if requiresUXP.Check(version) {
-		uxp, err := uxp.New(config)
+		uxp, err := uxp.New(config, o.chart("universal-crossplane")...)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create UXP prerequisite")
 		}
@@ -84,14 +216,14 @@ func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flag
 		}
 		prereqs = append(prereqs, pk8s)
 
-		phelm, err := helm.New(config)
+		phelm, err := providerhelm.New(config)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create Helm prerequisite")
 		}
 		prereqs = append(prereqs, phelm)
 	}
 
-	certmanager, err := certmanager.New(config)
+	certmanager, err := certmanager.New(config, o.chart("cert-manager")...)
 	if err != nil {
 		return nil, errors.Wrap(err, errCreatePrerequisite)
 	}
@@ -102,14 +234,14 @@ func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flag
 		svcType = ingressnginx.LoadBalancer
 	}
 
-	ingress, err := ingressnginx.New(config, svcType)
+	ingress, err := ingressnginx.New(config, svcType, o.chart("ingress-nginx")...)
 	if err != nil {
 		return nil, errors.Wrap(err, errCreatePrerequisite)
 	}
 	prereqs = append(prereqs, ingress)
 
 	if features.Enabled(spacefeature.EnableAlphaSharedTelemetry) {
-		otelopr, err := opentelemetrycollector.New(config)
+		otelopr, err := opentelemetrycollector.New(config, o.chart("opentelemetry-operator")...)
 		if err != nil {
 			return nil, errors.Wrap(err, errCreatePrerequisite)
 		}
@@ -117,18 +249,78 @@ func New(config *rest.Config, defs *defaults.CloudConfig, features *feature.Flag
 	}
 
 	if features.Enabled(spacefeature.EnableAlphaQueryAPI) {
-		cnpg, err := cloudnativepg.New(config)
+		cnpg, err := cloudnativepg.New(config, o.chart("cloudnative-pg")...)
 		if err != nil {
 			return nil, errors.Wrap(err, errCreatePrerequisite)
 		}
 		prereqs = append(prereqs, cnpg)
 	}
 
+	if err := overrideVersions(prereqs, o.versions); err != nil {
+		return nil, err
+	}
+	for _, p := range prereqs {
+		if c, ok := o.charts[p.GetName()]; ok && c.Version != "" {
+			if vs, ok := p.(versionSetter); ok {
+				vs.SetVersion(c.Version)
+			}
+		}
+		if rs, ok := p.(registrySetter); ok && o.registry != "" {
+			rs.SetRegistry(o.registry)
+		}
+	}
+
+	pinned := make(map[string]bool, len(o.versions))
+	for n := range o.versions {
+		pinned[n] = true
+	}
+
 	return &Manager{
 		prereqs: prereqs,
+		charts:  o.charts,
+		pinned:  pinned,
 	}, nil
 }
 
+// Close closes the local chart archives of the Prerequisites, if any. The
+// Manager cannot install or upgrade Prerequisites from them afterwards.
+func (m *Manager) Close() error {
+	var err error
+	for n, c := range m.charts {
+		if cerr := c.File.Close(); cerr != nil && err == nil {
+			err = errors.Wrapf(cerr, errFmtCloseChart, n)
+		}
+	}
+	return err
+}
+
+func overrideVersions(prereqs []Prerequisite, versions map[string]string) error {
+	byName := make(map[string]Prerequisite, len(prereqs))
+	names := make([]string, 0, len(prereqs))
+	for _, p := range prereqs {
+		byName[p.GetName()] = p
+		names = append(names, p.GetName())
+	}
+	for n, v := range versions {
+		p, ok := byName[n]
+		if !ok {
+			return errors.Errorf(errFmtUnknownPrereq, n, strings.Join(names, ", "))
+		}
+		vs, ok := p.(versionSetter)
+		if !ok {
+			return errors.Errorf(errFmtPrereqVersion, n)
+		}
+		vs.SetVersion(v)
+	}
+	return nil
+}
+
+// Prerequisites returns the Prerequisites of the Manager in the order they are
+// installed.
+func (m *Manager) Prerequisites() []Prerequisite {
+	return m.prereqs
+}
+
 // Check performs IsInstalled checks for each of the Prerequisites against the
 // target cluster.
 func (m *Manager) Check() (*Status, error) {
@@ -147,3 +339,28 @@ func (m *Manager) Check() (*Status, error) {
 		NotInstalled: notInstalled,
 	}, nil
 }
+
+// Outdated returns the installed Prerequisites whose installed version is older
+// than the version they are upgraded to, or differs from it if the version was
+// given explicitly with WithVersions. Prerequisites that are not installed, or
+// that were installed by other means than up, are not returned.
+func (m *Manager) Outdated() ([]Prerequisite, error) {
+	outdated := []Prerequisite{}
+	for _, p := range m.prereqs {
+		installed, err := p.IsInstalled()
+		if err != nil {
+			return nil, err
+		}
+		if !installed {
+			continue
+		}
+		current, err := p.InstalledVersion()
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtInstalledVersion, p.GetName())
+		}
+		if current != "" && helmchart.Outdated(current, p.Version(), m.pinned[p.GetName()]) {
+			outdated = append(outdated, p)
+		}
+	}
+	return outdated, nil
+}
@@ -39,12 +39,15 @@ func TestNew(t *testing.T) {
 		defs          *defaults.CloudConfig
 		setupFeatures func() *feature.Flags
 		versionStr    string
+		versions      map[string]string
+		charts        map[string]Chart
 	}
 
 	type want struct {
 		expectError     bool
 		expectedErrMsg  string
 		expectedPrereqs []string
+		versions        map[string]string
 	}
 
 	cases := map[string]struct {
@@ -127,12 +130,59 @@ func TestNew(t *testing.T) {
 				expectedPrereqs: []string{"certmanager", "ingressnginx", "opentelemetrycollector"},
 			},
 		},
+		"VersionOverrides": {
+			reason: "Testing version overrides should set the version of the named prerequisites.",
+			args: args{
+				config:        &rest.Config{},
+				defs:          &defaults.CloudConfig{},
+				setupFeatures: func() *feature.Flags { return &feature.Flags{} },
+				versionStr:    "v1.6.0",
+				versions:      map[string]string{"cert-manager": "v1.15.0", "provider-helm": "v0.20.0"},
+			},
+			want: want{
+				expectError:     false,
+				expectedPrereqs: []string{"uxp", "kubernetes", "helm", "certmanager", "ingressnginx"},
+				versions:        map[string]string{"cert-manager": "v1.15.0", "provider-helm": "v0.20.0"},
+			},
+		},
+		"BundledCharts": {
+			reason: "Testing bundled charts should set the version of the named prerequisites and ignore charts that are not required.",
+			args: args{
+				config:        &rest.Config{},
+				defs:          &defaults.CloudConfig{},
+				setupFeatures: func() *feature.Flags { return &feature.Flags{} },
+				versionStr:    "v1.8.0",
+				charts: map[string]Chart{
+					"ingress-nginx":  {Version: "4.10.0"},
+					"cloudnative-pg": {Version: "0.21.0"},
+				},
+			},
+			want: want{
+				expectError:     false,
+				expectedPrereqs: []string{"certmanager", "ingressnginx"},
+				versions:        map[string]string{"ingress-nginx": "4.10.0"},
+			},
+		},
+		"UnknownVersionOverride": {
+			reason: "Testing a version override for a prerequisite that is not installed should return an error.",
+			args: args{
+				config:        &rest.Config{},
+				defs:          &defaults.CloudConfig{},
+				setupFeatures: func() *feature.Flags { return &feature.Flags{} },
+				versionStr:    "v1.8.0",
+				versions:      map[string]string{"provider-helm": "v0.20.0"},
+			},
+			want: want{
+				expectError:    true,
+				expectedErrMsg: `unknown prerequisite "provider-helm"`,
+			},
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			features := tc.args.setupFeatures() // Initialize feature flags using setup function
-			manager, err := New(tc.args.config, tc.args.defs, features, tc.args.versionStr)
+			manager, err := New(tc.args.config, tc.args.defs, features, tc.args.versionStr, WithVersions(tc.args.versions), WithCharts(tc.args.charts))
 
 			if tc.want.expectError {
 				require.Error(t, err)
@@ -163,7 +213,104 @@ func TestNew(t *testing.T) {
 				}
 
 				require.ElementsMatch(t, tc.want.expectedPrereqs, prereqTypes)
+
+				for _, prereq := range manager.prereqs {
+					if v, ok := tc.want.versions[prereq.GetName()]; ok {
+						require.Equal(t, v, prereq.Version())
+					}
+				}
+			}
+		})
+	}
+}
+
+type fakePrerequisite struct {
+	Prerequisite
+
+	name      string
+	version   string
+	installed string
+}
+
+func (f *fakePrerequisite) GetName() string { return f.name }
+
+func (f *fakePrerequisite) Version() string { return f.version }
+
+func (f *fakePrerequisite) IsInstalled() (bool, error) { return f.installed != "-", nil }
+
+func (f *fakePrerequisite) InstalledVersion() (string, error) { return f.installed, nil }
+
+func TestOutdated(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		prereqs []Prerequisite
+		pinned  map[string]bool
+		want    []string
+	}{
+		"OlderVersion": {
+			reason: "Prerequisites installed at an older version should be outdated.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.14.4", installed: "v1.11.0"},
+				&fakePrerequisite{name: "ingress-nginx", version: "4.7.1", installed: "4.7.1"},
+			},
+			want: []string{"cert-manager"},
+		},
+		"NewerVersion": {
+			reason: "Prerequisites installed at a newer version should not be downgraded.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.11.0", installed: "v1.14.4"},
+				&fakePrerequisite{name: "ingress-nginx", version: "4.7.1", installed: "4.10.0"},
+			},
+			want: []string{},
+		},
+		"PinnedOlderVersion": {
+			reason: "Prerequisites installed at a newer version than one given explicitly should be outdated.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.11.0", installed: "v1.14.4"},
+				&fakePrerequisite{name: "ingress-nginx", version: "4.7.1", installed: "4.10.0"},
+			},
+			pinned: map[string]bool{"cert-manager": true},
+			want:   []string{"cert-manager"},
+		},
+		"InvalidVersion": {
+			reason: "Prerequisites whose versions cannot be compared should not be outdated.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.14.4", installed: "latest"},
+			},
+			want: []string{},
+		},
+		"VersionPrefix": {
+			reason: "Versions that only differ in the v prefix should be the same.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.14.4", installed: "1.14.4"},
+			},
+			want: []string{},
+		},
+		"NotInstalledByUp": {
+			reason: "Prerequisites installed by other means than up should not be outdated.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.14.4", installed: ""},
+			},
+			want: []string{},
+		},
+		"NotInstalled": {
+			reason: "Prerequisites that are not installed should not be outdated.",
+			prereqs: []Prerequisite{
+				&fakePrerequisite{name: "cert-manager", version: "v1.14.4", installed: "-"},
+			},
+			want: []string{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &Manager{prereqs: tc.prereqs, pinned: tc.pinned}
+			outdated, err := m.Outdated()
+			require.NoError(t, err, tc.reason)
+			got := []string{}
+			for _, p := range outdated {
+				got = append(got, p.GetName())
 			}
+			require.Equal(t, tc.want, got, tc.reason)
 		})
 	}
 }
@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/kubectl/pkg/util/podutils"
 
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
 )
@@ -40,8 +41,8 @@ var (
 	chartNamespace = chartName
 	otelMgrURL, _  = url.Parse("https://open-telemetry.github.io/opentelemetry-helm-charts")
 
-	// Chart version to be installed
-	version = "0.56.0"
+	// Default chart version to be installed
+	defaultVersion = "0.56.0"
 
 	// Set image used to contrib to cover more exporters
 	values = map[string]any{
@@ -64,15 +65,18 @@ type OpenTelemetryCollectorOperator struct {
 	mgr       install.Manager
 	crdclient *apixv1client.ApiextensionsV1Client
 	kclient   kubernetes.Interface
+	version   string
+	pinned    bool
 }
 
 // New constructs a new OpenTelemetryCollectorMgr instance that can used to install the
 // opentelemetry-operator chart.
-func New(config *rest.Config) (*OpenTelemetryCollectorOperator, error) {
+// Additional modifiers are passed to the Helm manager of the chart.
+func New(config *rest.Config, opts ...helm.InstallerModifierFn) (*OpenTelemetryCollectorOperator, error) {
 	mgr, err := helm.NewManager(config,
 		chartName,
 		otelMgrURL,
-		helm.WithNamespace(chartNamespace),
+		append([]helm.InstallerModifierFn{helm.WithNamespace(chartNamespace)}, opts...)...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf(errFmtCreateHelmManager, chartName))
@@ -90,6 +94,7 @@ func New(config *rest.Config) (*OpenTelemetryCollectorOperator, error) {
 		mgr:       mgr,
 		crdclient: crdclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -98,6 +103,37 @@ func (o *OpenTelemetryCollectorOperator) GetName() string {
 	return chartName
 }
 
+// Version returns the version of the opentelemetry-operator chart that will be
+// installed or upgraded to.
+func (o *OpenTelemetryCollectorOperator) Version() string {
+	return o.version
+}
+
+// InstalledVersion returns the version of the opentelemetry-operator release, or an empty
+// string if there is no release.
+func (o *OpenTelemetryCollectorOperator) InstalledVersion() (string, error) {
+	return helmchart.InstalledVersion(o.mgr)
+}
+
+// SetVersion overrides the version of the chart that is installed or upgraded
+// to. Unlike the default version, an overridden version is upgraded to even if
+// it's older than the installed version.
+func (o *OpenTelemetryCollectorOperator) SetVersion(v string) {
+	o.version = v
+	o.pinned = true
+}
+
+// Chart returns the repository URL of the opentelemetry-operator chart and the
+// values it is installed with.
+func (o *OpenTelemetryCollectorOperator) Chart() (*url.URL, map[string]any) {
+	return otelMgrURL, values
+}
+
+// Namespace returns the namespace the opentelemetry-operator chart is installed in.
+func (o *OpenTelemetryCollectorOperator) Namespace() string {
+	return chartNamespace
+}
+
 // Install performs a Helm install of the chart.
 func (o *OpenTelemetryCollectorOperator) Install() error {
 	installed, err := o.IsInstalled()
@@ -122,7 +158,7 @@ func (o *OpenTelemetryCollectorOperator) Install() error {
 		return errors.Wrap(err, fmt.Sprintf(errFmtCreateNamespace, chartNamespace))
 	}
 
-	if err = o.mgr.Install(version, values); err != nil {
+	if err = o.mgr.Install(o.version, values); err != nil {
 		return err
 	}
 
@@ -171,3 +207,21 @@ func (o *OpenTelemetryCollectorOperator) IsInstalled() (bool, error) {
 	}
 	return false, err
 }
+
+// Upgrade performs a Helm upgrade of the chart to its version, or installs it
+// if it is not installed.
+func (o *OpenTelemetryCollectorOperator) Upgrade() error {
+	installed, err := o.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return o.Install()
+	}
+	return helmchart.Upgrade(o.mgr, o.version, o.pinned, values)
+}
+
+// Uninstall performs a Helm uninstall of the chart.
+func (o *OpenTelemetryCollectorOperator) Uninstall() error {
+	return helmchart.Uninstall(o.mgr)
+}
@@ -17,6 +17,7 @@ package helm
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -34,14 +35,17 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/upbound/up/internal/oci"
 	"github.com/upbound/up/internal/resources"
 )
 
 var (
 	providerName = "provider-helm"
-	// Package version to be installed
-	version   = "v0.19.0"
-	pkgRef, _ = name.ParseReference(fmt.Sprintf("crossplane-contrib/provider-helm:%s", version))
+	// Default package version to be installed
+	defaultVersion = "v0.19.0"
+	pkgRepo        = "crossplane-contrib/provider-helm"
+	// Registry Crossplane pulls packages from by default.
+	pkgRegistry = "xpkg.upbound.io"
 
 	objectsCRD = "releases.helm.crossplane.io"
 	xrdCRD     = "compositeresourcedefinitions.apiextensions.crossplane.io"
@@ -66,6 +70,8 @@ type Helm struct {
 	crdclient *apixv1client.ApiextensionsV1Client
 	dClient   dynamic.Interface
 	kclient   kubernetes.Interface
+	version   string
+	registry  string
 }
 
 func init() {
@@ -97,6 +103,7 @@ func New(config *rest.Config) (*Helm, error) {
 		crdclient: crdclient,
 		dClient:   dclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -105,6 +112,46 @@ func (h *Helm) GetName() string {
 	return providerName
 }
 
+// Version returns the version of the provider-helm package that will be
+// installed or upgraded to.
+func (h *Helm) Version() string {
+	return h.version
+}
+
+// InstalledVersion returns the version of the installed provider-helm package, or
+// an empty string if it was installed by other means than up.
+func (h *Helm) InstalledVersion() (string, error) {
+	u, err := h.dClient.Resource(pkgGVR).Get(context.Background(), pkgName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	ref, err := name.ParseReference((&resources.Package{Unstructured: *u}).GetPackage())
+	if err != nil {
+		return "", err
+	}
+	return ref.Identifier(), nil
+}
+
+// SetVersion overrides the version of the package that is installed or
+// upgraded to.
+func (h *Helm) SetVersion(v string) {
+	h.version = v
+}
+
+// SetRegistry overrides the registry the package is pulled from, in which it
+// is expected without its organization.
+func (h *Helm) SetRegistry(registry string) {
+	h.registry = registry
+}
+
+// Package returns the fully qualified reference of the provider-helm package.
+func (h *Helm) Package() string {
+	return fmt.Sprintf("%s/%s:%s", pkgRegistry, pkgRepo, h.version)
+}
+
 // Install performs a kubectl apply of the package.
 func (h *Helm) Install() error { //nolint:gocyclo
 	installed, err := h.IsInstalled()
@@ -136,6 +183,11 @@ func (h *Helm) Install() error { //nolint:gocyclo
 		}
 	}
 
+	pkgRef, err := h.pkgRef()
+	if err != nil {
+		return err
+	}
+
 	p := &resources.Package{}
 	p.SetName(pkgName)
 	p.SetPackage(pkgRef.String())
@@ -295,3 +347,72 @@ func (h *Helm) createProviderConfig() error {
 		)
 	return err
 }
+
+// Upgrade updates the package to its version, or installs it if it is not
+// installed.
+func (h *Helm) Upgrade() error {
+	installed, err := h.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return h.Install()
+	}
+
+	pkgRef, err := h.pkgRef()
+	if err != nil {
+		return err
+	}
+	u, err := h.dClient.Resource(pkgGVR).Get(context.Background(), pkgName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		// The provider was installed by other means than up.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p := &resources.Package{Unstructured: *u}
+	if p.GetPackage() == pkgRef.String() {
+		return nil
+	}
+	p.SetPackage(pkgRef.String())
+	_, err = h.dClient.Resource(pkgGVR).Update(context.Background(), p.GetUnstructured(), metav1.UpdateOptions{})
+	return err
+}
+
+// Uninstall deletes the package and the objects created to configure it.
+func (h *Helm) Uninstall() error {
+	ctx := context.Background()
+	deletes := []func() error{
+		func() error {
+			return h.dClient.
+				Resource(resources.ProviderConfigHelmGVK.GroupVersion().WithResource("providerconfigs")).
+				Delete(ctx, "upbound-cluster", metav1.DeleteOptions{})
+		},
+		func() error {
+			return h.dClient.Resource(pkgGVR).Delete(ctx, pkgName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return h.dClient.Resource(resources.ControllerConfigGRV).Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return h.kclient.RbacV1().ClusterRoleBindings().Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return h.kclient.CoreV1().ServiceAccounts(ns).Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+	}
+	for _, d := range deletes {
+		if err := d(); err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Helm) pkgRef() (name.Reference, error) {
+	if h.registry != "" {
+		return name.ParseReference(fmt.Sprintf("%s/%s", strings.TrimSuffix(h.registry, "/"), oci.RemoveDomainAndOrg(h.Package())))
+	}
+	return name.ParseReference(fmt.Sprintf("%s:%s", pkgRepo, h.version))
+}
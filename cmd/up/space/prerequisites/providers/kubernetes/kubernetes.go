@@ -17,6 +17,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -34,13 +35,17 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/upbound/up/internal/oci"
 	"github.com/upbound/up/internal/resources"
 )
 
 var (
 	providerName = "provider-kubernetes"
-	version      = "v0.14.0"
-	pkgRef, _    = name.ParseReference(fmt.Sprintf("crossplane-contrib/provider-kubernetes:%s", version))
+	// Default package version to be installed
+	defaultVersion = "v0.14.0"
+	pkgRepo        = "crossplane-contrib/provider-kubernetes"
+	// Registry Crossplane pulls packages from by default.
+	pkgRegistry = "xpkg.upbound.io"
 
 	objectsCRD = "objects.kubernetes.crossplane.io"
 	xrdCRD     = "compositeresourcedefinitions.apiextensions.crossplane.io"
@@ -65,6 +70,8 @@ type Kubernetes struct {
 	crdclient *apixv1client.ApiextensionsV1Client
 	dClient   dynamic.Interface
 	kclient   kubernetes.Interface
+	version   string
+	registry  string
 }
 
 func init() {
@@ -96,6 +103,7 @@ func New(config *rest.Config) (*Kubernetes, error) {
 		crdclient: crdclient,
 		dClient:   dclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -104,6 +112,46 @@ func (k *Kubernetes) GetName() string {
 	return providerName
 }
 
+// Version returns the version of the provider-kubernetes package that will be
+// installed or upgraded to.
+func (k *Kubernetes) Version() string {
+	return k.version
+}
+
+// InstalledVersion returns the version of the installed provider-kubernetes package, or
+// an empty string if it was installed by other means than up.
+func (k *Kubernetes) InstalledVersion() (string, error) {
+	u, err := k.dClient.Resource(pkgGVR).Get(context.Background(), pkgName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	ref, err := name.ParseReference((&resources.Package{Unstructured: *u}).GetPackage())
+	if err != nil {
+		return "", err
+	}
+	return ref.Identifier(), nil
+}
+
+// SetVersion overrides the version of the package that is installed or
+// upgraded to.
+func (k *Kubernetes) SetVersion(v string) {
+	k.version = v
+}
+
+// SetRegistry overrides the registry the package is pulled from, in which it
+// is expected without its organization.
+func (k *Kubernetes) SetRegistry(registry string) {
+	k.registry = registry
+}
+
+// Package returns the fully qualified reference of the provider-kubernetes package.
+func (k *Kubernetes) Package() string {
+	return fmt.Sprintf("%s/%s:%s", pkgRegistry, pkgRepo, k.version)
+}
+
 // Install performs a Helm install of the chart.
 func (k *Kubernetes) Install() error { //nolint:gocyclo
 	installed, err := k.IsInstalled()
@@ -135,6 +183,11 @@ func (k *Kubernetes) Install() error { //nolint:gocyclo
 		}
 	}
 
+	pkgRef, err := k.pkgRef()
+	if err != nil {
+		return err
+	}
+
 	p := &resources.Package{}
 	p.SetName(pkgName)
 	p.SetPackage(pkgRef.String())
@@ -293,3 +346,72 @@ func (k *Kubernetes) createProviderConfig() error {
 		)
 	return err
 }
+
+// Upgrade updates the package to its version, or installs it if it is not
+// installed.
+func (k *Kubernetes) Upgrade() error {
+	installed, err := k.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return k.Install()
+	}
+
+	pkgRef, err := k.pkgRef()
+	if err != nil {
+		return err
+	}
+	u, err := k.dClient.Resource(pkgGVR).Get(context.Background(), pkgName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		// The provider was installed by other means than up.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p := &resources.Package{Unstructured: *u}
+	if p.GetPackage() == pkgRef.String() {
+		return nil
+	}
+	p.SetPackage(pkgRef.String())
+	_, err = k.dClient.Resource(pkgGVR).Update(context.Background(), p.GetUnstructured(), metav1.UpdateOptions{})
+	return err
+}
+
+// Uninstall deletes the package and the objects created to configure it.
+func (k *Kubernetes) Uninstall() error {
+	ctx := context.Background()
+	deletes := []func() error{
+		func() error {
+			return k.dClient.
+				Resource(resources.ProviderConfigKubernetesGVK.GroupVersion().WithResource("providerconfigs")).
+				Delete(ctx, "upbound-cluster", metav1.DeleteOptions{})
+		},
+		func() error {
+			return k.dClient.Resource(pkgGVR).Delete(ctx, pkgName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return k.dClient.Resource(resources.ControllerConfigGRV).Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return k.kclient.RbacV1().ClusterRoleBindings().Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return k.kclient.CoreV1().ServiceAccounts(ns).Delete(ctx, ccName, metav1.DeleteOptions{})
+		},
+	}
+	for _, d := range deletes {
+		if err := d(); err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *Kubernetes) pkgRef() (name.Reference, error) {
+	if k.registry != "" {
+		return name.ParseReference(fmt.Sprintf("%s/%s", strings.TrimSuffix(k.registry, "/"), oci.RemoveDomainAndOrg(k.Package())))
+	}
+	return name.ParseReference(fmt.Sprintf("%s:%s", pkgRepo, k.version))
+}
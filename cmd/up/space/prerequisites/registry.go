@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prerequisites
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"helm.sh/helm/v3/pkg/postrender"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/internal/oci"
+)
+
+const errRewriteImages = "cannot rewrite images of rendered manifests"
+
+var _ postrender.PostRenderer = &registryRewriter{}
+
+// registryRewriter is a Helm post renderer that rewrites the images of the
+// rendered manifests of a chart to the registry they were mirrored to. Images
+// are expected at the same path in the registry as up space mirror --to and up
+// space init --from-bundle push them to, i.e. without their domain and
+// organization.
+type registryRewriter struct {
+	registry string
+}
+
+// Run rewrites the images of the rendered manifests.
+func (r *registryRewriter) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
+	out := &bytes.Buffer{}
+	for i, doc := range strings.Split(in.String(), "\n---") {
+		var obj any
+		if err := k8syaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, errors.Wrap(err, errRewriteImages)
+		}
+		if obj == nil {
+			continue
+		}
+		b, err := k8syaml.Marshal(r.rewrite(obj))
+		if err != nil {
+			return nil, errors.Wrap(err, errRewriteImages)
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(b)
+	}
+	return out, nil
+}
+
+// rewrite rewrites the images of an object, i.e. the image of containers and
+// images passed to them as --*-image= flags.
+func (r *registryRewriter) rewrite(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			switch s, ok := e.(string); {
+			case k == "image" && ok && s != "":
+				v[k] = r.image(s)
+			case k == "args" || k == "command":
+				args, _ := e.([]any)
+				for i, a := range args {
+					s, _ := a.(string)
+					if flag, img, ok := strings.Cut(s, "-image="); ok && strings.HasPrefix(s, "--") && img != "" {
+						args[i] = fmt.Sprintf("%s-image=%s", flag, r.image(img))
+					}
+				}
+			default:
+				v[k] = r.rewrite(e)
+			}
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = r.rewrite(e)
+		}
+	}
+	return v
+}
+
+func (r *registryRewriter) image(img string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(r.registry, "/"), oci.RemoveDomainAndOrg(img))
+}
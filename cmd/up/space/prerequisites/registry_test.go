@@ -0,0 +1,116 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prerequisites
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const renderedManifests = `---
+# Source: cert-manager/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cert-manager
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.36
+      containers:
+      - name: cert-manager-controller
+        image: "quay.io/jetstack/cert-manager-controller:v1.14.4"
+        args:
+        - --v=2
+        - --acme-http01-solver-image=quay.io/jetstack/cert-manager-acmesolver:v1.14.4
+---
+# Source: ingress-nginx/templates/controller-deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ingress-nginx-controller
+spec:
+  template:
+    spec:
+      containers:
+      - name: controller
+        image: registry.k8s.io/ingress-nginx/controller:v1.10.0@sha256:42b3f0e5d0846876b1791cd3afeb5f1cbbe4259d6f35651dcc1b5c980925379c
+---
+# Source: cert-manager/templates/crds.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: challenges.acme.cert-manager.io
+spec:
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        properties:
+          image:
+            type: string
+`
+
+func TestRegistryRewriter(t *testing.T) {
+	r := &registryRewriter{registry: "registry.example.com/mirror/"}
+	out, err := r.Run(bytes.NewBufferString(renderedManifests))
+	require.NoError(t, err)
+
+	images := []string{}
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if img, ok := v["image"].(string); ok {
+				images = append(images, img)
+			}
+			if args, ok := v["args"].([]any); ok {
+				for _, a := range args {
+					images = append(images, a.(string))
+				}
+			}
+			for _, e := range v {
+				collect(e)
+			}
+		case []any:
+			for _, e := range v {
+				collect(e)
+			}
+		}
+	}
+	docs := bytes.Split(out.Bytes(), []byte("---\n"))
+	require.Len(t, docs, 3)
+	for _, doc := range docs {
+		var obj any
+		require.NoError(t, k8syaml.Unmarshal(doc, &obj))
+		collect(obj)
+	}
+
+	// All images of the rendered manifests should reference the registry only,
+	// and other values should be unchanged.
+	require.ElementsMatch(t, []string{
+		"registry.example.com/mirror/busybox:1.36",
+		"registry.example.com/mirror/cert-manager-controller:v1.14.4",
+		"--v=2",
+		"--acme-http01-solver-image=registry.example.com/mirror/cert-manager-acmesolver:v1.14.4",
+		"registry.example.com/mirror/controller:v1.10.0@sha256:42b3f0e5d0846876b1791cd3afeb5f1cbbe4259d6f35651dcc1b5c980925379c",
+	}, images)
+	require.Contains(t, out.String(), "type: string")
+}
@@ -17,6 +17,7 @@ package uxp
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +27,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/upbound/up/cmd/up/space/prerequisites/helmchart"
 	"github.com/upbound/up/cmd/up/uxp"
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
@@ -34,9 +36,26 @@ import (
 var (
 	chartName = "universal-crossplane"
 	ns        = "upbound-system"
-	// Chart version to be installed. universal-crossplane does not include a
-	// v prefix.
-	version = "1.15.2-up.1"
+	// Default chart version to be installed. universal-crossplane does not
+	// include a v prefix.
+	defaultVersion = "1.15.2-up.1"
+
+	values = map[string]any{
+		"args": []string{
+			"--enable-usages",
+			"--max-reconcile-rate=1000",
+		},
+		"resourcesCrossplane": map[string]any{
+			"requests": map[string]any{
+				"cpu":    "500m",
+				"memory": "1Gi",
+			},
+			"limits": map[string]any{
+				"cpu":    "1000m",
+				"memory": "2Gi",
+			},
+		},
+	}
 
 	xrdCRD = "compositeresourcedefinitions.apiextensions.crossplane.io"
 
@@ -51,18 +70,20 @@ type UXP struct {
 	mgr       install.Manager
 	crdclient *apixv1client.ApiextensionsV1Client
 	kclient   kubernetes.Interface
+	version   string
+	pinned    bool
 }
 
 // New constructs a new UXP instance that can used to install the
 // universal-crossplane chart.
-func New(config *rest.Config) (*UXP, error) {
+// Additional modifiers are passed to the Helm manager of the chart.
+func New(config *rest.Config, opts ...helm.InstallerModifierFn) (*UXP, error) {
 	mgr, err := helm.NewManager(config,
 		chartName,
 		uxp.RepoURL,
 		// The default namespace is upbound-system, but we set it in order to
 		// be explicit.
-		helm.WithNamespace(ns),
-		helm.Wait())
+		append([]helm.InstallerModifierFn{helm.WithNamespace(ns), helm.Wait()}, opts...)...)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf(errFmtCreateHelmManager, chartName))
 	}
@@ -79,6 +100,7 @@ func New(config *rest.Config) (*UXP, error) {
 		mgr:       mgr,
 		crdclient: crdclient,
 		kclient:   kclient,
+		version:   defaultVersion,
 	}, nil
 }
 
@@ -87,6 +109,37 @@ func (u *UXP) GetName() string {
 	return chartName
 }
 
+// Version returns the version of the universal-crossplane chart that will be
+// installed or upgraded to.
+func (u *UXP) Version() string {
+	return u.version
+}
+
+// InstalledVersion returns the version of the universal-crossplane release, or an empty
+// string if there is no release.
+func (u *UXP) InstalledVersion() (string, error) {
+	return helmchart.InstalledVersion(u.mgr)
+}
+
+// SetVersion overrides the version of the chart that is installed or upgraded
+// to. Unlike the default version, an overridden version is upgraded to even if
+// it's older than the installed version.
+func (u *UXP) SetVersion(v string) {
+	u.version = v
+	u.pinned = true
+}
+
+// Chart returns the repository URL of the universal-crossplane chart and the
+// values it is installed with.
+func (u *UXP) Chart() (*url.URL, map[string]any) {
+	return uxp.RepoURL, values
+}
+
+// Namespace returns the namespace the universal-crossplane chart is installed in.
+func (u *UXP) Namespace() string {
+	return ns
+}
+
 // Install performs a Helm install of the chart.
 func (u *UXP) Install() error {
 	installed, err := u.IsInstalled()
@@ -109,22 +162,7 @@ func (u *UXP) Install() error {
 	if err != nil && !kerrors.IsAlreadyExists(err) {
 		return errors.Wrap(err, fmt.Sprintf(errFmtCreateNamespace, ns))
 	}
-	return u.mgr.Install(version, map[string]any{
-		"args": []string{
-			"--enable-usages",
-			"--max-reconcile-rate=1000",
-		},
-		"resourcesCrossplane": map[string]any{
-			"requests": map[string]any{
-				"cpu":    "500m",
-				"memory": "1Gi",
-			},
-			"limits": map[string]any{
-				"cpu":    "1000m",
-				"memory": "2Gi",
-			},
-		},
-	})
+	return u.mgr.Install(u.version, values)
 }
 
 // IsInstalled checks if UXP has been installed in the target cluster.
@@ -144,3 +182,21 @@ func (u *UXP) IsInstalled() (bool, error) {
 	}
 	return false, err
 }
+
+// Upgrade performs a Helm upgrade of the chart to its version, or installs it
+// if it is not installed.
+func (u *UXP) Upgrade() error {
+	installed, err := u.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return u.Install()
+	}
+	return helmchart.Upgrade(u.mgr, u.version, u.pinned, values)
+}
+
+// Uninstall performs a Helm uninstall of the chart.
+func (u *UXP) Uninstall() error {
+	return helmchart.Uninstall(u.mgr)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+const (
+	sourcePrefix = "# Source: "
+
+	errFmtInvalidSource = "invalid manifest source %q"
+	errWriteManifests   = "failed to write rendered manifests"
+)
+
+// splitManifests splits rendered manifests by the chart template they were
+// rendered from, as given by the "# Source:" comment of each document. The
+// documents of each template are returned in the order they were rendered.
+// Documents without a source are returned under an empty path.
+func splitManifests(manifests string) (map[string]string, []string) {
+	docs := map[string]*strings.Builder{}
+	var order []string
+
+	var cur strings.Builder
+	flush := func() {
+		doc := cur.String()
+		cur.Reset()
+		if strings.TrimSpace(doc) == "" {
+			return
+		}
+		path := ""
+		if line, _, _ := strings.Cut(doc, "\n"); strings.HasPrefix(line, sourcePrefix) {
+			path = strings.TrimSpace(strings.TrimPrefix(line, sourcePrefix))
+		}
+		b, ok := docs[path]
+		if !ok {
+			b = &strings.Builder{}
+			docs[path] = b
+			order = append(order, path)
+		}
+		fmt.Fprintf(b, "---\n%s", doc)
+	}
+
+	s := bufio.NewScanner(strings.NewReader(manifests))
+	s.Buffer(nil, 10*1024*1024)
+	for s.Scan() {
+		if strings.TrimRight(s.Text(), " ") == "---" {
+			flush()
+			continue
+		}
+		fmt.Fprintln(&cur, s.Text())
+	}
+	flush()
+
+	out := make(map[string]string, len(docs))
+	for p, b := range docs {
+		out[p] = b.String()
+	}
+	return out, order
+}
+
+// writeManifests writes rendered manifests to dir, with one file per chart
+// template as helm template --output-dir does. Documents without a source are
+// written to manifests.yaml.
+func writeManifests(fs afero.Fs, dir, manifests string) error {
+	docs, order := splitManifests(manifests)
+	for _, p := range order {
+		name := "manifests.yaml"
+		if p != "" {
+			if !filepath.IsLocal(filepath.FromSlash(p)) {
+				return errors.Errorf(errFmtInvalidSource, p)
+			}
+			name = filepath.FromSlash(p)
+		}
+		path := filepath.Join(dir, name)
+		if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return errors.Wrap(err, errWriteManifests)
+		}
+		if err := afero.WriteFile(fs, path, []byte(docs[p]), 0o644); err != nil {
+			return errors.Wrap(err, errWriteManifests)
+		}
+	}
+	return nil
+}
+
+// writeValues writes the values a chart is rendered with to values.yaml in
+// dir.
+func writeValues(fs afero.Fs, dir string, values []byte) error {
+	if err := fs.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrap(err, errWriteManifests)
+	}
+	return errors.Wrap(afero.WriteFile(fs, filepath.Join(dir, "values.yaml"), values, 0o644), errWriteManifests)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package space
+
+import (
+	"os"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestWriteManifests(t *testing.T) {
+	type want struct {
+		files map[string]string
+		err   error
+	}
+	cases := map[string]struct {
+		reason    string
+		manifests string
+		want      want
+	}{
+		"OneFilePerTemplate": {
+			reason: "Documents should be written to a file per template, in the order they were rendered.",
+			manifests: `---
+# Source: spaces/templates/a.yaml
+kind: ConfigMap
+metadata:
+  name: a1
+---
+# Source: spaces/templates/b.yaml
+kind: Secret
+---
+# Source: spaces/templates/a.yaml
+kind: ConfigMap
+metadata:
+  name: a2
+`,
+			want: want{
+				files: map[string]string{
+					"/out/spaces/templates/a.yaml": "---\n# Source: spaces/templates/a.yaml\nkind: ConfigMap\nmetadata:\n  name: a1\n---\n# Source: spaces/templates/a.yaml\nkind: ConfigMap\nmetadata:\n  name: a2\n",
+					"/out/spaces/templates/b.yaml": "---\n# Source: spaces/templates/b.yaml\nkind: Secret\n",
+				},
+			},
+		},
+		"NoSource": {
+			reason: "Documents without a source should be written to manifests.yaml.",
+			manifests: `kind: ConfigMap
+---
+
+---
+kind: Secret
+`,
+			want: want{
+				files: map[string]string{
+					"/out/manifests.yaml": "---\nkind: ConfigMap\n---\nkind: Secret\n",
+				},
+			},
+		},
+		"SourceOutsideDir": {
+			reason: "Sources that are not local to the output directory should be rejected.",
+			manifests: `---
+# Source: ../../etc/passwd
+kind: ConfigMap
+`,
+			want: want{
+				files: map[string]string{},
+				err:   errors.Errorf(errFmtInvalidSource, "../../etc/passwd"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := writeManifests(fs, "/out", tc.manifests)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nwriteManifests(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+
+			files := map[string]string{}
+			if err := afero.Walk(fs, "/out", func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return nil
+				}
+				b, err := afero.ReadFile(fs, path)
+				if err != nil {
+					return err
+				}
+				files[path] = string(b)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want.files, files); diff != "" {
+				t.Errorf("\n%s\nwriteManifests(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -39,6 +39,7 @@ type Cmd struct {
 	Connect    connectCmd    `cmd:"" help:"Connect an Upbound Space to the Upbound web console." aliases:"attach"`
 	Destroy    destroyCmd    `cmd:"" help:"Remove the Upbound Spaces deployment."`
 	Disconnect disconnectCmd `cmd:"" help:"Disconnect an Upbound Space from the Upbound web console." aliases:"detach"`
+	Doctor     doctorCmd     `cmd:"" help:"Check the health of an Upbound Spaces deployment and its prerequisites."`
 	Init       initCmd       `cmd:"" help:"Initialize an Upbound Spaces deployment."`
 	List       listCmd       `cmd:"" help:"List all accessible spaces in Upbound."`
 	Mirror     mirror.Cmd    `cmd:"" maturity:"alpha" help:"List of all OCI artifacts required for Spaces."`
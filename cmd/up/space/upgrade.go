@@ -65,6 +65,8 @@ type upgradeCmd struct {
 	Yes      bool   `name:"yes" type:"bool" help:"Answer yes to all questions"`
 	Rollback bool   `help:"Rollback to previously installed version on failed upgrade."`
 
+	PrerequisiteVersions map[string]string `name:"prerequisite-versions" placeholder:"NAME=VERSION;..." help:"Install or upgrade prerequisites to these versions instead of the versions required by the target Spaces version, e.g. cert-manager=v1.14.4. Installed prerequisites that are newer than the versions required by the target Spaces version are only downgraded to versions given here."`
+
 	helmMgr    install.Manager
 	prereqs    *prerequisites.Manager
 	helmParams map[string]any
@@ -172,7 +174,7 @@ func (c *upgradeCmd) AfterApply(kongCtx *kong.Context, quiet config.QuietFlag) e
 	c.features = &feature.Flags{}
 	spacefeature.EnableFeatures(c.features, c.helmParams)
 
-	prereqs, err := prerequisites.New(kubeconfig, nil, c.features, c.Version)
+	prereqs, err := prerequisites.New(kubeconfig, nil, c.features, c.Version, prerequisites.WithVersions(c.PrerequisiteVersions))
 	if err != nil {
 		return err
 	}
@@ -218,6 +220,10 @@ func (c *upgradeCmd) Run(ctx context.Context) error {
 		}
 	}
 
+	if err := c.upgradePrereqs(); err != nil {
+		return err
+	}
+
 	pterm.Info.Printfln("Required prerequisites met!")
 	pterm.Info.Printfln("Proceeding with Upbound Spaces upgrade...")
 
@@ -251,6 +257,54 @@ func (c *upgradeCmd) Run(ctx context.Context) error {
 	return nil
 }
 
+// upgradePrereqs upgrades the installed prerequisites whose installed version
+// is older than the version required by the target Spaces version, or differs
+// from the version given with --prerequisite-versions. Newer installed versions
+// are only downgraded if given explicitly.
+func (c *upgradeCmd) upgradePrereqs() error {
+	upgrade, err := c.prereqs.Outdated()
+	if err != nil {
+		return err
+	}
+	if len(upgrade) == 0 {
+		return nil
+	}
+
+	pterm.Info.Printfln("One or more installed prerequisites will be upgraded:")
+	pterm.Println()
+	for _, p := range upgrade {
+		pterm.Println(fmt.Sprintf("⬆️  %s to %s", p.GetName(), p.Version()))
+	}
+	if !c.Yes {
+		pterm.Println() // Blank line
+		confirm := pterm.DefaultInteractiveConfirm
+		confirm.DefaultText = "Would you like to upgrade them now?"
+		result, _ := confirm.Show()
+		pterm.Println() // Blank line
+		if !result {
+			pterm.Warning.Println("Skipping the upgrade of prerequisites.")
+			return nil
+		}
+	}
+
+	for i, p := range upgrade {
+		if err := upterm.WrapWithSuccessSpinner(
+			upterm.StepCounter(
+				fmt.Sprintf("Upgrading %s to %s", p.GetName(), p.Version()),
+				i+1,
+				len(upgrade),
+			),
+			upterm.CheckmarkSuccessSpinner,
+			p.Upgrade,
+		); err != nil {
+			fmt.Println()
+			fmt.Println()
+			return err
+		}
+	}
+	return nil
+}
+
 func upgradeVersionBounds(_ string, ch *chart.Chart) error {
 	return checkVersion(fmt.Sprintf("unsupported target chart version %s", ch.Metadata.Version), upgradeVersionConstraints, ch.Metadata.Version)
 }
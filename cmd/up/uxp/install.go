@@ -28,6 +28,7 @@ import (
 
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/install/helm"
+	"github.com/upbound/up/internal/signature"
 )
 
 const (
@@ -41,12 +42,17 @@ func (c *installCmd) AfterApply(insCtx *install.Context) error {
 	if c.Unstable {
 		repo = uxpUnstableRepoURL
 	}
+	verifier, err := c.Verify.Verifier()
+	if err != nil {
+		return err
+	}
 	mgr, err := helm.NewManager(insCtx.Kubeconfig,
 		chartName,
 		repo,
 		helm.WithNamespace(insCtx.Namespace),
 		helm.WithChart(c.Bundle),
-		helm.WithAlternateChart(alternateChartName))
+		helm.WithAlternateChart(alternateChartName),
+		helm.WithVerifier(verifier))
 	if err != nil {
 		return err
 	}
@@ -84,6 +90,7 @@ type installCmd struct {
 	Unstable bool   `help:"Allow installing unstable versions."`
 
 	install.CommonParams
+	Verify signature.VerifyFlags `embed:""`
 }
 
 // Run executes the install command.
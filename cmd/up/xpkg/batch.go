@@ -316,7 +316,7 @@ func (c *batchCmd) pushWithRetry(p pterm.TextPrinter, upCtx *upbound.Context, im
 	retryMsg := ""
 	for i := uint(0); i < tries; i++ {
 		p.Printfln("Pushing xpkg to %s.%s", t, retryMsg)
-		err := PushImages(p, upCtx, imgs, t, c.Create, c.Flags.Profile)
+		err := PushImages(p, upCtx, imgs, t, c.Create, c.Flags.Profile, nil)
 		if err == nil {
 			break
 		}
@@ -37,6 +37,7 @@ import (
 
 	"github.com/upbound/up-sdk-go/service/repositories"
 	"github.com/upbound/up/internal/credhelper"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/xpkg"
 )
@@ -73,6 +74,8 @@ type pushCmd struct {
 	Package []string `short:"f" help:"Path to packages. If not specified and only one package exists in current directory it will be used."`
 	Create  bool     `help:"Create repository on push if it does not exist."`
 
+	Sign signature.SignFlags `embed:""`
+
 	// Common Upbound API configuration
 	Flags upbound.Flags `embed:""`
 }
@@ -101,16 +104,16 @@ func (c *pushCmd) Run(p pterm.TextPrinter, upCtx *upbound.Context) error { //nol
 		}
 		imgs = append(imgs, img)
 	}
-	return PushImages(p, upCtx, imgs, c.Tag, c.Create, c.Flags.Profile)
-}
-
-func PushImages(p pterm.TextPrinter, upCtx *upbound.Context, imgs []v1.Image, t string, create bool, profile string) error { //nolint:gocyclo
-	tag, err := name.NewTag(t, name.WithDefaultRegistry(upCtx.RegistryEndpoint.Hostname()))
+	signer, err := c.Sign.Signer(remote.WithAuthFromKeychain(keychain(upCtx, c.Flags.Profile)))
 	if err != nil {
 		return err
 	}
+	return PushImages(p, upCtx, imgs, c.Tag, c.Create, c.Flags.Profile, signer)
+}
 
-	kc := authn.NewMultiKeychain(
+// keychain returns the keychain packages are pushed with.
+func keychain(upCtx *upbound.Context, profile string) authn.Keychain {
+	return authn.NewMultiKeychain(
 		authn.NewKeychainFromHelper(
 			credhelper.New(
 				credhelper.WithDomain(upCtx.Domain.Hostname()),
@@ -119,6 +122,17 @@ func PushImages(p pterm.TextPrinter, upCtx *upbound.Context, imgs []v1.Image, t
 		),
 		authn.DefaultKeychain,
 	)
+}
+
+// PushImages pushes packages to a tag, as an index if there are several. The
+// tag is signed with signer if it is not nil.
+func PushImages(p pterm.TextPrinter, upCtx *upbound.Context, imgs []v1.Image, t string, create bool, profile string, signer *signature.Signer) error { //nolint:gocyclo
+	tag, err := name.NewTag(t, name.WithDefaultRegistry(upCtx.RegistryEndpoint.Hostname()))
+	if err != nil {
+		return err
+	}
+
+	kc := keychain(upCtx, profile)
 
 	if create {
 		if !strings.Contains(tag.RegistryStr(), upCtx.RegistryEndpoint.Hostname()) {
@@ -204,6 +218,17 @@ func PushImages(p pterm.TextPrinter, upCtx *upbound.Context, imgs []v1.Image, t
 		}
 	}
 
+	if signer != nil {
+		d, err := remote.Head(tag, remote.WithAuthFromKeychain(kc))
+		if err != nil {
+			return err
+		}
+		if err := signer.Sign(context.Background(), tag, d.Digest); err != nil {
+			return err
+		}
+		p.Printfln("xpkg %s signed", tag.Context().Digest(d.Digest.String()))
+	}
+
 	p.Printfln("xpkg pushed to %s", tag.String())
 	return nil
 }
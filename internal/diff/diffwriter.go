@@ -50,6 +50,19 @@ const (
 	indentSymbol = "   "
 )
 
+// OutputFormat is the format used to write a set of resource diffs.
+type OutputFormat string
+
+const (
+	// OutputFormatPretty writes the diffs as a terminal-friendly tree.
+	OutputFormatPretty OutputFormat = "pretty"
+	// OutputFormatJSON writes the diffs as a structured JSON document.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatMarkdown writes the diffs as a Markdown summary, suitable
+	// for posting as a pull request comment.
+	OutputFormatMarkdown OutputFormat = "markdown"
+)
+
 type ResourceDiff struct {
 	SimulationChange spacesv1alpha1.SimulationChange
 	Diff             diffv3.Changelog
@@ -61,6 +74,55 @@ type diffWriter interface {
 	Write(resources []ResourceDiff) error
 }
 
+// writerOptions configures the writer returned by NewWriter.
+type writerOptions struct {
+	redactor   *Redactor
+	violations []PolicyViolation
+}
+
+// WriterOption modifies the writer returned by NewWriter.
+type WriterOption func(o *writerOptions)
+
+// WithRedactor sets the redactor used to mask sensitive values before they
+// are written. Defaults to a redactor that only masks core Secrets.
+func WithRedactor(r *Redactor) WriterOption {
+	return func(o *writerOptions) {
+		o.redactor = r
+	}
+}
+
+// WithViolations sets the policy violations that are included in the report.
+// Only the JSON and Markdown formats include violations.
+func WithViolations(v []PolicyViolation) WriterOption {
+	return func(o *writerOptions) {
+		o.violations = v
+	}
+}
+
+// NewWriter returns a writer that outputs diffs in the given format. Styling
+// only applies to the pretty-printed format.
+func NewWriter(format OutputFormat, w io.Writer, styling bool, opts ...WriterOption) (diffWriter, error) {
+	o := &writerOptions{
+		redactor: DefaultRedactor(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var next diffWriter
+	switch format {
+	case OutputFormatPretty, "":
+		next = NewPrettyPrintWriter(w, styling)
+	case OutputFormatJSON:
+		next = NewJSONWriter(w, o.violations...)
+	case OutputFormatMarkdown:
+		next = NewMarkdownWriter(w, o.violations...)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return &redactingWriter{next: next, redactor: o.redactor}, nil
+}
+
 // prettyPrintWriter implements diffWriter, writing its responses to a buffer that can
 // be sent to stdout.
 type prettyPrintWriter struct {
@@ -68,9 +130,9 @@ type prettyPrintWriter struct {
 	styles outputStyles
 }
 
-// getLoggedOutputByType returns the value that should be logged by the writer
-// depending on the type.
-func (p *prettyPrintWriter) getLoggedOutputByType(value any) string {
+// formatValue returns the value that should be logged by a writer depending on
+// the type.
+func formatValue(value any) string {
 	if value == nil {
 		return "<nil>"
 	}
@@ -94,8 +156,8 @@ func (p *prettyPrintWriter) getLoggedOutputByType(value any) string {
 
 // printFieldUpdate prints the before and after values of a given field.
 func (p *prettyPrintWriter) printFieldUpdate(prefix string, change diffv3.Change) {
-	from := p.getLoggedOutputByType(change.From)
-	to := p.getLoggedOutputByType(change.To)
+	from := formatValue(change.From)
+	to := formatValue(change.To)
 	fmt.Fprintf(p.w, changeDeleteFmt, prefix+treeSymbolT, p.styles.Delete(from))
 	fmt.Fprintf(p.w, changeCreateFmt, prefix+treeSymbolL, p.styles.Create(to))
 }
@@ -161,12 +223,6 @@ func (p *prettyPrintWriter) Write(resources []ResourceDiff) error {
 
 		fmt.Fprintf(p.w, changeUpdateFmt, "", p.styles.Update(formatObjectReference(ref)))
 
-		// hide any changes to secrets
-		if change.SimulationChange.ObjectReference.Kind == "Secret" &&
-			change.SimulationChange.ObjectReference.APIVersion == "v1" {
-			continue
-		}
-
 		root := BuildDiffTree(change)
 		for i, child := range maps.Values(root.children) {
 			p.printNode("", i == (len(root.children)-1), []string{""}, child)
@@ -178,7 +234,15 @@ func (p *prettyPrintWriter) Write(resources []ResourceDiff) error {
 // writeSummary writes a summarised version of the differences to the associated
 // buffer.
 func (p *prettyPrintWriter) writeSummary(resources []ResourceDiff) {
-	updated, created, deleted := 0, 0, 0
+	created, updated, deleted := countChanges(resources)
+
+	fmt.Fprintf(p.w, changeSummaryFmt, p.styles.Create(created), p.styles.Update(updated), p.styles.Delete(deleted))
+	fmt.Fprintf(p.w, "\n\n")
+}
+
+// countChanges returns the number of created, updated and deleted resources in
+// the set of diffs.
+func countChanges(resources []ResourceDiff) (created, updated, deleted int) {
 	for _, res := range resources {
 		switch res.SimulationChange.Change {
 		case spacesv1alpha1.SimulationChangeTypeCreate:
@@ -190,9 +254,7 @@ func (p *prettyPrintWriter) writeSummary(resources []ResourceDiff) {
 		case spacesv1alpha1.SimulationChangeTypeUnknown:
 		}
 	}
-
-	fmt.Fprintf(p.w, changeSummaryFmt, p.styles.Create(created), p.styles.Update(updated), p.styles.Delete(deleted))
-	fmt.Fprintf(p.w, "\n\n")
+	return created, updated, deleted
 }
 
 // formatFieldPath returns a pretty-printed a field path.
@@ -0,0 +1,127 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// JSONReport is the document written by the JSON writer.
+type JSONReport struct {
+	Summary    JSONSummary     `json:"summary"`
+	Resources  []JSONResource  `json:"resources"`
+	Violations []JSONViolation `json:"violations,omitempty"`
+}
+
+// JSONSummary counts the resources by change type.
+type JSONSummary struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// JSONResource is a single changed resource.
+type JSONResource struct {
+	Change     string      `json:"change"`
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Name       string      `json:"name"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Fields     []JSONField `json:"fields,omitempty"`
+}
+
+// JSONField is a single changed field within an updated resource.
+type JSONField struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// JSONViolation is a policy rule that was violated by the changes.
+type JSONViolation struct {
+	Rule        string   `json:"rule"`
+	Description string   `json:"description,omitempty"`
+	Max         int      `json:"max"`
+	Resources   []string `json:"resources"`
+}
+
+var _ diffWriter = &jsonWriter{}
+
+// jsonWriter implements diffWriter, writing the diffs as a JSON document.
+type jsonWriter struct {
+	w          io.Writer
+	violations []PolicyViolation
+}
+
+// Write writes the diffed resources as a JSON document to the associated
+// buffer.
+func (j *jsonWriter) Write(resources []ResourceDiff) error {
+	created, updated, deleted := countChanges(resources)
+	report := JSONReport{
+		Summary: JSONSummary{
+			Created: created,
+			Updated: updated,
+			Deleted: deleted,
+		},
+		Resources: make([]JSONResource, 0, len(resources)),
+	}
+
+	for _, change := range resources {
+		ref := change.SimulationChange.ObjectReference
+		res := JSONResource{
+			Change:     string(change.SimulationChange.Change),
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+		}
+		if ref.Namespace != nil {
+			res.Namespace = *ref.Namespace
+		}
+
+		for _, d := range change.Diff {
+			res.Fields = append(res.Fields, JSONField{
+				Type: d.Type,
+				Path: formatFieldPath(d.Path),
+				From: d.From,
+				To:   d.To,
+			})
+		}
+
+		report.Resources = append(report.Resources, res)
+	}
+
+	for _, v := range j.violations {
+		report.Violations = append(report.Violations, JSONViolation{
+			Rule:        v.Rule.Name,
+			Description: v.Rule.Description,
+			Max:         v.Rule.Max,
+			Resources:   v.Resources,
+		})
+	}
+
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(report), "cannot encode diff as JSON")
+}
+
+// NewJSONWriter creates a new writer that, when calling `Write()`, will output
+// a JSON document to the writer, including any policy violations.
+func NewJSONWriter(w io.Writer, violations ...PolicyViolation) *jsonWriter {
+	return &jsonWriter{w: w, violations: violations}
+}
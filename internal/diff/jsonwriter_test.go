@@ -0,0 +1,141 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	diffv3 "github.com/r3labs/diff/v3"
+	"k8s.io/utils/ptr"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+func TestJSONWriter(t *testing.T) {
+	cases := map[string]struct {
+		input      []ResourceDiff
+		violations []PolicyViolation
+		want       JSONReport
+	}{
+		"Empty": {
+			input: []ResourceDiff{},
+			want:  JSONReport{Resources: []JSONResource{}},
+		},
+		"CreateAndUpdate": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeCreate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "new",
+						},
+					},
+				},
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "old", Namespace: ptr.To("default"),
+						},
+					},
+					Diff: diffv3.Changelog{
+						{Type: diffv3.UPDATE, Path: []string{"spec", "region"}, From: "us-east-1", To: "us-west-2"},
+					},
+				},
+			},
+			want: JSONReport{
+				Summary: JSONSummary{Created: 1, Updated: 1},
+				Resources: []JSONResource{
+					{Change: "Create", APIVersion: "example.org/v1", Kind: "Bucket", Name: "new"},
+					{
+						Change: "Update", APIVersion: "example.org/v1", Kind: "Bucket", Name: "old", Namespace: "default",
+						Fields: []JSONField{{Type: "update", Path: "spec.region", From: "us-east-1", To: "us-west-2"}},
+					},
+				},
+			},
+		},
+		"SecretFieldsRedacted": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "v1", Kind: "Secret", Name: "creds",
+						},
+					},
+					Diff: diffv3.Changelog{
+						{Type: diffv3.UPDATE, Path: []string{"data", "password"}, From: "a", To: "b"},
+					},
+				},
+			},
+			want: JSONReport{
+				Summary: JSONSummary{Updated: 1},
+				Resources: []JSONResource{{
+					Change: "Update", APIVersion: "v1", Kind: "Secret", Name: "creds",
+					Fields: []JSONField{{Type: "update", Path: "data.password", From: "<redacted>", To: "<redacted>"}},
+				}},
+			},
+		},
+		"Violations": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeDelete,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone",
+						},
+					},
+				},
+			},
+			violations: []PolicyViolation{{
+				Rule:      PolicyRule{Name: "no-bucket-deletes", Description: "Buckets hold data.", Match: PolicyMatch{Change: spacesv1alpha1.SimulationChangeTypeDelete, Kind: "Bucket"}},
+				Resources: []string{"Bucket.example.org/v1 gone"},
+			}},
+			want: JSONReport{
+				Summary: JSONSummary{Deleted: 1},
+				Resources: []JSONResource{
+					{Change: "Delete", APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone"},
+				},
+				Violations: []JSONViolation{
+					{Rule: "no-bucket-deletes", Description: "Buckets hold data.", Resources: []string{"Bucket.example.org/v1 gone"}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(OutputFormatJSON, buf, false, WithViolations(tc.violations))
+			if err != nil {
+				t.Fatalf("NewWriter(...): unexpected error: %v", err)
+			}
+			if err := w.Write(tc.input); err != nil {
+				t.Fatalf("Write(...): unexpected error: %v", err)
+			}
+
+			var got JSONReport
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("Write(...): invalid JSON: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Write(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
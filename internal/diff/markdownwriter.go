@@ -0,0 +1,106 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+var _ diffWriter = &markdownWriter{}
+
+// markdownWriter implements diffWriter, writing the diffs as Markdown with a
+// collapsible section for each updated resource.
+type markdownWriter struct {
+	w          io.Writer
+	violations []PolicyViolation
+}
+
+// Write writes the diffed resources as Markdown to the associated buffer.
+func (m *markdownWriter) Write(resources []ResourceDiff) error {
+	created, updated, deleted := countChanges(resources)
+	fmt.Fprintf(m.w, "### "+changeSummaryFmt+"\n\n", fmt.Sprint(created), fmt.Sprint(updated), fmt.Sprint(deleted))
+
+	for _, change := range resources {
+		ref := change.SimulationChange.ObjectReference
+		name := markdownCode(formatObjectReference(ref))
+
+		switch change.SimulationChange.Change { //nolint:exhaustive
+		case spacesv1alpha1.SimulationChangeTypeCreate:
+			fmt.Fprintf(m.w, "- [+] %s\n", name)
+			continue
+		case spacesv1alpha1.SimulationChangeTypeDelete:
+			fmt.Fprintf(m.w, "- [-] %s\n", name)
+			continue
+		}
+
+		if len(change.Diff) == 0 {
+			fmt.Fprintf(m.w, "- [~] %s\n", name)
+			continue
+		}
+
+		fmt.Fprintf(m.w, "\n<details>\n<summary>[~] %s (%d fields changed)</summary>\n\n", formatObjectReference(ref), len(change.Diff))
+		fmt.Fprint(m.w, "| Field | Before | After |\n| --- | --- | --- |\n")
+		for _, d := range change.Diff {
+			fmt.Fprintf(m.w, "| %s | %s | %s |\n",
+				markdownCode(formatFieldPath(d.Path)),
+				markdownCode(formatValue(d.From)),
+				markdownCode(formatValue(d.To)),
+			)
+		}
+		fmt.Fprint(m.w, "\n</details>\n\n")
+	}
+
+	m.writeViolations()
+	return nil
+}
+
+// writeViolations writes a section naming each violated policy rule.
+func (m *markdownWriter) writeViolations() {
+	if len(m.violations) == 0 {
+		return
+	}
+	fmt.Fprint(m.w, "\n#### Policy violations\n\n")
+	for _, v := range m.violations {
+		fmt.Fprintf(m.w, "- %s matched %d changes (max %d)", markdownCode(v.Rule.Name), len(v.Resources), v.Rule.Max)
+		if v.Rule.Description != "" {
+			fmt.Fprintf(m.w, ": %s", v.Rule.Description)
+		}
+		fmt.Fprint(m.w, "\n")
+		for _, r := range v.Resources {
+			fmt.Fprintf(m.w, "  - %s\n", markdownCode(r))
+		}
+	}
+}
+
+// markdownCode wraps the value in an inline code span that is safe to use
+// inside a table cell.
+func markdownCode(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+// NewMarkdownWriter creates a new writer that, when calling `Write()`, will
+// output a Markdown summary to the writer, including any policy violations.
+func NewMarkdownWriter(w io.Writer, violations ...PolicyViolation) *markdownWriter {
+	return &markdownWriter{w: w, violations: violations}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	diffv3 "github.com/r3labs/diff/v3"
+	"k8s.io/utils/ptr"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+func TestMarkdownWriter(t *testing.T) {
+	cases := map[string]struct {
+		input      []ResourceDiff
+		violations []PolicyViolation
+		want       string
+	}{
+		"Empty": {
+			input: []ResourceDiff{},
+			want:  "### Simulation: 0 resources added, 0 resources changed, 0 resources deleted\n\n",
+		},
+		"CreateUpdateAndDelete": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeCreate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "new",
+						},
+					},
+				},
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "old", Namespace: ptr.To("default"),
+						},
+					},
+					Diff: diffv3.Changelog{
+						{Type: diffv3.UPDATE, Path: []string{"spec", "region"}, From: "us-east-1", To: "us-west-2"},
+					},
+				},
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeDelete,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone",
+						},
+					},
+				},
+			},
+			want: "### Simulation: 1 resources added, 1 resources changed, 1 resources deleted\n\n" +
+				"- [+] `Bucket.example.org/v1 new`\n" +
+				"\n<details>\n<summary>[~] Bucket.example.org/v1 default/old (1 fields changed)</summary>\n\n" +
+				"| Field | Before | After |\n| --- | --- | --- |\n" +
+				"| `spec.region` | `\"us-east-1\"` | `\"us-west-2\"` |\n" +
+				"\n</details>\n\n" +
+				"- [-] `Bucket.example.org/v1 gone`\n",
+		},
+		"UpdateWithoutFields": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "old",
+						},
+					},
+				},
+			},
+			want: "### Simulation: 0 resources added, 1 resources changed, 0 resources deleted\n\n" +
+				"- [~] `Bucket.example.org/v1 old`\n",
+		},
+		"TableCellsEscaped": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "old",
+						},
+					},
+					Diff: diffv3.Changelog{
+						{Type: diffv3.UPDATE, Path: []string{"spec", "query"}, From: "a|b", To: "`c`"},
+					},
+				},
+			},
+			want: "### Simulation: 0 resources added, 1 resources changed, 0 resources deleted\n\n" +
+				"\n<details>\n<summary>[~] Bucket.example.org/v1 old (1 fields changed)</summary>\n\n" +
+				"| Field | Before | After |\n| --- | --- | --- |\n" +
+				"| `spec.query` | `\"a\\|b\"` | `` \"`c`\" `` |\n" +
+				"\n</details>\n\n",
+		},
+		"SecretFieldsRedacted": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeUpdate,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "v1", Kind: "Secret", Name: "creds",
+						},
+					},
+					Diff: diffv3.Changelog{
+						{Type: diffv3.UPDATE, Path: []string{"data", "password"}, From: "a", To: "b"},
+					},
+				},
+			},
+			want: "### Simulation: 0 resources added, 1 resources changed, 0 resources deleted\n\n" +
+				"\n<details>\n<summary>[~] Secret.v1 creds (1 fields changed)</summary>\n\n" +
+				"| Field | Before | After |\n| --- | --- | --- |\n" +
+				"| `data.password` | `\"<redacted>\"` | `\"<redacted>\"` |\n" +
+				"\n</details>\n\n",
+		},
+		"Violations": {
+			input: []ResourceDiff{
+				{
+					SimulationChange: spacesv1alpha1.SimulationChange{
+						Change: spacesv1alpha1.SimulationChangeTypeDelete,
+						ObjectReference: spacesv1alpha1.ChangedObjectReference{
+							APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone",
+						},
+					},
+				},
+			},
+			violations: []PolicyViolation{{
+				Rule:      PolicyRule{Name: "no-bucket-deletes", Description: "Buckets hold data."},
+				Resources: []string{"Bucket.example.org/v1 gone"},
+			}},
+			want: "### Simulation: 0 resources added, 0 resources changed, 1 resources deleted\n\n" +
+				"- [-] `Bucket.example.org/v1 gone`\n" +
+				"\n#### Policy violations\n\n" +
+				"- `no-bucket-deletes` matched 1 changes (max 0): Buckets hold data.\n" +
+				"  - `Bucket.example.org/v1 gone`\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(OutputFormatMarkdown, buf, false, WithViolations(tc.violations))
+			if err != nil {
+				t.Fatalf("NewWriter(...): unexpected error: %v", err)
+			}
+			if err := w.Write(tc.input); err != nil {
+				t.Fatalf("Write(...): unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("Write(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+// Policy is a set of rules that a set of resource diffs is evaluated against.
+//
+// An example policy file:
+//
+//	rules:
+//	- name: no-rds-deletes
+//	  match:
+//	    change: Delete
+//	    kind: RDSInstance
+//	- name: region-is-immutable
+//	  match:
+//	    change: Update
+//	    field: spec.forProvider.region
+//	- name: at-most-five-creates
+//	  match:
+//	    change: Create
+//	  max: 5
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule counts the changes matched by a rule and is violated when that
+// count exceeds the maximum.
+type PolicyRule struct {
+	// Name identifies the rule in the report.
+	Name string `json:"name"`
+	// Description is an optional, human-readable explanation of the rule.
+	Description string `json:"description,omitempty"`
+	// Match selects the changes this rule applies to.
+	Match PolicyMatch `json:"match"`
+	// Max is the number of matched changes that are allowed. Defaults to
+	// zero, meaning any matched change violates the rule.
+	Max int `json:"max,omitempty"`
+}
+
+// PolicyMatch selects changed resources. Empty fields match any value.
+type PolicyMatch struct {
+	// Change is the change type, one of Create, Update or Delete.
+	Change spacesv1alpha1.SimulationChangeType `json:"change,omitempty"`
+	// APIVersion of the changed resource.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the changed resource.
+	Kind string `json:"kind,omitempty"`
+	// Namespace of the changed resource.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the changed resource.
+	Name string `json:"name,omitempty"`
+	// Field is a dot-separated field path. When set, only updates changing
+	// this field, or a field below it, are matched.
+	Field string `json:"field,omitempty"`
+}
+
+// PolicyViolation is a rule that was violated, along with the resources that
+// were matched by it.
+type PolicyViolation struct {
+	Rule      PolicyRule
+	Resources []string
+}
+
+// String returns a single line description of the violation.
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("rule %q matched %d changes (max %d): %s", v.Rule.Name, len(v.Resources), v.Rule.Max, strings.Join(v.Resources, ", "))
+}
+
+// LoadPolicy reads a policy from the given YAML file.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path) // nolint:gosec // the path is provided by the user
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read policy file")
+	}
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(b, p); err != nil {
+		return nil, errors.Wrap(err, "cannot parse policy file")
+	}
+	return p, p.Validate()
+}
+
+// Validate checks that the policy rules are well formed.
+func (p *Policy) Validate() error {
+	names := map[string]bool{}
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			return errors.Errorf("rule %d is missing a name", i)
+		}
+		if names[r.Name] {
+			return errors.Errorf("rule %q is defined more than once", r.Name)
+		}
+		names[r.Name] = true
+
+		if r.Max < 0 {
+			return errors.Errorf("rule %q has a negative max", r.Name)
+		}
+		switch r.Match.Change {
+		case "", spacesv1alpha1.SimulationChangeTypeCreate, spacesv1alpha1.SimulationChangeTypeUpdate, spacesv1alpha1.SimulationChangeTypeDelete:
+		default:
+			return errors.Errorf("rule %q has an unknown change type %q", r.Name, r.Match.Change)
+		}
+		if r.Match.Field != "" && r.Match.Change != "" && r.Match.Change != spacesv1alpha1.SimulationChangeTypeUpdate {
+			return errors.Errorf("rule %q matches a field, which is only supported for updates", r.Name)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the rules that were violated by the set of diffs, in the
+// order they were defined.
+func (p *Policy) Evaluate(resources []ResourceDiff) []PolicyViolation {
+	violations := []PolicyViolation{}
+	for _, r := range p.Rules {
+		matched := []string{}
+		for _, res := range resources {
+			if r.Match.matches(res) {
+				matched = append(matched, formatObjectReference(res.SimulationChange.ObjectReference))
+			}
+		}
+		if len(matched) > r.Max {
+			violations = append(violations, PolicyViolation{Rule: r, Resources: matched})
+		}
+	}
+	return violations
+}
+
+// matches returns true if the diffed resource is selected by the match.
+func (m PolicyMatch) matches(res ResourceDiff) bool {
+	change := res.SimulationChange
+	ref := change.ObjectReference
+
+	if m.Change != "" && m.Change != change.Change {
+		return false
+	}
+	if m.APIVersion != "" && m.APIVersion != ref.APIVersion {
+		return false
+	}
+	if m.Kind != "" && m.Kind != ref.Kind {
+		return false
+	}
+	if m.Name != "" && m.Name != ref.Name {
+		return false
+	}
+	if m.Namespace != "" && (ref.Namespace == nil || m.Namespace != *ref.Namespace) {
+		return false
+	}
+	if m.Field == "" {
+		return true
+	}
+
+	for _, d := range res.Diff {
+		path := formatFieldPath(d.Path)
+		if path == m.Field || strings.HasPrefix(path, m.Field+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	diffv3 "github.com/r3labs/diff/v3"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+func resourceDiff(change spacesv1alpha1.SimulationChangeType, kind, name string, paths ...[]string) ResourceDiff {
+	rd := ResourceDiff{
+		SimulationChange: spacesv1alpha1.SimulationChange{
+			Change: change,
+			ObjectReference: spacesv1alpha1.ChangedObjectReference{
+				APIVersion: "example.org/v1", Kind: kind, Name: name,
+			},
+		},
+	}
+	for _, p := range paths {
+		rd.Diff = append(rd.Diff, diffv3.Change{Type: diffv3.UPDATE, Path: p})
+	}
+	return rd
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	diffs := []ResourceDiff{
+		resourceDiff(spacesv1alpha1.SimulationChangeTypeCreate, "Bucket", "a"),
+		resourceDiff(spacesv1alpha1.SimulationChangeTypeCreate, "Bucket", "b"),
+		resourceDiff(spacesv1alpha1.SimulationChangeTypeDelete, "RDSInstance", "db"),
+		resourceDiff(spacesv1alpha1.SimulationChangeTypeUpdate, "Bucket", "c", []string{"spec", "forProvider", "region"}),
+		resourceDiff(spacesv1alpha1.SimulationChangeTypeUpdate, "Bucket", "d", []string{"spec", "forProvider", "tags", "env"}),
+	}
+
+	cases := map[string]struct {
+		rules []PolicyRule
+		want  []string
+	}{
+		"NoRules": {
+			want: []string{},
+		},
+		"DeleteOfKind": {
+			rules: []PolicyRule{{Name: "no-rds-deletes", Match: PolicyMatch{Change: spacesv1alpha1.SimulationChangeTypeDelete, Kind: "RDSInstance"}}},
+			want:  []string{"no-rds-deletes"},
+		},
+		"FieldChanged": {
+			rules: []PolicyRule{
+				{Name: "region", Match: PolicyMatch{Field: "spec.forProvider.region"}},
+				{Name: "other", Match: PolicyMatch{Field: "spec.forProvider.reg"}},
+			},
+			want: []string{"region"},
+		},
+		"FieldPrefix": {
+			rules: []PolicyRule{{Name: "tags", Match: PolicyMatch{Field: "spec.forProvider.tags"}}},
+			want:  []string{"tags"},
+		},
+		"CreatesWithinMax": {
+			rules: []PolicyRule{{Name: "creates", Match: PolicyMatch{Change: spacesv1alpha1.SimulationChangeTypeCreate}, Max: 2}},
+			want:  []string{},
+		},
+		"CreatesOverMax": {
+			rules: []PolicyRule{{Name: "creates", Match: PolicyMatch{Change: spacesv1alpha1.SimulationChangeTypeCreate}, Max: 1}},
+			want:  []string{"creates"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := &Policy{Rules: tc.rules}
+			if err := p.Validate(); err != nil {
+				t.Fatalf("Validate(): unexpected error: %v", err)
+			}
+
+			got := []string{}
+			for _, v := range p.Evaluate(diffs) {
+				got = append(got, v.Rule.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Evaluate(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
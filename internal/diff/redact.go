@@ -0,0 +1,250 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/pkg/errors"
+	diffv3 "github.com/r3labs/diff/v3"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+const (
+	// redactedValue replaces the value of any masked field.
+	redactedValue = "<redacted>"
+
+	// wildcardSegment matches any single segment of a field path.
+	wildcardSegment = "*"
+
+	// sensitiveFormat is the OpenAPI format that marks a field as sensitive
+	// in a CRD schema.
+	sensitiveFormat = "password"
+)
+
+// RedactionRule masks the values of fields in changed resources.
+type RedactionRule struct {
+	// APIVersion of the resources this rule applies to. Applies to any
+	// APIVersion if empty.
+	APIVersion string
+	// Kind of the resources this rule applies to. Applies to any Kind if
+	// empty.
+	Kind string
+	// Paths are the field paths to mask. A path also masks every field below
+	// it, and `[*]` matches any single key or index. Every field is masked if
+	// empty.
+	Paths []string
+
+	segments [][]string
+}
+
+// Redactor masks sensitive values in resource diffs before they are written.
+type Redactor struct {
+	rules []RedactionRule
+}
+
+// NewRedactor returns a redactor for the given rules. Core Secrets are always
+// fully masked.
+func NewRedactor(rules ...RedactionRule) (*Redactor, error) {
+	r := &Redactor{}
+	for _, rule := range append([]RedactionRule{secretRedactionRule()}, rules...) {
+		if err := r.add(rule); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// DefaultRedactor returns a redactor that only masks core Secrets.
+func DefaultRedactor() *Redactor {
+	r, _ := NewRedactor()
+	return r
+}
+
+// secretRedactionRule masks every field of a core Secret.
+func secretRedactionRule() RedactionRule {
+	return RedactionRule{APIVersion: "v1", Kind: "Secret"}
+}
+
+// add parses the rule paths and adds it to the redactor.
+func (r *Redactor) add(rule RedactionRule) error {
+	rule.segments = make([][]string, 0, len(rule.Paths))
+	for _, p := range rule.Paths {
+		segs, err := fieldpath.Parse(p)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse redacted path %q", p)
+		}
+		s := make([]string, 0, len(segs))
+		for _, seg := range segs {
+			if seg.Type == fieldpath.SegmentIndex {
+				s = append(s, strconv.FormatUint(uint64(seg.Index), 10))
+				continue
+			}
+			s = append(s, seg.Field)
+		}
+		rule.segments = append(rule.segments, s)
+	}
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// Redact returns a copy of the resource diffs with sensitive values masked.
+func (r *Redactor) Redact(resources []ResourceDiff) []ResourceDiff {
+	out := make([]ResourceDiff, 0, len(resources))
+	for _, res := range resources {
+		ref := res.SimulationChange.ObjectReference
+		rules := r.rulesFor(ref)
+		if len(rules) == 0 || len(res.Diff) == 0 {
+			out = append(out, res)
+			continue
+		}
+
+		masked := make(diffv3.Changelog, 0, len(res.Diff))
+		for _, d := range res.Diff {
+			if masks(rules, d.Path) {
+				d.From = maskValue(d.From)
+				d.To = maskValue(d.To)
+			}
+			masked = append(masked, d)
+		}
+		res.Diff = masked
+		out = append(out, res)
+	}
+	return out
+}
+
+// rulesFor returns the rules that apply to the referenced resource.
+func (r *Redactor) rulesFor(ref spacesv1alpha1.ChangedObjectReference) []RedactionRule {
+	rules := []RedactionRule{}
+	for _, rule := range r.rules {
+		if rule.APIVersion != "" && rule.APIVersion != ref.APIVersion {
+			continue
+		}
+		if rule.Kind != "" && rule.Kind != ref.Kind {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// masks returns true if any of the rules masks the field at the given path.
+func masks(rules []RedactionRule, path []string) bool {
+	for _, rule := range rules {
+		if len(rule.segments) == 0 {
+			return true
+		}
+		for _, segs := range rule.segments {
+			if overlaps(path, segs) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps returns true if the changed field is at or below the masked path,
+// or if the changed field is a parent whose value contains the masked path. A
+// wildcard in the masked path matches any segment.
+func overlaps(path, masked []string) bool {
+	n := min(len(path), len(masked))
+	for i := 0; i < n; i++ {
+		if masked[i] != wildcardSegment && masked[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maskValue masks a field value, keeping nil values so that added and removed
+// fields can still be told apart.
+func maskValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactedValue
+}
+
+// SchemaRedactionRules returns the rules that mask every field marked as
+// sensitive in the schema of each served version of the CRD. A field is
+// sensitive if its OpenAPI format is "password".
+func SchemaRedactionRules(crd *extv1.CustomResourceDefinition) []RedactionRule {
+	rules := []RedactionRule{}
+	for _, v := range crd.Spec.Versions {
+		if !v.Served || v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		paths := sensitivePaths(*v.Schema.OpenAPIV3Schema, "")
+		if len(paths) == 0 {
+			continue
+		}
+		rules = append(rules, RedactionRule{
+			APIVersion: crd.Spec.Group + "/" + v.Name,
+			Kind:       crd.Spec.Names.Kind,
+			Paths:      paths,
+		})
+	}
+	return rules
+}
+
+// sensitivePaths recursively collects the paths of sensitive fields in the
+// schema.
+func sensitivePaths(s extv1.JSONSchemaProps, prefix string) []string {
+	if s.Format == sensitiveFormat {
+		return []string{prefix}
+	}
+
+	paths := []string{}
+	for name, prop := range s.Properties {
+		paths = append(paths, sensitivePaths(prop, joinFieldPath(prefix, name))...)
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		paths = append(paths, sensitivePaths(*s.Items.Schema, prefix+"[*]")...)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		paths = append(paths, sensitivePaths(*s.AdditionalProperties.Schema, prefix+"[*]")...)
+	}
+	return paths
+}
+
+// joinFieldPath appends a field name to a field path, quoting the name if it
+// cannot be written as a plain segment.
+func joinFieldPath(prefix, name string) string {
+	if strings.ContainsAny(name, ".[]'") {
+		return prefix + "['" + name + "']"
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+var _ diffWriter = &redactingWriter{}
+
+// redactingWriter implements diffWriter, masking sensitive values before
+// passing the diffs on to another writer.
+type redactingWriter struct {
+	next     diffWriter
+	redactor *Redactor
+}
+
+// Write redacts the resources and writes them to the wrapped writer.
+func (r *redactingWriter) Write(resources []ResourceDiff) error {
+	return r.next.Write(r.redactor.Redact(resources))
+}
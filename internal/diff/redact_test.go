@@ -0,0 +1,123 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	diffv3 "github.com/r3labs/diff/v3"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+func TestRedact(t *testing.T) {
+	ref := spacesv1alpha1.ChangedObjectReference{APIVersion: "example.org/v1", Kind: "Database", Name: "db"}
+
+	cases := map[string]struct {
+		rules []RedactionRule
+		input diffv3.Changelog
+		want  diffv3.Changelog
+	}{
+		"NoRules": {
+			input: diffv3.Changelog{{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "a", To: "b"}},
+			want:  diffv3.Changelog{{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "a", To: "b"}},
+		},
+		"ExactPath": {
+			rules: []RedactionRule{{Paths: []string{"spec.password"}}},
+			input: diffv3.Changelog{
+				{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "a", To: "b"},
+				{Type: diffv3.UPDATE, Path: []string{"spec", "size"}, From: 1, To: 2},
+			},
+			want: diffv3.Changelog{
+				{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "<redacted>", To: "<redacted>"},
+				{Type: diffv3.UPDATE, Path: []string{"spec", "size"}, From: 1, To: 2},
+			},
+		},
+		"ParentOfMaskedPath": {
+			rules: []RedactionRule{{Paths: []string{"spec.credentials.password"}}},
+			input: diffv3.Changelog{{Type: diffv3.CREATE, Path: []string{"spec", "credentials"}, To: map[string]any{"password": "a"}}},
+			want:  diffv3.Changelog{{Type: diffv3.CREATE, Path: []string{"spec", "credentials"}, To: "<redacted>"}},
+		},
+		"Wildcard": {
+			rules: []RedactionRule{{Paths: []string{"spec.users[*].password"}}},
+			input: diffv3.Changelog{
+				{Type: diffv3.UPDATE, Path: []string{"spec", "users", "0", "password"}, From: "a", To: "b"},
+				{Type: diffv3.UPDATE, Path: []string{"spec", "users", "0", "name"}, From: "a", To: "b"},
+			},
+			want: diffv3.Changelog{
+				{Type: diffv3.UPDATE, Path: []string{"spec", "users", "0", "password"}, From: "<redacted>", To: "<redacted>"},
+				{Type: diffv3.UPDATE, Path: []string{"spec", "users", "0", "name"}, From: "a", To: "b"},
+			},
+		},
+		"OtherKind": {
+			rules: []RedactionRule{{Kind: "Bucket"}},
+			input: diffv3.Changelog{{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "a", To: "b"}},
+			want:  diffv3.Changelog{{Type: diffv3.UPDATE, Path: []string{"spec", "password"}, From: "a", To: "b"}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRedactor(tc.rules...)
+			if err != nil {
+				t.Fatalf("NewRedactor(...): unexpected error: %v", err)
+			}
+			got := r.Redact([]ResourceDiff{{SimulationChange: spacesv1alpha1.SimulationChange{ObjectReference: ref}, Diff: tc.input}})
+			if diff := cmp.Diff(tc.want, got[0].Diff, cmpopts.IgnoreUnexported(diffv3.Change{})); diff != "" {
+				t.Errorf("Redact(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemaRedactionRules(t *testing.T) {
+	crd := &extv1.CustomResourceDefinition{
+		Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "Database"},
+			Versions: []extv1.CustomResourceDefinitionVersion{
+				{
+					Name:   "v1",
+					Served: true,
+					Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: &extv1.JSONSchemaProps{
+						Properties: map[string]extv1.JSONSchemaProps{
+							"spec": {Properties: map[string]extv1.JSONSchemaProps{
+								"password": {Type: "string", Format: "password"},
+								"size":     {Type: "integer"},
+								"users": {Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+									Properties: map[string]extv1.JSONSchemaProps{"token": {Type: "string", Format: "password"}},
+								}}},
+							}},
+						},
+					}},
+				},
+				{Name: "v0", Served: false},
+			},
+		},
+	}
+
+	want := []RedactionRule{{
+		APIVersion: "example.org/v1",
+		Kind:       "Database",
+		Paths:      []string{"spec.password", "spec.users[*].token"},
+	}}
+	got := SchemaRedactionRules(crd)
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(RedactionRule{}), cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("SchemaRedactionRules(...): -want, +got:\n%s", diff)
+	}
+}
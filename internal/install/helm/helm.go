@@ -19,6 +19,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -28,9 +29,12 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
@@ -38,6 +42,7 @@ import (
 
 	"github.com/upbound/up/internal/install"
 	"github.com/upbound/up/internal/kube"
+	"github.com/upbound/up/internal/signature"
 )
 
 const (
@@ -52,12 +57,14 @@ const (
 	errGetInstalledReleaseFmt            = "could not identify installed release for %s in namespace %s"
 	errGetInstalledReleaseOrAlternateFmt = "could not identify installed release for %s or %s in namespace %s"
 	errVerifyInstalledVersion            = "could not identify current version"
+	errVerifyInstalledValues             = "could not identify current values"
 	errVerifyChartNotInstalled           = "could not verify that chart is not already installed"
 	errChartAlreadyInstalledFmt          = "chart already installed with version %s"
 	errPullChart                         = "could not pull chart"
 	errGetLatestPulled                   = "could not identify chart pulled as latest"
 	errCorruptTempDirFmt                 = "corrupt chart tmp directory, consider removing cache (%s)"
 	errMoveLatest                        = "could not move latest pulled chart to cache"
+	errRenderChart                       = "could not render chart"
 
 	errUpgradeFromAlternateVersionFmt = "cannot upgrade %s to %s with version mismatch"
 	errFailedUpgradeFailedRollback    = "failed upgrade resulted in a failed rollback"
@@ -91,6 +98,25 @@ type helmInstaller interface {
 	Run(*chart.Chart, map[string]any) (*release.Release, error)
 }
 
+// renderer renders a chart without contacting the cluster, as helm template
+// does. The version of the API server is used for the capabilities of the
+// render if it can be discovered, which does not require any RBAC.
+type renderer struct {
+	*action.Install
+	discovery discovery.ServerVersionInterface
+}
+
+func (r *renderer) Run(c *chart.Chart, vals map[string]any) (*release.Release, error) {
+	if r.discovery != nil {
+		if v, err := r.discovery.ServerVersion(); err == nil {
+			if kv, err := chartutil.ParseKubeVersion(v.GitVersion); err == nil {
+				r.KubeVersion = kv
+			}
+		}
+	}
+	return r.Install.Run(c, vals)
+}
+
 type helmUpgrader interface {
 	Run(string, *chart.Chart, map[string]any) (*release.Release, error)
 }
@@ -130,6 +156,8 @@ type Installer struct {
 	tempDir         TempDirFn
 	log             logging.Logger
 	oci             bool
+	verifier        *signature.Verifier
+	postRenderer    postrender.PostRenderer
 
 	// Auth
 	username string
@@ -139,6 +167,7 @@ type Installer struct {
 	pullClient      helmPuller
 	getClient       helmGetter
 	installClient   helmInstaller
+	renderClient    helmInstaller
 	upgradeClient   helmUpgrader
 	rollbackClient  helmRollbacker
 	uninstallClient helmUninstaller
@@ -179,6 +208,29 @@ func WithBasicAuth(username, password string) InstallerModifierFn {
 	}
 }
 
+// WithVerifier sets the verifier the signature of the chart is verified with
+// when it is pulled.
+func WithVerifier(v *signature.Verifier) InstallerModifierFn {
+	return func(h *Installer) {
+		h.verifier = v
+	}
+}
+
+// WithPostRenderer sets a post renderer that modifies the rendered manifests of
+// the chart before they are installed.
+func WithPostRenderer(pr postrender.PostRenderer) InstallerModifierFn {
+	return func(h *Installer) {
+		h.postRenderer = pr
+	}
+}
+
+// WithRepoURL overrides the repository the chart is pulled from.
+func WithRepoURL(u *url.URL) InstallerModifierFn {
+	return func(h *Installer) {
+		h.repoURL = u
+	}
+}
+
 // IsOCI indicates that the chart is an OCI image.
 func IsOCI() InstallerModifierFn {
 	return func(h *Installer) {
@@ -281,7 +333,7 @@ func NewManager(config *rest.Config, chartName string, repoURL *url.URL, modifie
 		h.pullClient = newRegistryPuller(withRemoteOpts(remote.WithAuth(&authn.Basic{
 			Username: h.username,
 			Password: h.password,
-		})), withRepoURL(h.repoURL))
+		})), withRepoURL(h.repoURL), withVerifier(h.verifier))
 	} else {
 		// TODO(hasheddan): we currently use our own OCI client instead of the
 		// upstream Helm support.
@@ -308,14 +360,33 @@ func NewManager(config *rest.Config, chartName string, repoURL *url.URL, modifie
 	ic.Wait = h.wait
 	ic.Timeout = waitTimeout
 	ic.DisableHooks = h.noHooks
+	ic.PostRenderer = h.postRenderer
 	h.installClient = ic
 
+	// Render Client
+	// NOTE: a client-only install replaces the clients of its configuration,
+	// so it must not share the configuration of the other clients.
+	rc := action.NewInstall(&action.Configuration{Log: actionConfig.Log})
+	rc.Namespace = h.namespace
+	rc.ReleaseName = h.chartName
+	rc.DryRun = true
+	rc.ClientOnly = true
+	rc.IncludeCRDs = true
+	rc.DisableHooks = h.noHooks
+	rc.PostRenderer = h.postRenderer
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	h.renderClient = &renderer{Install: rc, discovery: dc}
+
 	// Upgrade Client
 	uc := action.NewUpgrade(actionConfig)
 	uc.Namespace = h.namespace
 	uc.Wait = h.wait
 	uc.Timeout = waitTimeout
 	uc.DisableHooks = h.noHooks
+	uc.PostRenderer = h.postRenderer
 	h.upgradeClient = uc
 
 	// Uninstall Client
@@ -336,27 +407,48 @@ func NewManager(config *rest.Config, chartName string, repoURL *url.URL, modifie
 
 // GetCurrentVersion gets the current UXP version in the cluster.
 func (h *Installer) GetCurrentVersion() (string, error) {
-	var release *release.Release
-	var err error
-	release, err = h.getClient.Run(h.chartName)
-	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+	release, err := h.getCurrentRelease()
+	if err != nil {
 		return "", err
 	}
+	if release == nil || release.Chart == nil || release.Chart.Metadata == nil {
+		return "", errors.New(errVerifyInstalledVersion)
+	}
+	return release.Chart.Metadata.Version, nil
+}
+
+// GetCurrentValues gets the values the current release in the cluster was
+// installed or upgraded with. Chart defaults are not included.
+func (h *Installer) GetCurrentValues() (map[string]any, error) {
+	release, err := h.getCurrentRelease()
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, errors.New(errVerifyInstalledValues)
+	}
+	return release.Config, nil
+}
+
+// getCurrentRelease gets the current release in the cluster, falling back to
+// the alternate chart's release if there is one.
+func (h *Installer) getCurrentRelease() (*release.Release, error) {
+	release, err := h.getClient.Run(h.chartName)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	}
 	if errors.Is(err, driver.ErrReleaseNotFound) {
 		if h.alternateChart != "" {
 			// TODO(hasheddan): add logging indicating fallback to crossplane.
 			if release, err = h.getClient.Run(h.alternateChart); err != nil {
-				return "", errors.Wrapf(err, errGetInstalledReleaseOrAlternateFmt, h.chartName, h.alternateChart, h.namespace)
+				return nil, errors.Wrapf(err, errGetInstalledReleaseOrAlternateFmt, h.chartName, h.alternateChart, h.namespace)
 			}
 			h.releaseName = h.alternateChart
 		} else {
-			return "", errors.Wrapf(err, errGetInstalledReleaseFmt, h.chartName, h.namespace)
+			return nil, errors.Wrapf(err, errGetInstalledReleaseFmt, h.chartName, h.namespace)
 		}
 	}
-	if release == nil || release.Chart == nil || release.Chart.Metadata == nil {
-		return "", errors.New(errVerifyInstalledVersion)
-	}
-	return release.Chart.Metadata.Version, nil
+	return release, nil
 }
 
 // Install installs in the cluster.
@@ -370,17 +462,7 @@ func (h *Installer) Install(version string, parameters map[string]any, opts ...i
 		return errors.Wrap(err, errVerifyChartNotInstalled)
 	}
 
-	var helmChart *chart.Chart
-	if h.chartFile == nil {
-		// install desired version from repo
-		helmChart, err = h.pullAndLoad(version)
-	} else {
-		// install specified chart from file or folder
-		// We assume a uxp or a crossplane chart is referred.
-		// For dev purposes, no need to assert this.
-		// (see above release check)
-		helmChart, err = h.load(h.chartFile.Name())
-	}
+	helmChart, err := h.loadChart(version)
 	if err != nil {
 		return err
 	}
@@ -395,6 +477,43 @@ func (h *Installer) Install(version string, parameters map[string]any, opts ...i
 	return err
 }
 
+// Render renders the manifests that an install would create, including CRDs
+// and hooks, without contacting the cluster.
+func (h *Installer) Render(version string, parameters map[string]any, opts ...install.InstallOption) (string, error) {
+	helmChart, err := h.loadChart(version)
+	if err != nil {
+		return "", err
+	}
+
+	for _, o := range opts {
+		if err := o(helmChart); err != nil {
+			return "", err
+		}
+	}
+
+	rel, err := h.renderClient.Run(helmChart, parameters)
+	if err != nil {
+		return "", errors.Wrap(err, errRenderChart)
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, strings.TrimSpace(rel.Manifest))
+	for _, m := range rel.Hooks {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", m.Path, m.Manifest)
+	}
+	return b.String(), nil
+}
+
+// loadChart loads the desired version of the chart from the repo, or the chart
+// from file or folder if one was specified.
+func (h *Installer) loadChart(version string) (*chart.Chart, error) {
+	if h.chartFile == nil {
+		return h.pullAndLoad(version)
+	}
+	// We assume a uxp or a crossplane chart is referred. For dev purposes, no
+	// need to assert this.
+	return h.load(h.chartFile.Name())
+}
+
 // Upgrade upgrades an existing installation to a new version.
 func (h *Installer) Upgrade(version string, parameters map[string]any, opts ...install.UpgradeOption) error { //nolint:gocyclo // looks still sane
 	// check if version exists
@@ -505,6 +624,13 @@ func (h *Installer) pullChart(version string) error {
 	if version == "" {
 		version = allVersions
 	}
+	// NOTE: charts pulled from OCI registries are verified by the registry
+	// puller. Helm repositories do not have signatures we can verify.
+	if !h.oci {
+		if err := h.verifier.Unverifiable(fmt.Sprintf("chart %s from %s", h.chartName, h.repoURL)); err != nil {
+			return err
+		}
+	}
 	h.pullClient.SetVersion(version)
 	_, err := h.pullClient.Run(h.chartName)
 	return err
@@ -190,6 +190,70 @@ func TestGetCurrentVersion(t *testing.T) {
 	}
 }
 
+func TestGetCurrentValues(t *testing.T) {
+	errBoom := errors.New("boom")
+	chartName := "primary-chart"
+	cases := map[string]struct {
+		reason    string
+		installer *Installer
+		values    map[string]any
+		err       error
+	}{
+		"ErrorGetRelease": {
+			reason: "If unable to get release an error should be returned.",
+			installer: &Installer{
+				getClient: &mockGetClient{
+					runFn: func(string) (*release.Release, error) {
+						return nil, errBoom
+					},
+				},
+			},
+			err: errBoom,
+		},
+		"ErrorReleaseNotFound": {
+			reason: "If the release is not found an error should be returned.",
+			installer: &Installer{
+				namespace: "test",
+				chartName: chartName,
+				getClient: &mockGetClient{
+					runFn: func(string) (*release.Release, error) {
+						return nil, driver.ErrReleaseNotFound
+					},
+				},
+			},
+			err: errors.Wrapf(driver.ErrReleaseNotFound, errGetInstalledReleaseFmt, chartName, "test"),
+		},
+		"Successful": {
+			reason: "If successful the values of the release should be returned.",
+			installer: &Installer{
+				getClient: &mockGetClient{
+					runFn: func(string) (*release.Release, error) {
+						return &release.Release{
+							Config: map[string]any{
+								"billing": map[string]any{"enabled": true},
+							},
+						}, nil
+					},
+				},
+			},
+			values: map[string]any{
+				"billing": map[string]any{"enabled": true},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := tc.installer.GetCurrentValues()
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nGetCurrentValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.values, v); diff != "" {
+				t.Errorf("\n%s\nGetCurrentValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestInstall(t *testing.T) {
 	errBoom := errors.New("boom")
 	chartName := "primary-chart"
@@ -314,6 +378,92 @@ func TestInstall(t *testing.T) {
 	}
 }
 
+func TestRender(t *testing.T) {
+	errBoom := errors.New("boom")
+	cases := map[string]struct {
+		reason    string
+		installer *Installer
+		version   string
+		manifests string
+		err       error
+	}{
+		"ErrorPullNewVersion": {
+			reason: "If unable to pull specified version an error should be returned.",
+			installer: &Installer{
+				pullClient: &mockPullClient{
+					runFn: func(string) (string, error) {
+						return "", errBoom
+					},
+				},
+			},
+			version: "real-version",
+			err:     errors.Wrap(errBoom, errPullChart),
+		},
+		"ErrorRender": {
+			reason: "If unable to render the chart an error should be returned.",
+			installer: &Installer{
+				pullClient: &mockPullClient{
+					runFn: func(string) (string, error) {
+						return "", nil
+					},
+				},
+				renderClient: &mockInstallClient{
+					runFn: func(*chart.Chart, map[string]any) (*release.Release, error) {
+						return nil, errBoom
+					},
+				},
+				cacheDir:  "/",
+				chartName: "test",
+				load: func(string) (*chart.Chart, error) {
+					return nil, nil
+				},
+			},
+			version: "real-version",
+			err:     errors.Wrap(errBoom, errRenderChart),
+		},
+		"Successful": {
+			reason: "The rendered manifests should be followed by the rendered hooks.",
+			installer: &Installer{
+				pullClient: &mockPullClient{
+					runFn: func(string) (string, error) {
+						return "", nil
+					},
+				},
+				renderClient: &mockInstallClient{
+					runFn: func(*chart.Chart, map[string]any) (*release.Release, error) {
+						return &release.Release{
+							Manifest: "---\n# Source: test/templates/cm.yaml\nkind: ConfigMap\n",
+							Hooks: []*release.Hook{{
+								Path:     "test/templates/job.yaml",
+								Manifest: "kind: Job",
+							}},
+						}, nil
+					},
+				},
+				cacheDir:  "/",
+				chartName: "test",
+				load: func(string) (*chart.Chart, error) {
+					return nil, nil
+				},
+			},
+			version:   "real-version",
+			manifests: "---\n# Source: test/templates/cm.yaml\nkind: ConfigMap\n---\n# Source: test/templates/job.yaml\nkind: Job\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.installer.fs = afero.NewMemMapFs()
+			m, err := tc.installer.Render(tc.version, nil)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.manifests, m); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestUpgrade(t *testing.T) {
 	errBoom := errors.New("boom")
 	chartName := "primary-chart"
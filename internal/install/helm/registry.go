@@ -15,6 +15,7 @@
 package helm
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -24,6 +25,8 @@ import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/spf13/afero"
+
+	"github.com/upbound/up/internal/signature"
 )
 
 const (
@@ -43,6 +46,7 @@ const (
 	errNotSingleLayer    = "OCI image does not have a single layer"
 	errLayerMediaTypeFmt = "OCI image layer has media type %s and %s is required"
 	errReadCompressed    = "failed to read compressed chart contents"
+	errGetImageDigest    = "failed to get OCI image digest"
 )
 
 type fetchFn func(ref name.Reference, options ...remote.Option) (v1.Image, error)
@@ -58,6 +62,7 @@ type registryPuller struct {
 	version    string
 	repoURL    *url.URL
 	remoteOpts []remote.Option
+	verifier   *signature.Verifier
 }
 
 type registryPullerOpt func(*registryPuller)
@@ -74,6 +79,12 @@ func withRepoURL(u *url.URL) registryPullerOpt {
 	}
 }
 
+func withVerifier(v *signature.Verifier) registryPullerOpt {
+	return func(r *registryPuller) {
+		r.verifier = v
+	}
+}
+
 func newRegistryPuller(opts ...registryPullerOpt) *registryPuller {
 	r := &registryPuller{
 		fs:                afero.NewOsFs(),
@@ -95,6 +106,13 @@ func (p *registryPuller) Run(chartName string) (string, error) {
 	if err != nil {
 		return "", errors.Wrap(err, errGetImage)
 	}
+	d, err := img.Digest()
+	if err != nil {
+		return "", errors.Wrap(err, errGetImageDigest)
+	}
+	if err := p.verifier.Verify(context.Background(), ref, d); err != nil {
+		return "", err
+	}
 	ls, err := img.Layers()
 	if err != nil {
 		return "", errors.Wrap(err, errGetImageLayers)
@@ -26,7 +26,9 @@ type UpgradeOption func(oldVersion string, ch *chart.Chart) error
 // TODO(hasheddan): support custom error types, such as AlreadyExists.
 type Manager interface {
 	GetCurrentVersion() (string, error)
+	GetCurrentValues() (map[string]any, error)
 	Install(version string, parameters map[string]any, opts ...InstallOption) error
+	Render(version string, parameters map[string]any, opts ...InstallOption) (string, error)
 	Upgrade(version string, parameters map[string]any, opts ...UpgradeOption) error
 	Uninstall() error
 }
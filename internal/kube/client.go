@@ -44,6 +44,15 @@ func GetKubeConfig(path string) (*rest.Config, error) {
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
 }
 
+// GetKubeConfigForContext constructs a Kubernetes REST config for the given
+// context of the specified kubeconfig. An empty context selects the current
+// context.
+func GetKubeConfigForContext(path, kubeContext string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = path
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+}
+
 // BuildCloudControlPlaneKubeconfig builds a kubeconfig entry for a control plane.
 func BuildCloudControlPlaneKubeconfig(proxy *url.URL, id string, token string, includePrefix bool) *api.Config { //nolint:interfacer
 	conf := api.NewConfig()
@@ -0,0 +1,92 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// NewChartImage returns a Helm chart as an OCI artifact, as pushed by helm
+// push. The config is the JSON encoded metadata of the chart and the archive is
+// the packaged chart.
+func NewChartImage(config, archive []byte) (v1.Image, error) {
+	return partial.CompressedToImage(&chartImage{
+		config: config,
+		layer:  static.NewLayer(archive, registry.ChartLayerMediaType),
+	})
+}
+
+// IsChartConfig returns true if the config of an OCI artifact is the metadata
+// of a Helm chart rather than the config of an image.
+func IsChartConfig(config []byte) bool {
+	md := &chart.Metadata{}
+	if err := json.Unmarshal(config, md); err != nil {
+		return false
+	}
+	return md.APIVersion != "" && md.Name != "" && md.Version != ""
+}
+
+type chartImage struct {
+	config []byte
+	layer  v1.Layer
+}
+
+func (c *chartImage) RawConfigFile() ([]byte, error) {
+	return c.config, nil
+}
+
+func (c *chartImage) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+
+func (c *chartImage) RawManifest() ([]byte, error) {
+	cfgLayer, err := partial.ConfigLayer(c)
+	if err != nil {
+		return nil, err
+	}
+	cfgDesc, err := partial.Descriptor(cfgLayer)
+	if err != nil {
+		return nil, err
+	}
+	cfgDesc.MediaType = registry.ConfigMediaType
+
+	layerDesc, err := partial.Descriptor(c.layer)
+	if err != nil {
+		return nil, err
+	}
+	layerDesc.MediaType = registry.ChartLayerMediaType
+
+	return json.Marshal(v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		Config:        *cfgDesc,
+		Layers:        []v1.Descriptor{*layerDesc},
+	})
+}
+
+func (c *chartImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	if d, err := c.layer.Digest(); err == nil && d == h {
+		return c.layer, nil
+	}
+	return nil, errors.Errorf("layer %s not found", h)
+}
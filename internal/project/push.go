@@ -31,6 +31,7 @@ import (
 	"github.com/upbound/up-sdk-go/service/repositories"
 	"github.com/upbound/up/internal/async"
 	"github.com/upbound/up/internal/credhelper"
+	"github.com/upbound/up/internal/signature"
 	"github.com/upbound/up/internal/upbound"
 	"github.com/upbound/up/internal/xpkg"
 	"github.com/upbound/up/pkg/apis/project/v1alpha1"
@@ -67,6 +68,13 @@ func PushWithUpboundContext(upCtx *upbound.Context) PusherOption {
 	}
 }
 
+// PushWithSigner sets the signer the pushed packages are signed with.
+func PushWithSigner(s *signature.Signer) PusherOption {
+	return func(p *realPusher) {
+		p.signer = s
+	}
+}
+
 // PushOption configures a build.
 type PushOption func(o *pushOptions)
 
@@ -104,6 +112,7 @@ type realPusher struct {
 	upCtx          *upbound.Context
 	transport      http.RoundTripper
 	maxConcurrency uint
+	signer         *signature.Signer
 }
 
 // Push implements the Pusher interface.
@@ -247,11 +256,19 @@ func (p *realPusher) pushIndex(ctx context.Context, tag name.Tag, imgs ...v1.Ima
 	// Tag the function the same as the configuration. The configuration depends
 	// on it by digest, so this isn't necessary for things to work correctly,
 	// but it makes the Marketplace experience more intuitive for the user.
-	return remote.WriteIndex(tag, idx,
+	err = remote.WriteIndex(tag, idx,
 		remote.WithAuthFromKeychain(kc),
 		remote.WithContext(ctx),
 		remote.WithTransport(p.transport),
 	)
+	if err != nil {
+		return err
+	}
+	dgst, err := idx.Digest()
+	if err != nil {
+		return err
+	}
+	return p.signer.Sign(ctx, tag, dgst)
 }
 
 func (p *realPusher) pushImage(ctx context.Context, ref name.Reference, img v1.Image) error {
@@ -270,11 +287,19 @@ func (p *realPusher) pushImage(ctx context.Context, ref name.Reference, img v1.I
 		return err
 	}
 
-	return remote.Write(ref, img,
+	err = remote.Write(ref, img,
 		remote.WithAuthFromKeychain(kc),
 		remote.WithContext(ctx),
 		remote.WithTransport(p.transport),
 	)
+	if err != nil {
+		return err
+	}
+	dgst, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	return p.signer.Sign(ctx, ref, dgst)
 }
 
 func isUpboundRepository(upCtx *upbound.Context, tag name.Repository) bool {
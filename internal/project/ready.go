@@ -0,0 +1,120 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"time"
+
+	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	xpkgv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	xpkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WaitForPackagesReady waits until the Configuration with the given tag, and
+// all of its dependencies, are installed and healthy. Revisions of other tags
+// from the same repository, such as the one being upgraded from, are not
+// considered.
+func WaitForPackagesReady(ctx context.Context, cl client.Client, tag name.Tag) error {
+	nn := types.NamespacedName{
+		Name: "lock",
+	}
+	var lock xpkgv1beta1.Lock
+	for {
+		time.Sleep(500 * time.Millisecond)
+		err := cl.Get(ctx, nn, &lock)
+		if err != nil {
+			return err
+		}
+
+		cfgPkg, cfgFound := lookupLockPackage(lock.Packages, tag.Repository.String(), tag.TagStr())
+		if !cfgFound {
+			// Configuration with the new tag not in lock yet.
+			continue
+		}
+		healthy, err := packageIsHealthy(ctx, cl, cfgPkg)
+		if err != nil {
+			return err
+		}
+		if !healthy {
+			// Configuration is not healthy yet.
+			continue
+		}
+
+		healthy, err = allDepsHealthy(ctx, cl, lock, cfgPkg)
+		if err != nil {
+			return err
+		}
+		if healthy {
+			break
+		}
+	}
+	return nil
+}
+
+func allDepsHealthy(ctx context.Context, cl client.Client, lock xpkgv1beta1.Lock, pkg xpkgv1beta1.LockPackage) (bool, error) {
+	for _, dep := range pkg.Dependencies {
+		depPkg, found := lookupLockPackage(lock.Packages, dep.Package, dep.Constraints)
+		if !found {
+			// Dep is not in lock yet - no need to look at the rest.
+			break
+		}
+		healthy, err := packageIsHealthy(ctx, cl, depPkg)
+		if err != nil {
+			return false, err
+		}
+		if !healthy {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func lookupLockPackage(pkgs []xpkgv1beta1.LockPackage, source, version string) (xpkgv1beta1.LockPackage, bool) {
+	for _, pkg := range pkgs {
+		if pkg.Source == source {
+			if version == "" || pkg.Version == version {
+				return pkg, true
+			}
+		}
+	}
+	return xpkgv1beta1.LockPackage{}, false
+}
+
+func packageIsHealthy(ctx context.Context, cl client.Client, lpkg xpkgv1beta1.LockPackage) (bool, error) {
+	var pkg xpkgv1.PackageRevision
+	switch lpkg.Type {
+	case xpkgv1beta1.ConfigurationPackageType:
+		pkg = &xpkgv1.ConfigurationRevision{}
+
+	case xpkgv1beta1.ProviderPackageType:
+		pkg = &xpkgv1.ProviderRevision{}
+
+	case xpkgv1beta1.FunctionPackageType:
+		pkg = &xpkgv1.FunctionRevision{}
+	}
+
+	err := cl.Get(ctx, types.NamespacedName{Name: lpkg.Name}, pkg)
+	if err != nil {
+		return false, err
+	}
+
+	return resource.IsConditionTrue(pkg.GetCondition(commonv1.TypeHealthy)), nil
+}
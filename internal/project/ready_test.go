@@ -0,0 +1,112 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	xpkgv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	xpkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func configurationRevision(name string, healthy bool) *xpkgv1.ConfigurationRevision {
+	rev := &xpkgv1.ConfigurationRevision{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	status := corev1.ConditionFalse
+	if healthy {
+		status = corev1.ConditionTrue
+	}
+	rev.SetConditions(commonv1.Condition{Type: commonv1.TypeHealthy, Status: status, Reason: "Test"})
+	return rev
+}
+
+func TestWaitForPackagesReady(t *testing.T) {
+	errGiveUp := errors.New("gave up waiting")
+	tag, err := name.NewTag("xpkg.upbound.io/acme/getting-started:v0.2.0")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		packages  []xpkgv1beta1.LockPackage
+		revisions []client.Object
+		expectErr error
+	}{
+		{
+			name: "NewTagHealthy",
+			packages: []xpkgv1beta1.LockPackage{
+				{Name: "old", Type: xpkgv1beta1.ConfigurationPackageType, Source: "xpkg.upbound.io/acme/getting-started", Version: "v0.1.0"},
+				{Name: "new", Type: xpkgv1beta1.ConfigurationPackageType, Source: "xpkg.upbound.io/acme/getting-started", Version: "v0.2.0"},
+			},
+			revisions: []client.Object{configurationRevision("old", false), configurationRevision("new", true)},
+		},
+		{
+			name: "OnlyOldTagHealthy",
+			packages: []xpkgv1beta1.LockPackage{
+				{Name: "old", Type: xpkgv1beta1.ConfigurationPackageType, Source: "xpkg.upbound.io/acme/getting-started", Version: "v0.1.0"},
+			},
+			revisions: []client.Object{configurationRevision("old", true)},
+			expectErr: errGiveUp,
+		},
+		{
+			name: "NewTagUnhealthy",
+			packages: []xpkgv1beta1.LockPackage{
+				{Name: "old", Type: xpkgv1beta1.ConfigurationPackageType, Source: "xpkg.upbound.io/acme/getting-started", Version: "v0.1.0"},
+				{Name: "new", Type: xpkgv1beta1.ConfigurationPackageType, Source: "xpkg.upbound.io/acme/getting-started", Version: "v0.2.0"},
+			},
+			revisions: []client.Object{configurationRevision("old", true), configurationRevision("new", false)},
+			expectErr: errGiveUp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, xpkgv1.AddToScheme(scheme))
+			require.NoError(t, xpkgv1beta1.AddToScheme(scheme))
+
+			lock := &xpkgv1beta1.Lock{ObjectMeta: metav1.ObjectMeta{Name: "lock"}, Packages: tt.packages}
+			gets := 0
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(tt.revisions, lock)...).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						if _, ok := obj.(*xpkgv1beta1.Lock); ok {
+							gets++
+							// Give up instead of waiting forever.
+							if gets > 2 {
+								return errGiveUp
+							}
+						}
+						return cl.Get(ctx, key, obj, opts...)
+					},
+				}).
+				Build()
+
+			err := WaitForPackagesReady(context.Background(), cl, tag)
+			require.ErrorIs(t, err, tt.expectErr)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"crypto"
+	"os"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// passwordEnv is the environment variable the password of encrypted private
+// keys is read from, as with cosign.
+const passwordEnv = "COSIGN_PASSWORD"
+
+// VerifyFlags are the flags of commands that verify the artifacts they pull.
+type VerifyFlags struct {
+	Verify             Policy   `default:"none" enum:"none,warn,enforce" help:"Signature verification policy of pulled OCI artifacts. With warn, unverified artifacts are reported. With enforce, they are refused, as are charts pulled from Helm repositories, which have no signatures. The charts of Spaces prerequisites are exempt from verification."`
+	VerifyKeys         []string `name:"verify-key" type:"existingfile" placeholder:"PATH" help:"Public keys signatures of pulled artifacts may be made by, e.g. a cosign.pub file."`
+	VerifyTrustRoots   []string `name:"verify-trust-root" type:"existingfile" placeholder:"PATH" help:"PEM certificates that the certificates of keyless signatures must chain up to, e.g. a Fulcio root. Requires --verify-identity, --verify-oidc-issuer and --verify-tlog-key."`
+	VerifyIdentity     string   `name:"verify-identity" placeholder:"IDENTITY" help:"Identity the certificates of keyless signatures must be issued to, e.g. an email address or a workflow URI."`
+	VerifyOIDCIssuer   string   `name:"verify-oidc-issuer" placeholder:"URL" help:"OIDC issuer that must have authenticated the identity of keyless signatures, e.g. https://token.actions.githubusercontent.com."`
+	VerifyTlogKeys     []string `name:"verify-tlog-key" type:"existingfile" placeholder:"PATH" help:"Public keys of the transparency logs keyless signatures must be recorded in, e.g. the Rekor public key. Certificates are verified at the time signatures were recorded."`
+	VerifyAttestations []string `name:"verify-attestation" placeholder:"PREDICATE-TYPE" help:"Predicate types of attestations that pulled artifacts must also have, e.g. https://slsa.dev/provenance/v1."`
+}
+
+// Verifier returns the verifier configured by the flags, or nil if artifacts
+// are not verified.
+func (f VerifyFlags) Verifier(opts ...remote.Option) (*Verifier, error) {
+	if f.Verify == "" || f.Verify == PolicyNone {
+		return nil, nil
+	}
+	if len(f.VerifyKeys) == 0 && len(f.VerifyTrustRoots) == 0 {
+		return nil, errors.New(errNoVerificationMethod)
+	}
+	keys := make([]crypto.PublicKey, 0, len(f.VerifyKeys))
+	for _, p := range f.VerifyKeys {
+		k, err := LoadPublicKey(p)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	vopts := []VerifierOption{
+		WithPolicy(f.Verify),
+		WithPublicKeys(keys...),
+		WithAttestations(f.VerifyAttestations...),
+		WithRemoteOptions(opts...),
+	}
+	if len(f.VerifyTrustRoots) > 0 {
+		if f.VerifyIdentity == "" || f.VerifyOIDCIssuer == "" || len(f.VerifyTlogKeys) == 0 {
+			return nil, errors.New(errKeylessFlags)
+		}
+		roots, err := LoadTrustRoots(f.VerifyTrustRoots...)
+		if err != nil {
+			return nil, err
+		}
+		tlogKeys := make([]crypto.PublicKey, 0, len(f.VerifyTlogKeys))
+		for _, p := range f.VerifyTlogKeys {
+			k, err := LoadPublicKey(p)
+			if err != nil {
+				return nil, err
+			}
+			tlogKeys = append(tlogKeys, k)
+		}
+		vopts = append(vopts,
+			WithTrustRoots(roots),
+			WithIdentity(f.VerifyIdentity, f.VerifyOIDCIssuer),
+			WithTransparencyLogKeys(tlogKeys...),
+		)
+	}
+	return NewVerifier(vopts...), nil
+}
+
+// SignFlags are the flags of commands that sign the artifacts they push.
+type SignFlags struct {
+	SignKey string `type:"existingfile" placeholder:"PATH" help:"Private key to sign pushed artifacts with, e.g. a cosign.key file. The password of encrypted keys is read from the COSIGN_PASSWORD environment variable."`
+}
+
+// Signer returns the signer configured by the flags, or nil if artifacts are
+// not signed.
+func (f SignFlags) Signer(opts ...remote.Option) (*Signer, error) {
+	if f.SignKey == "" {
+		return nil, nil
+	}
+	key, err := LoadPrivateKey(f.SignKey, []byte(os.Getenv(passwordEnv)))
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(key, opts...), nil
+}
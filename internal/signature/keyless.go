@@ -0,0 +1,195 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// BundleAnnotation is the layer annotation holding the transparency log bundle
+// of a keyless signature, which proves when the signature was made.
+const BundleAnnotation = "dev.sigstore.cosign/bundle"
+
+const (
+	kindHashedRekord = "hashedrekord"
+	algorithmSHA256  = "sha256"
+)
+
+const (
+	errKeylessConstraints = "keyless signatures require an identity, an OIDC issuer and a transparency log key"
+	errKeylessAttestation = "keyless attestations are not supported, attestations must be signed with one of the public keys"
+	errNoBundle           = "keyless signature has no transparency log bundle"
+	errInvalidBundle      = "invalid transparency log bundle"
+	errBundleTimestamp    = "transparency log bundle is not signed by any of the transparency log keys"
+	errBundleMismatch     = "transparency log entry is for another signature"
+	errFmtIdentity        = "certificate is issued to %v, expected %s"
+	errFmtIssuer          = "certificate identity is authenticated by %q, expected %s"
+)
+
+var (
+	// oidIssuer and oidIssuerV2 are the extensions of Fulcio certificates that
+	// hold the OIDC issuer that authenticated their identity, as a raw string
+	// and as a DER encoded UTF8String respectively.
+	oidIssuer   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// bundle is the transparency log bundle of a keyless signature, i.e. its entry
+// in the log with the signed entry timestamp of the log.
+type bundle struct {
+	SignedEntryTimestamp []byte        `json:"SignedEntryTimestamp"`
+	Payload              bundlePayload `json:"Payload"`
+}
+
+// bundlePayload is the entry of a bundle. Its fields are in the order of its
+// canonical JSON encoding, which is what the signed entry timestamp signs.
+type bundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the subset of the body of a hashedrekord entry needed to
+// verify that it is for a signature. Byte slices are base64 encoded in JSON.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyKeyless verifies that sig is a signature of msg by the certificate of
+// the layer, that the signature was recorded in the transparency log while the
+// certificate was valid, and that the certificate chains up to a trust root and
+// is issued to the expected identity.
+func (v *Verifier) verifyKeyless(l layer, msg, sig []byte) error {
+	if v.identity == "" || v.issuer == "" || len(v.tlogKeys) == 0 {
+		return errors.New(errKeylessConstraints)
+	}
+	certs, err := parseCertificates([]byte(l.annotations[CertificateAnnotation]))
+	if err != nil || len(certs) == 0 {
+		return errors.New(errInvalidCertificate)
+	}
+	c := certs[0]
+	if err := verify(c.PublicKey, msg, sig); err != nil {
+		return err
+	}
+	signed, err := v.verifyBundle(l, c, msg, sig)
+	if err != nil {
+		return err
+	}
+	if err := v.verifyCertificate(c, []byte(l.annotations[ChainAnnotation]), signed); err != nil {
+		return err
+	}
+	return v.verifyIdentity(c)
+}
+
+// verifyBundle verifies that the transparency log bundle of a layer is signed
+// by one of the transparency log keys and records the signature of msg by the
+// certificate. It returns the time the signature was recorded.
+func (v *Verifier) verifyBundle(l layer, c *x509.Certificate, msg, sig []byte) (time.Time, error) {
+	raw := l.annotations[BundleAnnotation]
+	if raw == "" {
+		return time.Time{}, errors.New(errNoBundle)
+	}
+	b := &bundle{}
+	if err := json.Unmarshal([]byte(raw), b); err != nil {
+		return time.Time{}, errors.Wrap(err, errInvalidBundle)
+	}
+	canonical, err := json.Marshal(b.Payload)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errInvalidBundle)
+	}
+	if !slices.ContainsFunc(v.tlogKeys, func(k crypto.PublicKey) bool { return verify(k, canonical, b.SignedEntryTimestamp) == nil }) {
+		return time.Time{}, errors.New(errBundleTimestamp)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(b.Payload.Body)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errInvalidBundle)
+	}
+	e := &hashedRekord{}
+	if err := json.Unmarshal(body, e); err != nil {
+		return time.Time{}, errors.Wrap(err, errInvalidBundle)
+	}
+	h := sha256.Sum256(msg)
+	if e.Kind != kindHashedRekord ||
+		e.Spec.Data.Hash.Algorithm != algorithmSHA256 ||
+		e.Spec.Data.Hash.Value != hex.EncodeToString(h[:]) ||
+		!bytes.Equal(e.Spec.Signature.Content, sig) {
+		return time.Time{}, errors.New(errBundleMismatch)
+	}
+	certs, err := parseCertificates(e.Spec.Signature.PublicKey.Content)
+	if err != nil || len(certs) == 0 || !certs[0].Equal(c) {
+		return time.Time{}, errors.New(errBundleMismatch)
+	}
+	return time.Unix(b.Payload.IntegratedTime, 0), nil
+}
+
+// verifyIdentity verifies that a certificate is issued to the identity of the
+// verifier, as authenticated by its OIDC issuer.
+func (v *Verifier) verifyIdentity(c *x509.Certificate) error {
+	ids := slices.Clone(c.EmailAddresses)
+	for _, u := range c.URIs {
+		ids = append(ids, u.String())
+	}
+	if !slices.Contains(ids, v.identity) {
+		return errors.Errorf(errFmtIdentity, ids, v.identity)
+	}
+	if iss := certificateIssuer(c); iss != v.issuer {
+		return errors.Errorf(errFmtIssuer, iss, v.issuer)
+	}
+	return nil
+}
+
+// certificateIssuer returns the OIDC issuer of a Fulcio certificate, or an
+// empty string if it has none.
+func certificateIssuer(c *x509.Certificate) string {
+	for _, ext := range c.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var iss string
+			if _, err := asn1.Unmarshal(ext.Value, &iss); err == nil {
+				return iss
+			}
+		case ext.Id.Equal(oidIssuer):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	pemPublicKey  = "PUBLIC KEY"
+	pemPrivateKey = "PRIVATE KEY"
+	pemCert       = "CERTIFICATE"
+
+	// pemEncryptedKeys are the PEM types of private keys generated by cosign
+	// generate-key-pair, by current and older versions of cosign.
+	pemEncryptedKey       = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	pemEncryptedCosignKey = "ENCRYPTED COSIGN PRIVATE KEY"
+
+	kdfScrypt       = "scrypt"
+	cipherSecretbox = "nacl/secretbox"
+)
+
+const (
+	errFmtReadKey        = "failed to read key %s"
+	errFmtNoPEM          = "%s does not contain a PEM block"
+	errFmtPEMType        = "%s contains a PEM block of type %q, expected %q"
+	errFmtParseKey       = "failed to parse key %s"
+	errFmtNotSigner      = "key %s cannot sign"
+	errFmtNoCertificates = "%s does not contain any certificates"
+	errFmtDecryptKey     = "failed to decrypt key %s: wrong password or corrupted key"
+	errFmtKeyEncryption  = "key %s is encrypted with unsupported algorithms %s and %s"
+)
+
+// encryptedKey is the encrypted private key format of cosign. Byte slices are
+// base64 encoded in JSON.
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPublicKey loads a PEM encoded public key, such as the cosign.pub key
+// generated by cosign generate-key-pair.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := readPEM(path, pemPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(b.Bytes)
+	return key, errors.Wrapf(err, errFmtParseKey, path)
+}
+
+// LoadTrustRoots loads PEM encoded certificates that keyless signatures must
+// chain up to, such as the Fulcio root of a sigstore deployment.
+func LoadTrustRoots(paths ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, p := range paths {
+		b, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtReadKey, p)
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf(errFmtNoCertificates, p)
+		}
+	}
+	return pool, nil
+}
+
+// LoadPrivateKey loads a PEM encoded private key. Keys generated by cosign
+// generate-key-pair are decrypted with password, while unencrypted PKCS #8
+// keys are loaded as is.
+func LoadPrivateKey(path string, password []byte) (crypto.Signer, error) {
+	b, err := readPEM(path, "")
+	if err != nil {
+		return nil, err
+	}
+
+	der := b.Bytes
+	switch b.Type {
+	case pemEncryptedKey, pemEncryptedCosignKey:
+		if der, err = decryptKey(path, b.Bytes, password); err != nil {
+			return nil, err
+		}
+	case pemPrivateKey:
+	default:
+		return nil, errors.Errorf(errFmtPEMType, path, b.Type, pemPrivateKey)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtParseKey, path)
+	}
+	s, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf(errFmtNotSigner, path)
+	}
+	return s, nil
+}
+
+func decryptKey(path string, data, password []byte) ([]byte, error) {
+	k := &encryptedKey{}
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, errors.Wrapf(err, errFmtParseKey, path)
+	}
+	if k.KDF.Name != kdfScrypt || k.Cipher.Name != cipherSecretbox {
+		return nil, errors.Errorf(errFmtKeyEncryption, path, k.KDF.Name, k.Cipher.Name)
+	}
+	secret, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtParseKey, path)
+	}
+	var (
+		key   [32]byte
+		nonce [24]byte
+	)
+	copy(key[:], secret)
+	copy(nonce[:], k.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.Errorf(errFmtDecryptKey, path)
+	}
+	return der, nil
+}
+
+// readPEM reads the first PEM block of a file, which must be of type typ if it
+// is not empty.
+func readPEM(path, typ string) (*pem.Block, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtReadKey, path)
+	}
+	b, _ := pem.Decode(data)
+	if b == nil {
+		return nil, errors.Errorf(errFmtNoPEM, path)
+	}
+	if typ != "" && b.Type != typ {
+		return nil, errors.Errorf(errFmtPEMType, path, b.Type, typ)
+	}
+	return b, nil
+}
+
+// parseCertificates parses PEM encoded certificates.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var b *pem.Block
+		b, data = pem.Decode(data)
+		if b == nil {
+			return certs, nil
+		}
+		if b.Type != pemCert {
+			continue
+		}
+		c, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	errFmtSign          = "failed to sign %s"
+	errFmtPushSignature = "failed to push signature %s"
+)
+
+// A Signer signs OCI artifacts with a private key, and pushes their signatures
+// to the registry they are pushed to.
+type Signer struct {
+	key        crypto.Signer
+	remoteOpts []remote.Option
+}
+
+// NewSigner returns a signer that signs with key, and pushes signatures with the
+// remote options.
+func NewSigner(key crypto.Signer, opts ...remote.Option) *Signer {
+	return &Signer{
+		key:        key,
+		remoteOpts: opts,
+	}
+}
+
+// Sign signs the artifact with the digest in the repository of ref. Signatures
+// are added to any existing signatures of the artifact. A nil Signer signs
+// nothing.
+func (s *Signer) Sign(ctx context.Context, ref name.Reference, digest v1.Hash) error {
+	if s == nil {
+		return nil
+	}
+	repo := ref.Context()
+	p, err := json.Marshal(payload{
+		Critical: critical{
+			Identity: identity{DockerReference: repo.Name()},
+			Image:    image{DockerManifestDigest: digest.String()},
+			Type:     payloadTypeSignature,
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, errFmtSign, ref)
+	}
+	sig, err := sign(s.key, p)
+	if err != nil {
+		return errors.Wrapf(err, errFmtSign, ref)
+	}
+
+	tag := signatureTag(repo, digest, signatureTagSuffix)
+	opts := append([]remote.Option{remote.WithContext(ctx)}, s.remoteOpts...)
+	base, err := remote.Image(tag, opts...)
+	switch {
+	case isNotFound(err):
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	case err != nil:
+		return errors.Wrapf(err, errFmtPushSignature, tag)
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer: static.NewLayer(p, SimpleSigningMediaType),
+		Annotations: map[string]string{
+			SignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, errFmtPushSignature, tag)
+	}
+	return errors.Wrapf(remote.Write(tag, img, opts...), errFmtPushSignature, tag)
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signature signs and verifies OCI artifacts in the format of cosign,
+// so that artifacts signed by up can be verified by cosign and vice versa.
+// Signatures and attestations are stored in the registry next to the artifact
+// they sign, in the sha256-<digest>.sig and sha256-<digest>.att tags.
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// SimpleSigningMediaType is the media type of the layers of signature
+	// images, which contain the signed payload.
+	SimpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// DSSEMediaType is the media type of the layers of attestation images,
+	// which contain a signed in-toto statement in a DSSE envelope.
+	DSSEMediaType types.MediaType = "application/vnd.dsse.envelope.v1+json"
+
+	// SignatureAnnotation is the layer annotation holding the base64 encoded
+	// signature of the payload of a signature image.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// CertificateAnnotation is the layer annotation holding the PEM encoded
+	// certificate of a keyless signature.
+	CertificateAnnotation = "dev.sigstore.cosign/certificate"
+
+	// ChainAnnotation is the layer annotation holding the PEM encoded
+	// intermediate certificates of a keyless signature.
+	ChainAnnotation = "dev.sigstore.cosign/chain"
+)
+
+const (
+	signatureTagSuffix   = "sig"
+	attestationTagSuffix = "att"
+
+	payloadTypeSignature = "cosign container image signature"
+	payloadTypeInToto    = "application/vnd.in-toto+json"
+)
+
+const (
+	errFmtUnsupportedKey = "unsupported key type %T"
+	errInvalidSignature  = "invalid signature"
+)
+
+// payload is the simple signing payload of a signature, which binds it to the
+// digest of the artifact.
+type payload struct {
+	Critical critical       `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+type critical struct {
+	Identity identity `json:"identity"`
+	Image    image    `json:"image"`
+	Type     string   `json:"type"`
+}
+
+type identity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type image struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// envelope is a DSSE envelope. Byte slices are base64 encoded in JSON.
+type envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"`
+	Signatures  []envelopeSignature `json:"signatures"`
+}
+
+type envelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// statement is the subset of an in-toto statement needed to verify it.
+type statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []subject `json:"subject"`
+}
+
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// signatureTag returns the tag of the signature or attestation image of the
+// artifact with the digest in repo.
+func signatureTag(repo name.Repository, digest v1.Hash, suffix string) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s.%s", digest.Algorithm, digest.Hex, suffix))
+}
+
+// pae returns the DSSE pre-authentication encoding of a payload, which is what
+// the signatures of an envelope sign.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// sign signs msg as cosign does: ECDSA and RSA keys sign its SHA-256 digest,
+// while ed25519 keys sign it as is.
+func sign(key crypto.Signer, msg []byte) ([]byte, error) {
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, msg, crypto.Hash(0))
+	}
+	h := sha256.Sum256(msg)
+	return key.Sign(rand.Reader, h[:], crypto.SHA256)
+}
+
+// verify verifies the signature of msg made by sign.
+func verify(key crypto.PublicKey, msg, sig []byte) error {
+	h := sha256.Sum256(msg)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, h[:], sig) {
+			return errors.New(errInvalidSignature)
+		}
+		return nil
+	case *rsa.PublicKey:
+		return errors.Wrap(rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig), errInvalidSignature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, msg, sig) {
+			return errors.New(errInvalidSignature)
+		}
+		return nil
+	default:
+		return errors.Errorf(errFmtUnsupportedKey, key)
+	}
+}
+
+// isNotFound returns true if err is a registry error for a missing manifest.
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,482 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestVerify(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	signingKey := newKey(t)
+	otherKey := newKey(t)
+	tlogKey := newKey(t)
+	root, rootKey := newCert(t, nil, nil, &signingKey.PublicKey)
+	leaf, _ := newCert(t, root, rootKey, &signingKey.PublicKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	// The leaf certificate expired a minute ago, but was valid when signatures
+	// were recorded in the transparency log.
+	recorded := time.Unix(leaf.NotBefore.Unix()+60, 0)
+	keyless := []VerifierOption{
+		WithTrustRoots(roots),
+		WithIdentity(testIdentity, testIssuer),
+		WithTransparencyLogKeys(&tlogKey.PublicKey),
+	}
+
+	// signKeyless pushes a keyless signature of the artifact by the leaf
+	// certificate, recorded in the transparency log at the given time.
+	signKeyless := func(t *testing.T, ref name.Reference, d v1.Hash, at time.Time, modify func(map[string]string)) {
+		t.Helper()
+		p, _ := json.Marshal(payload{Critical: critical{Image: image{DockerManifestDigest: d.String()}, Type: payloadTypeSignature}})
+		sig := signWith(t, signingKey, p)
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: pemCert, Bytes: leaf.Raw})
+		h := sha256.Sum256(p)
+		e := &hashedRekord{Kind: kindHashedRekord}
+		e.Spec.Data.Hash.Algorithm = algorithmSHA256
+		e.Spec.Data.Hash.Value = hex.EncodeToString(h[:])
+		e.Spec.Signature.Content = sig
+		e.Spec.Signature.PublicKey.Content = certPEM
+		body, _ := json.Marshal(e)
+		b := bundle{Payload: bundlePayload{Body: base64.StdEncoding.EncodeToString(body), IntegratedTime: at.Unix(), LogID: "test", LogIndex: 1}}
+		canonical, _ := json.Marshal(b.Payload)
+		b.SignedEntryTimestamp = signWith(t, tlogKey, canonical)
+		raw, _ := json.Marshal(b)
+		annotations := map[string]string{
+			SignatureAnnotation:   base64.StdEncoding.EncodeToString(sig),
+			CertificateAnnotation: string(certPEM),
+			BundleAnnotation:      string(raw),
+		}
+		if modify != nil {
+			modify(annotations)
+		}
+		pushLayer(t, signatureTag(ref.Context(), d, signatureTagSuffix), p, SimpleSigningMediaType, annotations)
+	}
+
+	// push pushes a random artifact and returns its reference and digest.
+	push := func(t *testing.T, repo string) (name.Reference, v1.Hash) {
+		t.Helper()
+		ref, err := name.ParseReference(u.Host + "/" + repo + ":v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+		d, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref, d
+	}
+
+	cases := map[string]struct {
+		reason string
+		setup  func(t *testing.T, ref name.Reference, d v1.Hash)
+		opts   []VerifierOption
+		want   error
+	}{
+		"SignedWithKey": {
+			reason: "Artifacts signed with one of the public keys should be verified.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				if err := NewSigner(signingKey).Sign(context.Background(), ref, d); err != nil {
+					t.Fatal(err)
+				}
+			},
+			opts: []VerifierOption{WithPublicKeys(&otherKey.PublicKey, &signingKey.PublicKey)},
+		},
+		"SignedWithOtherKey": {
+			reason: "Artifacts signed with another key should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				if err := NewSigner(otherKey).Sign(context.Background(), ref, d); err != nil {
+					t.Fatal(err)
+				}
+			},
+			opts: []VerifierOption{WithPublicKeys(&signingKey.PublicKey)},
+			want: errors.New(errNoMatchingKey),
+		},
+		"Unsigned": {
+			reason: "Unsigned artifacts should be refused.",
+			opts:   []VerifierOption{WithPublicKeys(&signingKey.PublicKey)},
+			want:   errors.New(errUnsigned),
+		},
+		"UnsignedWarn": {
+			reason: "Unsigned artifacts should only be reported with the warn policy.",
+			opts: []VerifierOption{
+				WithPolicy(PolicyWarn),
+				WithPublicKeys(&signingKey.PublicKey),
+				WithWarnFn(func(string, ...any) {}),
+			},
+		},
+		"Keyless": {
+			reason: "Artifacts signed with a certificate of the identity that chains up to a trust root, and recorded in the transparency log, should be verified.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: keyless,
+		},
+		"KeylessUntrusted": {
+			reason: "Artifacts signed with a certificate that does not chain up to a trust root should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: []VerifierOption{
+				WithTrustRoots(x509.NewCertPool()),
+				WithIdentity(testIdentity, testIssuer),
+				WithTransparencyLogKeys(&tlogKey.PublicKey),
+			},
+			want: errors.Wrap(x509.UnknownAuthorityError{}, errInvalidCertificate),
+		},
+		"KeylessUnconstrained": {
+			reason: "Keyless signatures should be refused without an identity, an OIDC issuer and a transparency log key.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: []VerifierOption{WithTrustRoots(roots)},
+			want: errors.New(errKeylessConstraints),
+		},
+		"KeylessOtherIdentity": {
+			reason: "Artifacts signed with a certificate of another identity should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: []VerifierOption{
+				WithTrustRoots(roots),
+				WithIdentity("other@example.com", testIssuer),
+				WithTransparencyLogKeys(&tlogKey.PublicKey),
+			},
+			want: errors.Errorf(errFmtIdentity, []string{testIdentity}, "other@example.com"),
+		},
+		"KeylessOtherIssuer": {
+			reason: "Artifacts signed with a certificate of an identity authenticated by another OIDC issuer should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: []VerifierOption{
+				WithTrustRoots(roots),
+				WithIdentity(testIdentity, "https://other.example.com"),
+				WithTransparencyLogKeys(&tlogKey.PublicKey),
+			},
+			want: errors.Errorf(errFmtIssuer, testIssuer, "https://other.example.com"),
+		},
+		"KeylessNotRecorded": {
+			reason: "Keyless signatures without a transparency log bundle should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, func(a map[string]string) { delete(a, BundleAnnotation) })
+			},
+			opts: keyless,
+			want: errors.New(errNoBundle),
+		},
+		"KeylessOtherLog": {
+			reason: "Keyless signatures recorded in another transparency log should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, recorded, nil)
+			},
+			opts: []VerifierOption{
+				WithTrustRoots(roots),
+				WithIdentity(testIdentity, testIssuer),
+				WithTransparencyLogKeys(&otherKey.PublicKey),
+			},
+			want: errors.New(errBundleTimestamp),
+		},
+		"KeylessRecordedAfterExpiry": {
+			reason: "Keyless signatures recorded after their certificate expired should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				signKeyless(t, ref, d, leaf.NotAfter.Add(time.Second), nil)
+			},
+			opts: keyless,
+			want: errors.Wrap(x509.CertificateInvalidError{
+				Cert:   leaf,
+				Reason: x509.Expired,
+				Detail: fmt.Sprintf("current time %s is after %s", time.Unix(leaf.NotAfter.Add(time.Second).Unix(), 0).Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339)),
+			}, errInvalidCertificate),
+		},
+		"OtherDigest": {
+			reason: "Signatures of another digest should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				p, _ := json.Marshal(payload{Critical: critical{Image: image{DockerManifestDigest: "sha256:0000"}, Type: payloadTypeSignature}})
+				pushLayer(t, signatureTag(ref.Context(), d, signatureTagSuffix), p, SimpleSigningMediaType, map[string]string{
+					SignatureAnnotation: base64.StdEncoding.EncodeToString(signWith(t, signingKey, p)),
+				})
+			},
+			opts: []VerifierOption{WithPublicKeys(&signingKey.PublicKey)},
+			want: errors.New(errDigestMismatch),
+		},
+		"Attestation": {
+			reason: "Artifacts with a signed attestation of the required predicate type should be verified.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				if err := NewSigner(signingKey).Sign(context.Background(), ref, d); err != nil {
+					t.Fatal(err)
+				}
+				st, _ := json.Marshal(statement{
+					Type:          "https://in-toto.io/Statement/v1",
+					PredicateType: "https://slsa.dev/provenance/v1",
+					Subject:       []subject{{Name: ref.Context().Name(), Digest: map[string]string{d.Algorithm: d.Hex}}},
+				})
+				env, _ := json.Marshal(envelope{
+					PayloadType: payloadTypeInToto,
+					Payload:     st,
+					Signatures:  []envelopeSignature{{Sig: signWith(t, signingKey, pae(payloadTypeInToto, st))}},
+				})
+				pushLayer(t, signatureTag(ref.Context(), d, attestationTagSuffix), env, DSSEMediaType, nil)
+			},
+			opts: []VerifierOption{
+				WithPublicKeys(&signingKey.PublicKey),
+				WithAttestations("https://slsa.dev/provenance/v1"),
+			},
+		},
+		"MissingAttestation": {
+			reason: "Artifacts without an attestation of the required predicate type should be refused.",
+			setup: func(t *testing.T, ref name.Reference, d v1.Hash) {
+				t.Helper()
+				if err := NewSigner(signingKey).Sign(context.Background(), ref, d); err != nil {
+					t.Fatal(err)
+				}
+			},
+			opts: []VerifierOption{
+				WithPublicKeys(&signingKey.PublicKey),
+				WithAttestations("https://slsa.dev/provenance/v1"),
+			},
+			want: errors.Errorf(errFmtAttestation, "https://slsa.dev/provenance/v1"),
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			ref, d := push(t, "test/"+strings.ToLower(n))
+			if tc.setup != nil {
+				tc.setup(t, ref, d)
+			}
+			err := NewVerifier(tc.opts...).Verify(context.Background(), ref, d)
+			if tc.want != nil {
+				tc.want = errors.Wrapf(tc.want, errFmtVerify, ref)
+			}
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nVerify(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	key := newKey(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason   string
+		block    *pem.Block
+		password string
+		want     error
+	}{
+		"Unencrypted": {
+			reason: "Unencrypted PKCS #8 keys should be loaded.",
+			block:  &pem.Block{Type: pemPrivateKey, Bytes: der},
+		},
+		"Encrypted": {
+			reason:   "Keys encrypted by cosign should be decrypted with the password.",
+			block:    &pem.Block{Type: pemEncryptedKey, Bytes: encrypt(t, der, "secret")},
+			password: "secret",
+		},
+		"WrongPassword": {
+			reason:   "Keys encrypted by cosign should not be decrypted with another password.",
+			block:    &pem.Block{Type: pemEncryptedKey, Bytes: encrypt(t, der, "secret")},
+			password: "guess",
+			want:     errors.Errorf(errFmtDecryptKey, "key.pem"),
+		},
+	}
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(tc.block), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			wd, _ := os.Getwd()
+			defer os.Chdir(wd) //nolint:errcheck // Best effort.
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadPrivateKey("key.pem", []byte(tc.password))
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nLoadPrivateKey(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want == nil && !key.Equal(got) {
+				t.Errorf("\n%s\nLoadPrivateKey(...): got another key", tc.reason)
+			}
+		})
+	}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+const (
+	testIdentity = "signer@example.com"
+	testIssuer   = "https://issuer.example.com"
+)
+
+// newCert returns a self-signed CA certificate if parent is nil, or a code
+// signing certificate of the test identity issued by parent otherwise.
+func newCert(t *testing.T, parent *x509.Certificate, parentKey crypto.Signer, pub crypto.PublicKey) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	iss, err := asn1.MarshalWithParams(testIssuer, "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(-time.Minute),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		caKey := newKey(t)
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		parent, parentKey, pub = tmpl, caKey, &caKey.PublicKey
+	} else {
+		tmpl.EmailAddresses = []string{testIdentity}
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: oidIssuerV2, Value: iss}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, parentKey
+}
+
+func signWith(t *testing.T, key crypto.Signer, msg []byte) []byte {
+	t.Helper()
+	sig, err := sign(key, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// pushLayer pushes an image with a single layer to tag.
+func pushLayer(t *testing.T, tag name.Tag, content []byte, mt types.MediaType, annotations map[string]string) {
+	t.Helper()
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mutate.Addendum{
+		Layer:       static.NewLayer(content, mt),
+		Annotations: annotations,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// encrypt encrypts a key as cosign generate-key-pair does, with cheaper scrypt
+// parameters.
+func encrypt(t *testing.T, der []byte, password string) []byte {
+	t.Helper()
+	k := &encryptedKey{}
+	k.KDF.Name = kdfScrypt
+	k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P = 1024, 8, 1
+	k.KDF.Salt = []byte("salt")
+	k.Cipher.Name = cipherSecretbox
+	k.Cipher.Nonce = make([]byte, 24)
+	secret, err := scrypt.Key([]byte(password), k.KDF.Salt, 1024, 8, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		key   [32]byte
+		nonce [24]byte
+	)
+	copy(key[:], secret)
+	k.Ciphertext = secretbox.Seal(nil, der, &nonce, &key)
+	b, err := json.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
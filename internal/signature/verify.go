@@ -0,0 +1,382 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pterm/pterm"
+)
+
+// Policy is how artifacts that cannot be verified are handled.
+type Policy string
+
+const (
+	// PolicyNone does not verify artifacts.
+	PolicyNone Policy = "none"
+	// PolicyWarn verifies artifacts, and warns about artifacts that cannot be
+	// verified.
+	PolicyWarn Policy = "warn"
+	// PolicyEnforce verifies artifacts, and refuses artifacts that cannot be
+	// verified.
+	PolicyEnforce Policy = "enforce"
+)
+
+const (
+	errFmtVerify            = "failed to verify signature of %s"
+	errFmtWarnVerify        = "%s could not be verified: %v"
+	errFmtGetSignatures     = "failed to get signatures of %s"
+	errFmtAttestation       = "no valid attestation with predicate type %s"
+	errFmtLayer             = "failed to read layer %s"
+	errUnsigned             = "artifact is not signed"
+	errNoValidSignature     = "no valid signature"
+	errNoMatchingKey        = "signature does not match any of the keys or trust roots"
+	errDigestMismatch       = "signature is for another digest"
+	errPayloadType          = "unexpected payload type"
+	errSubjectMismatch      = "attestation is for another subject"
+	errInvalidCertificate   = "invalid certificate"
+	errNoVerificationMethod = "a public key or a trust root is required to verify signatures"
+	errKeylessFlags         = "--verify-trust-root requires --verify-identity, --verify-oidc-issuer and --verify-tlog-key"
+	errFmtNotOCI            = "%s is not pulled from an OCI registry, so its signature cannot be verified"
+)
+
+// A Verifier verifies the signatures and attestations of OCI artifacts.
+// Signatures must be made by one of its public keys, or be keyless signatures
+// recorded in a transparency log by a certificate that chains up to one of its
+// trust roots and is issued to its identity. A nil Verifier verifies nothing.
+type Verifier struct {
+	policy       Policy
+	keys         []crypto.PublicKey
+	roots        *x509.CertPool
+	identity     string
+	issuer       string
+	tlogKeys     []crypto.PublicKey
+	attestations []string
+	remoteOpts   []remote.Option
+	warn         func(format string, a ...any)
+}
+
+// VerifierOption modifies a verifier.
+type VerifierOption func(*Verifier)
+
+// WithPolicy sets the policy of the verifier.
+func WithPolicy(p Policy) VerifierOption {
+	return func(v *Verifier) {
+		v.policy = p
+	}
+}
+
+// WithPublicKeys sets the public keys signatures may be made by.
+func WithPublicKeys(keys ...crypto.PublicKey) VerifierOption {
+	return func(v *Verifier) {
+		v.keys = keys
+	}
+}
+
+// WithTrustRoots sets the certificates the certificates of keyless signatures
+// must chain up to.
+func WithTrustRoots(roots *x509.CertPool) VerifierOption {
+	return func(v *Verifier) {
+		v.roots = roots
+	}
+}
+
+// WithIdentity sets the identity the certificates of keyless signatures must be
+// issued to, e.g. an email address or a URI, and the OIDC issuer that must have
+// authenticated it.
+func WithIdentity(identity, issuer string) VerifierOption {
+	return func(v *Verifier) {
+		v.identity = identity
+		v.issuer = issuer
+	}
+}
+
+// WithTransparencyLogKeys sets the public keys of the transparency logs keyless
+// signatures must be recorded in, e.g. the Rekor public key.
+func WithTransparencyLogKeys(keys ...crypto.PublicKey) VerifierOption {
+	return func(v *Verifier) {
+		v.tlogKeys = keys
+	}
+}
+
+// WithAttestations sets the predicate types of the attestations artifacts must
+// have in addition to a signature, e.g. https://slsa.dev/provenance/v1.
+func WithAttestations(predicateTypes ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.attestations = predicateTypes
+	}
+}
+
+// WithRemoteOptions sets the options used to get signatures from registries.
+func WithRemoteOptions(opts ...remote.Option) VerifierOption {
+	return func(v *Verifier) {
+		v.remoteOpts = opts
+	}
+}
+
+// WithWarnFn sets the function artifacts that cannot be verified are reported
+// to with the warn policy.
+func WithWarnFn(fn func(format string, a ...any)) VerifierOption {
+	return func(v *Verifier) {
+		v.warn = fn
+	}
+}
+
+// NewVerifier returns a verifier. It enforces signatures by default.
+func NewVerifier(opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		policy: PolicyEnforce,
+		warn: func(format string, a ...any) {
+			pterm.Warning.Printfln(format, a...)
+		},
+	}
+	for _, o := range opts {
+		o(v)
+	}
+	return v
+}
+
+// Verify verifies the signature of the artifact with the digest in the
+// repository of ref, and its attestations if any are required.
+func (v *Verifier) Verify(ctx context.Context, ref name.Reference, digest v1.Hash) error {
+	if v == nil || v.policy == PolicyNone {
+		return nil
+	}
+	return v.handle(ref.String(), v.verify(ctx, ref.Context(), digest))
+}
+
+// Unverifiable reports that an artifact that is not pulled from an OCI
+// registry, such as a chart from a Helm repository, cannot be verified.
+func (v *Verifier) Unverifiable(artifact string) error {
+	if v == nil || v.policy == PolicyNone {
+		return nil
+	}
+	return v.handle(artifact, errors.Errorf(errFmtNotOCI, artifact))
+}
+
+func (v *Verifier) handle(artifact string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if v.policy == PolicyWarn {
+		v.warn(errFmtWarnVerify, artifact, err)
+		return nil
+	}
+	return errors.Wrapf(err, errFmtVerify, artifact)
+}
+
+func (v *Verifier) verify(ctx context.Context, repo name.Repository, digest v1.Hash) error {
+	layers, err := v.layers(ctx, signatureTag(repo, digest, signatureTagSuffix))
+	if err != nil {
+		return err
+	}
+	if len(layers) == 0 {
+		return errors.New(errUnsigned)
+	}
+	err = errors.New(errNoValidSignature)
+	for _, l := range layers {
+		if l.mediaType != SimpleSigningMediaType {
+			continue
+		}
+		if err = v.verifySignature(l, digest); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(v.attestations) == 0 {
+		return nil
+	}
+	layers, err = v.layers(ctx, signatureTag(repo, digest, attestationTagSuffix))
+	if err != nil {
+		return err
+	}
+	verified := map[string]bool{}
+	for _, l := range layers {
+		if l.mediaType != DSSEMediaType {
+			continue
+		}
+		if pt, err := v.verifyAttestation(l, digest); err == nil {
+			verified[pt] = true
+		}
+	}
+	for _, pt := range v.attestations {
+		if !verified[pt] {
+			return errors.Errorf(errFmtAttestation, pt)
+		}
+	}
+	return nil
+}
+
+// verifySignature verifies the signature of a simple signing payload, and that
+// the payload is for the digest.
+func (v *Verifier) verifySignature(l layer, digest v1.Hash) error {
+	sig, err := base64.StdEncoding.DecodeString(l.annotations[SignatureAnnotation])
+	if err != nil {
+		return errors.Wrap(err, errInvalidSignature)
+	}
+	if err := v.verifyBlob(l, l.content, sig); err != nil {
+		return err
+	}
+	p := &payload{}
+	if err := json.Unmarshal(l.content, p); err != nil {
+		return errors.Wrap(err, errInvalidSignature)
+	}
+	if p.Critical.Type != payloadTypeSignature {
+		return errors.New(errPayloadType)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest.String() {
+		return errors.New(errDigestMismatch)
+	}
+	return nil
+}
+
+// verifyAttestation verifies the signature of an in-toto statement in a DSSE
+// envelope, and that the statement is about the digest. It returns the
+// predicate type of the statement.
+func (v *Verifier) verifyAttestation(l layer, digest v1.Hash) (string, error) {
+	env := &envelope{}
+	if err := json.Unmarshal(l.content, env); err != nil {
+		return "", errors.Wrap(err, errInvalidSignature)
+	}
+	if env.PayloadType != payloadTypeInToto {
+		return "", errors.New(errPayloadType)
+	}
+	err := errors.New(errNoValidSignature)
+	for _, s := range env.Signatures {
+		if err = v.verifyKeys(pae(env.PayloadType, env.Payload), s.Sig); err == nil {
+			break
+		}
+	}
+	if err != nil && l.annotations[CertificateAnnotation] != "" {
+		return "", errors.New(errKeylessAttestation)
+	}
+	if err != nil {
+		return "", err
+	}
+	st := &statement{}
+	if err := json.Unmarshal(env.Payload, st); err != nil {
+		return "", errors.Wrap(err, errInvalidSignature)
+	}
+	for _, s := range st.Subject {
+		if s.Digest[digest.Algorithm] == digest.Hex {
+			return st.PredicateType, nil
+		}
+	}
+	return "", errors.New(errSubjectMismatch)
+}
+
+// verifyBlob verifies that sig is a signature of msg by one of the public keys,
+// or a keyless signature by the certificate of the layer.
+func (v *Verifier) verifyBlob(l layer, msg, sig []byte) error {
+	if err := v.verifyKeys(msg, sig); err == nil || v.roots == nil || l.annotations[CertificateAnnotation] == "" {
+		return err
+	}
+	return v.verifyKeyless(l, msg, sig)
+}
+
+// verifyKeys verifies that sig is a signature of msg by one of the public keys.
+func (v *Verifier) verifyKeys(msg, sig []byte) error {
+	for _, k := range v.keys {
+		if verify(k, msg, sig) == nil {
+			return nil
+		}
+	}
+	return errors.New(errNoMatchingKey)
+}
+
+// verifyCertificate verifies that a code signing certificate chains up to a
+// trust root at the time a signature was made with it. Keyless certificates
+// are only valid for minutes, so they are verified at the time the signature
+// was recorded in the transparency log.
+func (v *Verifier) verifyCertificate(c *x509.Certificate, chain []byte, signed time.Time) error {
+	intermediates, err := parseCertificates(chain)
+	if err != nil {
+		return errors.Wrap(err, errInvalidCertificate)
+	}
+	pool := x509.NewCertPool()
+	for _, c := range intermediates {
+		pool.AddCert(c)
+	}
+	_, err = c.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: pool,
+		CurrentTime:   signed,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	return errors.Wrap(err, errInvalidCertificate)
+}
+
+// layer is a layer of a signature or attestation image.
+type layer struct {
+	mediaType   types.MediaType
+	annotations map[string]string
+	content     []byte
+}
+
+// layers returns the layers of a signature or attestation image, or none if the
+// image does not exist.
+func (v *Verifier) layers(ctx context.Context, tag name.Tag) ([]layer, error) {
+	img, err := remote.Image(tag, append([]remote.Option{remote.WithContext(ctx)}, v.remoteOpts...)...)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtGetSignatures, tag)
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtGetSignatures, tag)
+	}
+	layers := make([]layer, 0, len(m.Layers))
+	for _, d := range m.Layers {
+		l, err := img.LayerByDigest(d.Digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtLayer, d.Digest)
+		}
+		content, err := readLayer(l)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtLayer, d.Digest)
+		}
+		layers = append(layers, layer{
+			mediaType:   d.MediaType,
+			annotations: d.Annotations,
+			content:     content,
+		})
+	}
+	return layers, nil
+}
+
+func readLayer(l v1.Layer) ([]byte, error) {
+	rc, err := l.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck // Read only.
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	upctx "github.com/upbound/up/cmd/up/ctx"
+	"github.com/upbound/up/internal/profile"
+	"github.com/upbound/up/internal/upbound"
+)
+
+// ControlPlaneConfig gets a REST config for a given control plane within the
+// space of the current kubeconfig context.
+func ControlPlaneConfig(ctx context.Context, upCtx *upbound.Context, ctp types.NamespacedName) (*rest.Config, error) {
+	po := clientcmd.NewDefaultPathOptions()
+
+	conf, err := po.GetStartingConfig()
+	if err != nil {
+		return nil, err
+	}
+	state, err := upctx.DeriveState(ctx, upCtx, conf, profile.GetIngressHost)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	var space *upctx.Space
+
+	if space, ok = state.(*upctx.Space); !ok {
+		if group, ok := state.(*upctx.Group); ok {
+			space = &group.Space
+		} else if ctp, ok := state.(*upctx.ControlPlane); ok {
+			space = &ctp.Group.Space
+		} else {
+			return nil, errors.New("current kubeconfig is not pointed at a space cluster")
+		}
+	}
+
+	spaceClient, err := space.BuildClient(upCtx, ctp)
+	if err != nil {
+		return nil, err
+	}
+
+	return spaceClient.ClientConfig()
+}
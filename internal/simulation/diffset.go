@@ -0,0 +1,195 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/pkg/errors"
+	diffv3 "github.com/r3labs/diff/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+	"github.com/upbound/up/internal/diff"
+	"github.com/upbound/up/internal/kube"
+)
+
+const (
+	// annotationKeyClonedState is the annotation key storing the JSON
+	// representation of the state of the resource at control plane clone time.
+	annotationKeyClonedState = "simulation.spaces.upbound.io/cloned-state"
+)
+
+// removeFieldsForDiff removes any fields that should be excluded from the diff.
+func removeFieldsForDiff(u *unstructured.Unstructured) error {
+	// based on the filters in the simulation preprocessor
+	// https://github.com/upbound/spaces/blob/v1.8.0/internal/controller/mxe/simulation/preprocess.go#L100-L108
+	trim := []string{
+		"metadata.generateName",
+		"metadata.uid",
+		"metadata.resourceVersion",
+		"metadata.generation",
+		"metadata.creationTimestamp",
+		"metadata.ownerReferences",
+		"metadata.managedFields",
+		"metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']",
+		fmt.Sprintf("metadata.annotations['%s']", annotationKeyClonedState),
+		"spec.compositionRevisionRef",
+	}
+
+	wildcards := []string{
+		"status.conditions[*].lastTransitionTime",
+	}
+
+	p := fieldpath.Pave(u.UnstructuredContent())
+
+	// expand each wildcard path and add to list to trim
+	for _, wildcard := range wildcards {
+		if expanded, err := p.ExpandWildcards(wildcard); err != nil {
+			return errors.Wrap(err, "unable to expand wildcards in ignored fields")
+		} else {
+			trim = append(trim, expanded...)
+		}
+	}
+
+	for _, path := range trim {
+		if err := p.DeleteField(path); err != nil {
+			return errors.Wrap(err, "cannot delete field")
+		}
+	}
+
+	return nil
+}
+
+// ComputeDiffSet reads through all of the changes from the simulation status
+// and looks up the difference between the initial version of the resource and
+// the version currently in the API server (at the time of the function call).
+// Debug messages are written to the debug writer.
+func ComputeDiffSet(ctx context.Context, config *rest.Config, changes []spacesv1alpha1.SimulationChange, debug io.Writer) ([]diff.ResourceDiff, error) { // nolint:gocyclo
+	lookup, err := kube.NewDiscoveryResourceLookup(config)
+	if err != nil {
+		return []diff.ResourceDiff{}, errors.Wrap(err, "unable to create resource lookup client")
+	}
+
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return []diff.ResourceDiff{}, errors.Wrap(err, "unable to create dynamic client")
+	}
+
+	diffSet := make([]diff.ResourceDiff, 0, len(changes))
+
+	fmt.Fprintf(debug, "iterating over %d changes\n", len(changes))
+
+	// stores a list of resources that we want to filter in the diff, that
+	// aren't being filtered in the reconciler
+	trimKind := []schema.GroupVersionKind{
+		{Group: "apiextensions.crossplane.io", Version: "v1", Kind: "CompositionRevision"},
+		{Group: "pkg.crossplane.io", Version: "v1beta1", Kind: "DeploymentRuntimeConfig"},
+	}
+
+	for _, change := range changes {
+		gvk := schema.FromAPIVersionAndKind(change.ObjectReference.APIVersion, change.ObjectReference.Kind)
+
+		// todo(redbackthomson): Remove this logic once we have done a better
+		// job of filtering in the reconciler
+		if slices.Contains(trimKind, gvk) {
+			fmt.Fprintf(debug, "skipping gvk %+v\n", gvk)
+			continue
+		}
+
+		rs, err := lookup.Get(gvk)
+		if err != nil {
+			fmt.Fprintf(debug, "unable to find gvk from lookup %q\n", gvk)
+			return []diff.ResourceDiff{}, err
+		}
+
+		switch change.Change { //nolint:exhaustive
+		case spacesv1alpha1.SimulationChangeTypeCreate:
+			diffSet = append(diffSet, diff.ResourceDiff{
+				SimulationChange: change,
+			})
+			fmt.Fprintf(debug, "appended create to diff set for %v\n", change.ObjectReference)
+			continue
+		case spacesv1alpha1.SimulationChangeTypeDelete:
+			diffSet = append(diffSet, diff.ResourceDiff{
+				SimulationChange: change,
+			})
+			fmt.Fprintf(debug, "appended delete to diff set for %v\n", change.ObjectReference)
+			continue
+		}
+
+		var cl dynamic.ResourceInterface
+		ncl := dyn.Resource(schema.GroupVersionResource{
+			Group:    rs.Group,
+			Version:  rs.Version,
+			Resource: rs.Name,
+		})
+		if change.ObjectReference.Namespace != nil {
+			cl = ncl.Namespace(*change.ObjectReference.Namespace)
+		} else {
+			cl = ncl
+		}
+
+		after, err := cl.Get(ctx, change.ObjectReference.Name, metav1.GetOptions{})
+		if err != nil {
+			return []diff.ResourceDiff{}, errors.Wrap(err, "unable to get object from simulated control plane")
+		}
+
+		beforeRaw, ok := after.GetAnnotations()[annotationKeyClonedState]
+		if !ok {
+			fmt.Fprintf(debug, "object %v is missing the previous cloned state annotation\n", change.ObjectReference)
+			continue
+		}
+		beforeObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, []byte(beforeRaw))
+		if err != nil {
+			return []diff.ResourceDiff{}, errors.Wrapf(err, "previous cloned state annotation on %v could not be decoded", change.ObjectReference)
+		}
+
+		before := beforeObj.(*unstructured.Unstructured)
+		if err := removeFieldsForDiff(after); err != nil {
+			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to remove fields before diff")
+		}
+
+		if err := removeFieldsForDiff(before); err != nil {
+			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to remove fields before diff")
+		}
+
+		diffd, err := diffv3.Diff(before.UnstructuredContent(), after.UnstructuredContent())
+		if err != nil {
+			return []diff.ResourceDiff{}, errors.Wrapf(err, "unable to calculate diff for object %v", change.ObjectReference)
+		}
+
+		// we filtered out all of the changes
+		if len(diffd) == 0 {
+			continue
+		}
+
+		diffSet = append(diffSet, diff.ResourceDiff{
+			SimulationChange: change,
+			Diff:             diffd,
+		})
+		fmt.Fprintf(debug, "appended update to diff set for %v\n", change.ObjectReference)
+	}
+	return diffSet, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/upbound/up/internal/diff"
+)
+
+// WriteDiff writes the diff set in the given format to the file at path, or to
+// standard out if path is empty. Sensitive values are masked by the redactor,
+// and the policy violations are included in the JSON and Markdown reports.
+func WriteDiff(diffSet []diff.ResourceDiff, format diff.OutputFormat, path string, redactor *diff.Redactor, violations []diff.PolicyViolation) error {
+	stdout := path == ""
+	pretty := format == diff.OutputFormatPretty
+
+	buf := &strings.Builder{}
+	writer, err := diff.NewWriter(format, buf, stdout && pretty, diff.WithRedactor(redactor), diff.WithViolations(violations))
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(diffSet); err != nil {
+		return err
+	}
+
+	if stdout {
+		if pretty {
+			fmt.Printf("\n\n")
+		}
+		fmt.Print(buf.String())
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(buf.String()), 0o644) // nolint:gosec // nothing system sensitive in the file
+}
+
+// EvaluatePolicy evaluates the policy rules against the diff set, returning
+// the violated rules. A nil policy is never violated.
+func EvaluatePolicy(policy *diff.Policy, diffSet []diff.ResourceDiff) []diff.PolicyViolation {
+	if policy == nil {
+		return nil
+	}
+	return policy.Evaluate(diffSet)
+}
+
+// ReportViolations reports each violated rule to w, returning an error if there
+// were any.
+func ReportViolations(w io.Writer, violations []diff.PolicyViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(violations))
+	fmt.Fprintf(w, "\nPolicy violations:\n")
+	for _, v := range violations {
+		fmt.Fprintf(w, "  %s\n", v)
+		if v.Rule.Description != "" {
+			fmt.Fprintf(w, "    %s\n", v.Rule.Description)
+		}
+		names = append(names, v.Rule.Name)
+	}
+	return errors.Errorf("failing since policy rules were violated: %s", strings.Join(names, ", "))
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apixv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/upbound/up/internal/diff"
+)
+
+// NewRedactor returns a redactor that masks the given field paths in every
+// resource, along with any fields marked as sensitive in the schemas of the
+// changed custom resources.
+func NewRedactor(ctx context.Context, config *rest.Config, diffSet []diff.ResourceDiff, paths []string) (*diff.Redactor, error) {
+	rules := []diff.RedactionRule{}
+	if len(paths) > 0 {
+		rules = append(rules, diff.RedactionRule{Paths: paths})
+	}
+
+	schemaRules, err := schemaRedactionRules(ctx, config, diffSet)
+	if err != nil {
+		return nil, err
+	}
+	rules = append(rules, schemaRules...)
+
+	return diff.NewRedactor(rules...)
+}
+
+// schemaRedactionRules returns the redaction rules derived from the CRDs of
+// every changed custom resource.
+func schemaRedactionRules(ctx context.Context, config *rest.Config, diffSet []diff.ResourceDiff) ([]diff.RedactionRule, error) {
+	kinds := map[schema.GroupKind]bool{}
+	for _, d := range diffSet {
+		gvk := schema.FromAPIVersionAndKind(d.SimulationChange.ObjectReference.APIVersion, d.SimulationChange.ObjectReference.Kind)
+		if gvk.Group == "" {
+			continue
+		}
+		kinds[gvk.GroupKind()] = true
+	}
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	cl, err := apixv1client.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create CRD client")
+	}
+	crds, err := cl.CustomResourceDefinitions().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list CRDs")
+	}
+
+	rules := []diff.RedactionRule{}
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		if !kinds[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] {
+			continue
+		}
+		rules = append(rules, diff.SchemaRedactionRules(crd)...)
+	}
+	return rules, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulation contains helpers for running control plane simulations
+// and computing the differences they detected.
+package simulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+
+	spacesv1alpha1 "github.com/upbound/up-sdk-go/apis/spaces/v1alpha1"
+)
+
+// FailOnCondition is the simulation condition that signals a failure in the
+// simulation commands.
+type FailOnCondition string
+
+const (
+	// FailOnNone signals that the command should never return a failure exit
+	// code regardless of the results of the simulation.
+	FailOnNone FailOnCondition = "none"
+	// FailOnDifference signals that the command should return a failure exit
+	// code when any difference was detected.
+	FailOnDifference FailOnCondition = "difference"
+)
+
+const (
+	// controlPlaneReadyTimeout is the time to wait for a simulated control
+	// plane to start and be ready to accept changes.
+	controlPlaneReadyTimeout = 5 * time.Minute
+
+	// simulationCompleteReason is the value present in the `reason` field of
+	// the `AcceptingChanges` condition (on a Simulation) once the results have
+	// been published.
+	simulationCompleteReason = "SimulationComplete"
+)
+
+// CreateOptions configures a new simulation.
+type CreateOptions struct {
+	// Name of the simulation. A name is generated from the source control
+	// plane if not specified.
+	Name string
+	// CompleteAfter is the maximum amount of time the simulated control plane
+	// should run before the simulation is marked as complete.
+	CompleteAfter *time.Duration
+}
+
+// Create creates a new simulation of the given source control plane.
+func Create(ctx context.Context, cl client.Client, source types.NamespacedName, opts CreateOptions) (*spacesv1alpha1.Simulation, error) {
+	sim := &spacesv1alpha1.Simulation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: source.Namespace,
+		},
+		Spec: spacesv1alpha1.SimulationSpec{
+			ControlPlaneName: source.Name,
+			DesiredState:     spacesv1alpha1.SimulationStateAcceptingChanges,
+		},
+	}
+
+	if sim.Name == "" {
+		sim.GenerateName = source.Name + "-"
+	}
+
+	if opts.CompleteAfter != nil {
+		sim.Spec.CompletionCriteria = []spacesv1alpha1.CompletionCriterion{{
+			Type:     spacesv1alpha1.CompletionCriterionTypeDuration,
+			Duration: metav1.Duration{Duration: *opts.CompleteAfter},
+		}}
+	}
+
+	if err := cl.Create(ctx, sim); err != nil {
+		return nil, errors.Wrap(err, "error creating simulation")
+	}
+
+	return sim, nil
+}
+
+// WaitForAcceptingChanges pauses until the given simulation is able to accept
+// changes, or times out.
+func WaitForAcceptingChanges(ctx context.Context, cl client.Client, sim *spacesv1alpha1.Simulation) error {
+	if err := wait.For(func(ctx context.Context) (bool, error) {
+		if err := cl.Get(ctx, types.NamespacedName{Name: sim.Name, Namespace: sim.Namespace}, sim); err != nil {
+			return false, err
+		}
+		return sim.Status.GetCondition(spacesv1alpha1.TypeAcceptingChanges).Status == corev1.ConditionTrue, nil
+	}, wait.WithImmediate(), wait.WithInterval(time.Second*2), wait.WithTimeout(controlPlaneReadyTimeout), wait.WithContext(ctx)); err != nil {
+		return errors.Wrap(err, "timed out before simulation could accept changes")
+	}
+	return nil
+}
+
+// WaitForComplete pauses until the given simulation has been marked as
+// complete.
+func WaitForComplete(ctx context.Context, cl client.Client, sim *spacesv1alpha1.Simulation) error {
+	if err := wait.For(func(ctx context.Context) (bool, error) {
+		if err := cl.Get(ctx, types.NamespacedName{Name: sim.Name, Namespace: sim.Namespace}, sim); err != nil {
+			return false, err
+		}
+		if sim.Spec.DesiredState != spacesv1alpha1.SimulationStateComplete {
+			return false, nil
+		}
+		return sim.Status.GetCondition(spacesv1alpha1.TypeAcceptingChanges).Reason == simulationCompleteReason, nil
+	}, wait.WithImmediate(), wait.WithInterval(time.Second*2), wait.WithContext(ctx)); err != nil {
+		return errors.Wrap(err, "error while waiting for simulation to complete")
+	}
+	return nil
+}
+
+// Complete marks the simulation as complete, so that the results are
+// published without waiting for the completion criteria.
+func Complete(ctx context.Context, cl client.Client, sim *spacesv1alpha1.Simulation) error {
+	return errors.Wrap(setDesiredState(ctx, cl, sim, spacesv1alpha1.SimulationStateComplete), "unable to complete simulation")
+}
+
+// Terminate marks the simulation as terminated.
+func Terminate(ctx context.Context, cl client.Client, sim *spacesv1alpha1.Simulation) error {
+	return errors.Wrap(setDesiredState(ctx, cl, sim, spacesv1alpha1.SimulationStateTerminated), "unable to terminate simulation")
+}
+
+// setDesiredState patches the desired state of the simulation. A merge patch
+// is used since the simulation may have been updated by its controller since
+// it was last read.
+func setDesiredState(ctx context.Context, cl client.Client, sim *spacesv1alpha1.Simulation, state spacesv1alpha1.SimulationState) error {
+	orig := sim.DeepCopy()
+	sim.Spec.DesiredState = state
+	return cl.Patch(ctx, sim, client.MergeFrom(orig))
+}
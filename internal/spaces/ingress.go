@@ -113,7 +113,7 @@ func (c *ingressCache) Get(ctx context.Context, space v1alpha1.Space) (ingress *
 	}
 	if caString, ok := ingressPublic.Data["ingress-ca"]; !ok {
 		return nil, errors.Wrap(err, `"ingress-ca" not found in public ingress configmap`)
-	} else if err = ensureCertificateAuthorityData(caString); err != nil {
+	} else if err = EnsureCertificateAuthorityData(caString); err != nil {
 		return nil, err
 	} else {
 		ingress.CAData = []byte(caString)
@@ -124,7 +124,9 @@ func (c *ingressCache) Get(ctx context.Context, space v1alpha1.Space) (ingress *
 	return ingress, err
 }
 
-func ensureCertificateAuthorityData(tlsCert string) error {
+// EnsureCertificateAuthorityData returns an error if tlsCert is not a PEM
+// encoded x509 certificate.
+func EnsureCertificateAuthorityData(tlsCert string) error {
 	block, _ := pem.Decode([]byte(tlsCert))
 	if block == nil || block.Type != "CERTIFICATE" {
 		return errors.New("CA string does not contain PEM certificate data")
@@ -0,0 +1,245 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokencache caches org-scoped exec credentials on disk, so that the
+// kubectl invocations against a Cloud Space do not each exchange a token with
+// Upbound.
+package tokencache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	clientauthentication "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+
+	"github.com/upbound/up/internal/config"
+)
+
+const (
+	// cacheDir is the directory in the up config directory that credentials
+	// are cached in.
+	cacheDir = "tokens"
+
+	// defaultExpiryDelta is how long before their expiration cached
+	// credentials are refreshed, so that they do not expire in flight.
+	defaultExpiryDelta = time.Minute
+)
+
+const (
+	errCreateDir  = "failed to create token cache directory"
+	errLock       = "failed to lock token cache"
+	errWriteCache = "failed to write token cache"
+	errFmtName    = "invalid token cache name %q"
+)
+
+// FetchFn fetches a new credential when there is no valid cached credential.
+type FetchFn func() (*clientauthentication.ExecCredential, error)
+
+// Cache caches exec credentials on disk per profile and organization. Accesses
+// are serialized with a file lock, so that concurrent up processes fetch a
+// credential only once.
+type Cache struct {
+	dir         string
+	expiryDelta time.Duration
+	now         func() time.Time
+	log         logging.Logger
+}
+
+// Option modifies a cache.
+type Option func(*Cache)
+
+// WithExpiryDelta sets how long before their expiration cached credentials are
+// refreshed.
+func WithExpiryDelta(d time.Duration) Option {
+	return func(c *Cache) {
+		c.expiryDelta = d
+	}
+}
+
+// WithClock sets the function the cache gets the current time from.
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+// WithLogger sets the logger errors of the cache are logged to.
+func WithLogger(l logging.Logger) Option {
+	return func(c *Cache) {
+		c.log = l
+	}
+}
+
+// New returns a cache of credentials in dir.
+func New(dir string, opts ...Option) *Cache {
+	c := &Cache{
+		dir:         dir,
+		expiryDelta: defaultExpiryDelta,
+		now:         time.Now,
+		log:         logging.NewNopLogger(),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// DefaultDir returns the default directory credentials are cached in.
+func DefaultDir() (string, error) {
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, config.ConfigDir, cacheDir), nil
+}
+
+// entry is a cached credential.
+type entry struct {
+	// Session is the digest of the session token the credential was
+	// exchanged for. Credentials of other sessions are not returned, e.g.
+	// after logging in again to another account.
+	Session    string                               `json:"session"`
+	Credential *clientauthentication.ExecCredential `json:"credential"`
+}
+
+// Get returns the cached credential of the organization for the profile and
+// session token, unless it expires soon. Otherwise, it fetches a new credential
+// and caches it. The cache is best effort: if it cannot be used, the error is
+// logged at debug level and the credential is fetched without caching it.
+func (c *Cache) Get(profile, org, session string, fetch FetchFn) (*clientauthentication.ExecCredential, error) {
+	profileName, err := name(profile)
+	if err != nil {
+		c.log.Debug("failed to use token cache", "error", err)
+		return fetch()
+	}
+	orgName, err := name(org)
+	if err != nil {
+		c.log.Debug("failed to use token cache", "error", err)
+		return fetch()
+	}
+	dir := filepath.Join(c.dir, profileName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		c.log.Debug("failed to use token cache", "error", errors.Wrap(err, errCreateDir))
+		return fetch()
+	}
+	path := filepath.Join(dir, orgName+".json")
+
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		c.log.Debug("failed to use token cache", "error", errors.Wrap(err, errLock))
+		return fetch()
+	}
+	defer unlock() //nolint:errcheck // The lock is released when the file is closed.
+
+	sum := sha256.Sum256([]byte(session))
+	digest := hex.EncodeToString(sum[:])
+	if cred := c.read(path, digest); cred != nil {
+		return cred, nil
+	}
+
+	cred, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := write(path, entry{Session: digest, Credential: cred}); err != nil {
+		c.log.Debug("failed to use token cache", "error", errors.Wrap(err, errWriteCache))
+	}
+	return cred, nil
+}
+
+// Clear removes the credentials cached for the profile.
+func (c *Cache) Clear(profile string) error {
+	n, err := name(profile)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(c.dir, n))
+}
+
+// name returns the name of the file or directory of a profile or organization
+// in the cache. Names that would refer to another directory are rejected.
+func name(key string) (string, error) {
+	n := url.PathEscape(key)
+	if n == "" || n == "." || n == ".." || strings.ContainsAny(n, `/\`) {
+		return "", errors.Errorf(errFmtName, key)
+	}
+	return n, nil
+}
+
+// read returns the credential cached at path if it is for the session and does
+// not expire soon. Unreadable caches are ignored, as they are overwritten.
+func (c *Cache) read(path, session string) *clientauthentication.ExecCredential {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil
+	}
+	e := &entry{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil
+	}
+	if e.Session != session || e.Credential == nil || e.Credential.Status == nil || e.Credential.Status.ExpirationTimestamp == nil {
+		return nil
+	}
+	if !c.now().Add(c.expiryDelta).Before(e.Credential.Status.ExpirationTimestamp.Time) {
+		return nil
+	}
+	return e.Credential
+}
+
+// write atomically writes an entry to path, so that readers that do not hold
+// the lock never see a partial entry.
+func write(path string, e entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // Renamed on success.
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// lock exclusively locks the file at path, blocking until it is locked. The
+// returned function unlocks it.
+func lock(path string) (func() error, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() error {
+		defer f.Close() //nolint:errcheck // Closing releases the lock too.
+		return unlockFile(f)
+	}, nil
+}
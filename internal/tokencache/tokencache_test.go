@@ -0,0 +1,255 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokencache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthentication "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+func credential(token string, exp time.Time) *clientauthentication.ExecCredential {
+	t := v1.NewTime(exp)
+	return &clientauthentication.ExecCredential{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "ExecCredential",
+			APIVersion: clientauthentication.SchemeGroupVersion.String(),
+		},
+		Status: &clientauthentication.ExecCredentialStatus{
+			ExpirationTimestamp: &t,
+			Token:               token,
+		},
+	}
+}
+
+func TestGet(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type cached struct {
+		profile string
+		org     string
+		session string
+		cred    *clientauthentication.ExecCredential
+	}
+	type want struct {
+		token   string
+		fetched bool
+		err     error
+	}
+	cases := map[string]struct {
+		reason string
+		cached *cached
+		fetch  FetchFn
+		want   want
+	}{
+		"Miss": {
+			reason: "A credential should be fetched if none is cached.",
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", now.Add(time.Hour)), nil
+			},
+			want: want{token: "new", fetched: true},
+		},
+		"Hit": {
+			reason: "A cached credential should be returned until shortly before it expires.",
+			cached: &cached{profile: "default", org: "acme", session: "session", cred: credential("cached", now.Add(2*time.Minute))},
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return nil, errBoom
+			},
+			want: want{token: "cached"},
+		},
+		"ExpiresSoon": {
+			reason: "A credential should be fetched if the cached credential expires soon.",
+			cached: &cached{profile: "default", org: "acme", session: "session", cred: credential("cached", now.Add(30*time.Second))},
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", now.Add(time.Hour)), nil
+			},
+			want: want{token: "new", fetched: true},
+		},
+		"OtherSession": {
+			reason: "Credentials cached for another session should not be returned.",
+			cached: &cached{profile: "default", org: "acme", session: "other", cred: credential("cached", now.Add(time.Hour))},
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", now.Add(time.Hour)), nil
+			},
+			want: want{token: "new", fetched: true},
+		},
+		"OtherOrganization": {
+			reason: "Credentials cached for another organization should not be returned.",
+			cached: &cached{profile: "default", org: "other", session: "session", cred: credential("cached", now.Add(time.Hour))},
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", now.Add(time.Hour)), nil
+			},
+			want: want{token: "new", fetched: true},
+		},
+		"OtherProfile": {
+			reason: "Credentials cached for another profile should not be returned.",
+			cached: &cached{profile: "other", org: "acme", session: "session", cred: credential("cached", now.Add(time.Hour))},
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", now.Add(time.Hour)), nil
+			},
+			want: want{token: "new", fetched: true},
+		},
+		"FetchError": {
+			reason: "Errors fetching a credential should be returned.",
+			fetch: func() (*clientauthentication.ExecCredential, error) {
+				return nil, errBoom
+			},
+			want: want{fetched: true, err: errBoom},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := New(t.TempDir(), WithClock(func() time.Time { return now }))
+			if tc.cached != nil {
+				if _, err := c.Get(tc.cached.profile, tc.cached.org, tc.cached.session, func() (*clientauthentication.ExecCredential, error) {
+					return tc.cached.cred, nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fetched := false
+			cred, err := c.Get("default", "acme", "session", func() (*clientauthentication.ExecCredential, error) {
+				fetched = true
+				return tc.fetch()
+			})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nGet(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			token := ""
+			if cred != nil {
+				token = cred.Status.Token
+			}
+			if diff := cmp.Diff(tc.want, want{token: token, fetched: fetched, err: tc.want.err}, cmp.AllowUnexported(want{}), test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nGet(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGetConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	var fetches atomic.Int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// NOTE: each caller has its own cache, as separate kubectl
+			// invocations of up would.
+			_, err := New(dir).Get("default", "acme", "session", func() (*clientauthentication.ExecCredential, error) {
+				fetches.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return credential("token", time.Now().Add(time.Hour)), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if diff := cmp.Diff(int32(1), fetches.Load()); diff != "" {
+		t.Errorf("\nConcurrent callers should fetch a credential only once.\nGet(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestGetUnavailable(t *testing.T) {
+	// NOTE: the cache directory is a file, so the cache cannot be used.
+	dir := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason  string
+		profile string
+	}{
+		"Unwritable": {
+			reason:  "A credential should be fetched without caching it if the cache cannot be written.",
+			profile: "default",
+		},
+		"InvalidProfile": {
+			reason:  "A credential should be fetched without caching it for profiles that are not valid cache names.",
+			profile: "..",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cred, err := New(dir).Get(tc.profile, "acme", "session", func() (*clientauthentication.ExecCredential, error) {
+				return credential("new", time.Now().Add(time.Hour)), nil
+			})
+			if err != nil {
+				t.Fatalf("\n%s\nGet(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff("new", cred.Status.Token); diff != "" {
+				t.Errorf("\n%s\nGet(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestClear(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		profile string
+		want    error
+	}{
+		"Profile": {
+			reason:  "The credentials of the profile should be removed.",
+			profile: "default",
+		},
+		"Parent": {
+			reason:  "Profiles that refer to the parent directory should be rejected.",
+			profile: "..",
+			want:    errors.Errorf(errFmtName, ".."),
+		},
+		"Current": {
+			reason:  "Profiles that refer to the cache directory should be rejected.",
+			profile: ".",
+			want:    errors.Errorf(errFmtName, "."),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "tokens")
+			c := New(dir)
+			if _, err := c.Get("default", "acme", "session", func() (*clientauthentication.ExecCredential, error) {
+				return credential("token", time.Now().Add(time.Hour)), nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Clear(tc.profile)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nClear(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("\n%s\nClear(...): the cache directory should not be removed: %v", tc.reason, err)
+			}
+		})
+	}
+}
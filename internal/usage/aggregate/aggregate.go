@@ -23,7 +23,9 @@ import (
 )
 
 const (
-	mrCountUpboundEventName    = "kube_managedresource_uid"
+	// MRCountEventName is the name of the usage events that record the count
+	// of instances of a GVK on an MXP.
+	MRCountEventName           = "kube_managedresource_uid"
 	mrCountMaxUpboundEventName = "max_resource_count_per_gvk_per_mxp"
 )
 
@@ -34,25 +36,26 @@ type mxpGVK struct {
 	Kind    string
 }
 
+var _ Aggregator = &MaxResourceCountPerGVKPerMXP{}
+
 // MaxResourceCountPerGVKPerMXP aggregates the maximum recorded GVK counts per MXP from
 // Upbound usage events.
 type MaxResourceCountPerGVKPerMXP struct {
+	// EventName is the name of the usage events to aggregate. Defaults to
+	// MRCountEventName.
+	EventName string
+
 	counts map[mxpGVK]int
 }
 
 // Add adds a usage event to the aggregate.
 func (ag *MaxResourceCountPerGVKPerMXP) Add(e model.MXPGVKEvent) error {
-	if err := ag.validateEvent(e); err != nil {
+	if err := validateMRCountEvent(e, ag.EventName); err != nil {
 		return err
 	}
 
 	value := int(e.Value)
-	key := mxpGVK{
-		MXPID:   e.Tags.MXPID,
-		Group:   e.Tags.Group,
-		Version: e.Tags.Version,
-		Kind:    e.Tags.Kind,
-	}
+	key := keyOf(e)
 
 	if ag.counts == nil {
 		ag.counts = make(map[mxpGVK]int)
@@ -72,20 +75,20 @@ func (ag *MaxResourceCountPerGVKPerMXP) UpboundEvents() []model.MXPGVKEvent {
 		events = append(events, model.MXPGVKEvent{
 			Name:  mrCountMaxUpboundEventName,
 			Value: float64(count),
-			Tags: model.MXPGVKEventTags{
-				MXPID:   key.MXPID,
-				Group:   key.Group,
-				Version: key.Version,
-				Kind:    key.Kind,
-			},
+			Tags:  key.tags(),
 		})
 	}
 	return events
 }
 
-func (ag *MaxResourceCountPerGVKPerMXP) validateEvent(e model.MXPGVKEvent) error {
-	if e.Name != mrCountUpboundEventName {
-		return fmt.Errorf("expected event name %s, got %s", mrCountUpboundEventName, e.Name)
+// validateMRCountEvent validates a managed resource count usage event with the
+// given name, or MRCountEventName if the name is empty.
+func validateMRCountEvent(e model.MXPGVKEvent, name string) error {
+	if name == "" {
+		name = MRCountEventName
+	}
+	if e.Name != name {
+		return fmt.Errorf("expected event name %s, got %s", name, e.Name)
 	}
 	if e.Tags.MXPID == "" {
 		return errors.New("MXPID tag is empty")
@@ -100,5 +103,22 @@ func (ag *MaxResourceCountPerGVKPerMXP) validateEvent(e model.MXPGVKEvent) error
 		return errors.New("Kind tag is empty")
 	}
 	return nil
+}
 
+func keyOf(e model.MXPGVKEvent) mxpGVK {
+	return mxpGVK{
+		MXPID:   e.Tags.MXPID,
+		Group:   e.Tags.Group,
+		Version: e.Tags.Version,
+		Kind:    e.Tags.Kind,
+	}
+}
+
+func (k mxpGVK) tags() model.MXPGVKEventTags {
+	return model.MXPGVKEventTags{
+		MXPID:   k.MXPID,
+		Group:   k.Group,
+		Version: k.Version,
+		Kind:    k.Kind,
+	}
 }
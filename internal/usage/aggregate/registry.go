@@ -0,0 +1,104 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/upbound/up/internal/usage/model"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+const (
+	// MaxResourceCountPerGVKPerMXPName is the name of the
+	// MaxResourceCountPerGVKPerMXP aggregator.
+	MaxResourceCountPerGVKPerMXPName = mrCountMaxUpboundEventName
+	// AverageResourceCountPerGVKPerMXPName is the name of the
+	// AverageResourceCountPerGVKPerMXP aggregator.
+	AverageResourceCountPerGVKPerMXPName = mrCountAverageUpboundEventName
+	// ResourceHoursPerGVKPerMXPName is the name of the
+	// ResourceHoursPerGVKPerMXP aggregator.
+	ResourceHoursPerGVKPerMXPName = mrHoursUpboundEventName
+	// MaxResourceCountPerAccountName is the name of the
+	// MaxResourceCountPerAccount aggregator.
+	MaxResourceCountPerAccountName = mrCountMaxPerAccountUpboundEventName
+)
+
+// Aggregator aggregates Upbound usage events across a window of time.
+type Aggregator interface {
+	// Add adds a usage event to the aggregate.
+	Add(e model.MXPGVKEvent) error
+	// UpboundEvents returns the aggregated usage events.
+	UpboundEvents() []model.MXPGVKEvent
+}
+
+// NewAggregatorFn returns a new Aggregator for the usage events with the given
+// name in a window of time.
+type NewAggregatorFn func(window usagetime.Range, eventName string) Aggregator
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]NewAggregatorFn{
+		MaxResourceCountPerGVKPerMXPName: func(_ usagetime.Range, eventName string) Aggregator {
+			return &MaxResourceCountPerGVKPerMXP{EventName: eventName}
+		},
+		AverageResourceCountPerGVKPerMXPName: func(_ usagetime.Range, eventName string) Aggregator {
+			return &AverageResourceCountPerGVKPerMXP{EventName: eventName}
+		},
+		ResourceHoursPerGVKPerMXPName: func(window usagetime.Range, eventName string) Aggregator {
+			return &ResourceHoursPerGVKPerMXP{Window: window, EventName: eventName}
+		},
+		MaxResourceCountPerAccountName: func(_ usagetime.Range, eventName string) Aggregator {
+			return &MaxResourceCountPerAccount{EventName: eventName}
+		},
+	}
+)
+
+// Register registers an Aggregator by name. It panics if an Aggregator with
+// the same name is already registered.
+func Register(name string, fn NewAggregatorFn) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("aggregator %q is already registered", name))
+	}
+	registry[name] = fn
+}
+
+// New returns a new instance of the named Aggregator for the usage events with
+// the given name in a window of time.
+func New(name, eventName string, window usagetime.Range) (Aggregator, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregator %q", name)
+	}
+	return fn(window, eventName), nil
+}
+
+// Names returns the sorted names of the registered Aggregators.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
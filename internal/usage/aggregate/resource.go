@@ -0,0 +1,145 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregate
+
+import (
+	"github.com/upbound/up/internal/usage/model"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+const (
+	mrCountAverageUpboundEventName       = "average_resource_count_per_gvk_per_mxp"
+	mrHoursUpboundEventName              = "resource_hours_per_gvk_per_mxp"
+	mrCountMaxPerAccountUpboundEventName = "max_resource_count_per_account"
+)
+
+var _ Aggregator = &AverageResourceCountPerGVKPerMXP{}
+
+// AverageResourceCountPerGVKPerMXP aggregates the average recorded GVK counts
+// per MXP from Upbound usage events.
+type AverageResourceCountPerGVKPerMXP struct {
+	// EventName is the name of the usage events to aggregate. Defaults to
+	// MRCountEventName.
+	EventName string
+
+	sums    map[mxpGVK]float64
+	samples map[mxpGVK]int
+}
+
+// Add adds a usage event to the aggregate.
+func (ag *AverageResourceCountPerGVKPerMXP) Add(e model.MXPGVKEvent) error {
+	if err := validateMRCountEvent(e, ag.EventName); err != nil {
+		return err
+	}
+	if ag.sums == nil {
+		ag.sums = make(map[mxpGVK]float64)
+		ag.samples = make(map[mxpGVK]int)
+	}
+	key := keyOf(e)
+	ag.sums[key] += max(e.Value, 0)
+	ag.samples[key]++
+	return nil
+}
+
+// UpboundEvents returns an Upbound usage event for each combination of MXP and
+// GVK.
+func (ag *AverageResourceCountPerGVKPerMXP) UpboundEvents() []model.MXPGVKEvent {
+	events := []model.MXPGVKEvent{}
+	for key, avg := range ag.averages() {
+		events = append(events, model.MXPGVKEvent{
+			Name:  mrCountAverageUpboundEventName,
+			Value: avg,
+			Tags:  key.tags(),
+		})
+	}
+	return events
+}
+
+func (ag *AverageResourceCountPerGVKPerMXP) averages() map[mxpGVK]float64 {
+	avgs := make(map[mxpGVK]float64, len(ag.sums))
+	for key, sum := range ag.sums {
+		avgs[key] = sum / float64(ag.samples[key])
+	}
+	return avgs
+}
+
+var _ Aggregator = &ResourceHoursPerGVKPerMXP{}
+
+// ResourceHoursPerGVKPerMXP aggregates the resource-hours of each GVK per MXP
+// from Upbound usage events, i.e. the average recorded count multiplied by the
+// length of the window in hours.
+type ResourceHoursPerGVKPerMXP struct {
+	Window usagetime.Range
+	// EventName is the name of the usage events to aggregate. Defaults to
+	// MRCountEventName.
+	EventName string
+
+	avg AverageResourceCountPerGVKPerMXP
+}
+
+// Add adds a usage event to the aggregate.
+func (ag *ResourceHoursPerGVKPerMXP) Add(e model.MXPGVKEvent) error {
+	ag.avg.EventName = ag.EventName
+	return ag.avg.Add(e)
+}
+
+// UpboundEvents returns an Upbound usage event for each combination of MXP and
+// GVK.
+func (ag *ResourceHoursPerGVKPerMXP) UpboundEvents() []model.MXPGVKEvent {
+	hours := ag.Window.End.Sub(ag.Window.Start).Hours()
+	events := []model.MXPGVKEvent{}
+	for key, avg := range ag.avg.averages() {
+		events = append(events, model.MXPGVKEvent{
+			Name:  mrHoursUpboundEventName,
+			Value: avg * hours,
+			Tags:  key.tags(),
+		})
+	}
+	return events
+}
+
+var _ Aggregator = &MaxResourceCountPerAccount{}
+
+// MaxResourceCountPerAccount aggregates the total of the maximum recorded GVK
+// counts of all MXPs from Upbound usage events.
+type MaxResourceCountPerAccount struct {
+	// EventName is the name of the usage events to aggregate. Defaults to
+	// MRCountEventName.
+	EventName string
+
+	max MaxResourceCountPerGVKPerMXP
+}
+
+// Add adds a usage event to the aggregate.
+func (ag *MaxResourceCountPerAccount) Add(e model.MXPGVKEvent) error {
+	ag.max.EventName = ag.EventName
+	return ag.max.Add(e)
+}
+
+// UpboundEvents returns a single Upbound usage event with the total count. The
+// account tag is left to the report writer.
+func (ag *MaxResourceCountPerAccount) UpboundEvents() []model.MXPGVKEvent {
+	if len(ag.max.counts) == 0 {
+		return []model.MXPGVKEvent{}
+	}
+	total := 0
+	for _, count := range ag.max.counts {
+		total += count
+	}
+	return []model.MXPGVKEvent{{
+		Name:  mrCountMaxPerAccountUpboundEventName,
+		Value: float64(total),
+	}}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/usage/model"
+	usagetesting "github.com/upbound/up/internal/usage/testing"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+func TestAggregatorUpboundEvents(t *testing.T) {
+	thing := func(mxp string, v float64) model.MXPGVKEvent {
+		return model.MXPGVKEvent{
+			Name:  "kube_managedresource_uid",
+			Value: v,
+			Tags: model.MXPGVKEventTags{
+				MXPID:   mxp,
+				Group:   "example.com",
+				Version: "v1",
+				Kind:    "Thing",
+			},
+		}
+	}
+	aggregated := func(name, mxp string, v float64) model.MXPGVKEvent {
+		e := thing(mxp, v)
+		e.Name = name
+		return e
+	}
+	events := []model.MXPGVKEvent{
+		thing("mxp1", 2),
+		thing("mxp1", 4),
+		thing("mxp1", 6),
+		thing("mxp2", 1),
+	}
+	window := usagetime.Range{
+		Start: time.Date(2006, 5, 4, 3, 0, 0, 0, time.UTC),
+		End:   time.Date(2006, 5, 4, 5, 0, 0, 0, time.UTC),
+	}
+
+	renamed := func(name string, es []model.MXPGVKEvent) []model.MXPGVKEvent {
+		out := make([]model.MXPGVKEvent, len(es))
+		for i, e := range es {
+			e.Name = name
+			out[i] = e
+		}
+		return out
+	}
+
+	type args struct {
+		aggregator string
+		eventName  string
+		events     []model.MXPGVKEvent
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []model.MXPGVKEvent
+	}{
+		"Average": {
+			reason: "The average of the added values should be emitted for each MXP and GVK.",
+			args: args{
+				aggregator: AverageResourceCountPerGVKPerMXPName,
+				events:     events,
+			},
+			want: []model.MXPGVKEvent{
+				aggregated("average_resource_count_per_gvk_per_mxp", "mxp1", 4),
+				aggregated("average_resource_count_per_gvk_per_mxp", "mxp2", 1),
+			},
+		},
+		"ResourceHours": {
+			reason: "The average of the added values times the hours in the window should be emitted for each MXP and GVK.",
+			args: args{
+				aggregator: ResourceHoursPerGVKPerMXPName,
+				events:     events,
+			},
+			want: []model.MXPGVKEvent{
+				aggregated("resource_hours_per_gvk_per_mxp", "mxp1", 8),
+				aggregated("resource_hours_per_gvk_per_mxp", "mxp2", 2),
+			},
+		},
+		"MaxPerAccount": {
+			reason: "The total of the largest added values of all MXPs and GVKs should be emitted.",
+			args: args{
+				aggregator: MaxResourceCountPerAccountName,
+				events:     events,
+			},
+			want: []model.MXPGVKEvent{
+				{Name: "max_resource_count_per_account", Value: 7},
+			},
+		},
+		"EventName": {
+			reason: "Usage events with the given name should be aggregated.",
+			args: args{
+				aggregator: ResourceHoursPerGVKPerMXPName,
+				eventName:  "kube_composite_uid",
+				events:     renamed("kube_composite_uid", events),
+			},
+			want: []model.MXPGVKEvent{
+				aggregated("resource_hours_per_gvk_per_mxp", "mxp1", 8),
+				aggregated("resource_hours_per_gvk_per_mxp", "mxp2", 2),
+			},
+		},
+		"MaxPerAccountNoEvents": {
+			reason: "There should be no events emitted if none were added.",
+			args: args{
+				aggregator: MaxResourceCountPerAccountName,
+			},
+			want: []model.MXPGVKEvent{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ag, err := New(tc.args.aggregator, tc.args.eventName, window)
+			if err != nil {
+				t.Fatalf("\n%s\nNew(...): unexpected error: %v", tc.reason, err)
+			}
+			for i, e := range tc.args.events {
+				if err := ag.Add(e); err != nil {
+					t.Fatalf("\n%s\nAdd(...): error adding event %d: %v", tc.reason, i, err)
+				}
+			}
+
+			got := ag.UpboundEvents()
+
+			// Sort for stability.
+			usagetesting.SortEvents(got)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nUpboundEvents(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("unknown", MRCountEventName, usagetime.Range{}); err == nil {
+		t.Errorf("New(...): expected error for unknown aggregator")
+	}
+	want := []string{
+		"average_resource_count_per_gvk_per_mxp",
+		"max_resource_count_per_account",
+		"max_resource_count_per_gvk_per_mxp",
+		"resource_hours_per_gvk_per_mxp",
+	}
+	if diff := cmp.Diff(want, Names()); diff != "" {
+		t.Errorf("Names(): -want, +got:\n%s", diff)
+	}
+}
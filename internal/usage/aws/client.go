@@ -0,0 +1,63 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// ClientOptions configure an S3 client.
+type ClientOptions struct {
+	// Endpoint is a custom S3 endpoint. The default AWS endpoint is used if
+	// empty.
+	Endpoint string
+	// Region is the region of the bucket. The region of the environment is
+	// used if empty.
+	Region string
+	// PathStyle enables path-style addressing, i.e. the bucket is part of the
+	// path instead of the host name. Most S3-compatible storage such as MinIO
+	// requires path-style addressing.
+	PathStyle bool
+	// CABundle is a PEM encoded CA bundle used to verify the endpoint, e.g.
+	// for S3-compatible storage with a private CA.
+	CABundle io.Reader
+}
+
+// NewClient returns an S3 client configured by opts. The session is configured
+// by the environment, e.g. AWS_REGION and AWS_ACCESS_KEY_ID.
+func NewClient(opts ClientOptions) (*s3.S3, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		CustomCABundle: opts.CABundle,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating aws session")
+	}
+	config := &aws.Config{}
+	if opts.Endpoint != "" {
+		config.Endpoint = aws.String(opts.Endpoint)
+	}
+	if opts.Region != "" {
+		config.Region = aws.String(opts.Region)
+	}
+	if opts.PathStyle {
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+	return s3.New(sess, config), nil
+}
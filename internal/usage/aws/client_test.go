@@ -0,0 +1,115 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/usage/model"
+	"github.com/upbound/up/internal/usage/report"
+	usagetesting "github.com/upbound/up/internal/usage/testing"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+// s3CompatibleHandler serves objects from a bucket the way path-style
+// S3-compatible storage such as MinIO does.
+func s3CompatibleHandler(bucket string, objects map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+bucket && r.URL.Query().Get("list-type") == "2" {
+			prefix := r.URL.Query().Get("prefix")
+			keys := []string{}
+			for k := range objects {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			b := &strings.Builder{}
+			fmt.Fprintf(b, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>%s</Name><Prefix>%s</Prefix><KeyCount>%d</KeyCount><IsTruncated>false</IsTruncated>`, bucket, prefix, len(keys))
+			for _, k := range keys {
+				fmt.Fprintf(b, "<Contents><Key>%s</Key></Contents>", k)
+			}
+			b.WriteString("</ListBucketResult>")
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(b.String()))
+			return
+		}
+		if o, ok := objects[strings.TrimPrefix(r.URL.Path, "/"+bucket+"/")]; ok && strings.HasPrefix(r.URL.Path, "/"+bucket+"/") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(o))
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+func TestNewClientS3Compatible(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "minio")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minio123")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	srv := httptest.NewTLSServer(s3CompatibleHandler("usage", map[string]string{
+		"account=test-account/date=2006-05-04/hour=03/events.json": `[
+{"name":"kube_managedresource_uid","value":3,"tags":{"customresource_group":"example.com","customresource_version":"v1","customresource_kind":"Thing","mxp_id":"mxp1"}}
+]`,
+	}))
+	defer srv.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	window := usagetime.Range{
+		Start: time.Date(2006, 5, 4, 3, 0, 0, 0, time.UTC),
+		End:   time.Date(2006, 5, 4, 4, 0, 0, 0, time.UTC),
+	}
+	want := []model.MXPGVKEvent{{
+		Name:         "max_resource_count_per_gvk_per_mxp",
+		Value:        3,
+		Timestamp:    window.Start,
+		TimestampEnd: window.End,
+		Tags: model.MXPGVKEventTags{
+			Group:   "example.com",
+			Version: "v1",
+			Kind:    "Thing",
+			MXPID:   "mxp1",
+		},
+	}}
+
+	cli, err := NewClient(ClientOptions{Endpoint: srv.URL, PathStyle: true, CABundle: bytes.NewReader(ca)})
+	if err != nil {
+		t.Fatalf("NewClient(...): unexpected error: %v", err)
+	}
+	iter, err := NewWindowIterator(cli, "usage", "test-account", window, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWindowIterator(...): unexpected error: %v", err)
+	}
+	w := &usagetesting.MockWriter{}
+	if err := report.MaxResourceCountPerGVKPerMXP(context.Background(), iter, w); err != nil {
+		t.Fatalf("MaxResourceCountPerGVKPerMXP(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, w.Events); diff != "" {
+		t.Errorf("MaxResourceCountPerGVKPerMXP(...): -want, +got:\n%s", diff)
+	}
+}
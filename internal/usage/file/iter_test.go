@@ -0,0 +1,226 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/usage/encoding/json"
+	"github.com/upbound/up/internal/usage/model"
+	"github.com/upbound/up/internal/usage/report"
+	usagetesting "github.com/upbound/up/internal/usage/testing"
+	usagetime "github.com/upbound/up/internal/usage/time"
+)
+
+func TestDirIterator(t *testing.T) {
+	type iteration struct {
+		Dirs   []string
+		Window usagetime.Range
+	}
+	cases := map[string]struct {
+		reason string
+		tr     usagetime.Range
+		window time.Duration
+		want   []iteration
+	}{
+		"3HourRange2HourWindow": {
+			reason: "3h range divided into 2h windows.",
+			tr: usagetime.Range{
+				Start: time.Date(2006, 5, 4, 3, 0, 0, 0, time.UTC),
+				End:   time.Date(2006, 5, 4, 6, 0, 0, 0, time.UTC),
+			},
+			window: 2 * time.Hour,
+			want: []iteration{
+				{
+					Dirs: []string{
+						"usage/account=test-account/date=2006-05-04/hour=03",
+						"usage/account=test-account/date=2006-05-04/hour=04",
+					},
+					Window: usagetime.Range{
+						Start: time.Date(2006, 5, 4, 3, 0, 0, 0, time.UTC),
+						End:   time.Date(2006, 5, 4, 5, 0, 0, 0, time.UTC),
+					},
+				},
+				{
+					Dirs: []string{
+						"usage/account=test-account/date=2006-05-04/hour=05",
+					},
+					Window: usagetime.Range{
+						Start: time.Date(2006, 5, 4, 5, 0, 0, 0, time.UTC),
+						End:   time.Date(2006, 5, 4, 6, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			iter, err := NewDirIterator("usage", "test-account", tc.tr, tc.window)
+			if err != nil {
+				t.Fatalf("\n%s\nNewDirIterator(...): unexpected error: %v", tc.reason, err)
+			}
+			got := []iteration{}
+			for iter.More() {
+				dirs, window, err := iter.Next()
+				if err != nil {
+					t.Fatalf("\n%s\nNext(): unexpected error: %v", tc.reason, err)
+				}
+				got = append(got, iteration{Dirs: dirs, Window: window})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nNext(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWindowIterator(t *testing.T) {
+	thing := func(mxp string, v float64) model.MXPGVKEvent {
+		return model.MXPGVKEvent{
+			Name:  "kube_managedresource_uid",
+			Value: v,
+			Tags: model.MXPGVKEventTags{
+				Group:   "example.com",
+				Version: "v1",
+				Kind:    "Thing",
+				MXPID:   mxp,
+			},
+		}
+	}
+	hour03 := usagetime.Range{
+		Start: time.Date(2006, 5, 4, 3, 0, 0, 0, time.UTC),
+		End:   time.Date(2006, 5, 4, 4, 0, 0, 0, time.UTC),
+	}
+	hour04 := usagetime.Range{
+		Start: time.Date(2006, 5, 4, 4, 0, 0, 0, time.UTC),
+		End:   time.Date(2006, 5, 4, 5, 0, 0, 0, time.UTC),
+	}
+	aggregated := func(mxp string, v float64, window usagetime.Range) model.MXPGVKEvent {
+		e := thing(mxp, v)
+		e.Name = "max_resource_count_per_gvk_per_mxp"
+		e.Timestamp = window.Start
+		e.TimestampEnd = window.End
+		return e
+	}
+
+	type object struct {
+		path   string
+		gzip   bool
+		events []model.MXPGVKEvent
+	}
+	cases := map[string]struct {
+		reason  string
+		objects []object
+		want    []model.MXPGVKEvent
+	}{
+		"NoObjects": {
+			reason: "Missing hour directories have no events.",
+		},
+		"PlainAndGzippedObjects": {
+			reason: "Events are read from plain and gzipped objects and aggregated per window.",
+			objects: []object{
+				{
+					path:   "account=test-account/date=2006-05-04/hour=03/mxp1.json",
+					events: []model.MXPGVKEvent{thing("mxp1", 4), thing("mxp1", 7)},
+				},
+				{
+					path:   "account=test-account/date=2006-05-04/hour=03/mxp2.json.gz",
+					gzip:   true,
+					events: []model.MXPGVKEvent{thing("mxp2", 2)},
+				},
+				{
+					path:   "account=test-account/date=2006-05-04/hour=04/mxp1.json.gz",
+					gzip:   true,
+					events: []model.MXPGVKEvent{thing("mxp1", 5)},
+				},
+				{
+					path:   "account=other-account/date=2006-05-04/hour=04/mxp3.json",
+					events: []model.MXPGVKEvent{thing("mxp3", 1)},
+				},
+			},
+			want: []model.MXPGVKEvent{
+				aggregated("mxp1", 5, hour04),
+				aggregated("mxp1", 7, hour03),
+				aggregated("mxp2", 2, hour03),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, o := range tc.objects {
+				writeObject(t, filepath.Join(dir, o.path), o.gzip, o.events)
+			}
+
+			iter, err := NewWindowIterator(dir, "test-account", usagetime.Range{Start: hour03.Start, End: hour04.End}, time.Hour)
+			if err != nil {
+				t.Fatalf("\n%s\nNewWindowIterator(...): unexpected error: %v", tc.reason, err)
+			}
+			w := &usagetesting.MockWriter{}
+			if err := report.MaxResourceCountPerGVKPerMXP(context.Background(), iter, w); err != nil {
+				t.Fatalf("\n%s\nMaxResourceCountPerGVKPerMXP(...): unexpected error: %v", tc.reason, err)
+			}
+			usagetesting.SortEvents(w.Events)
+			if diff := cmp.Diff(tc.want, w.Events); diff != "" {
+				t.Errorf("\n%s\nMaxResourceCountPerGVKPerMXP(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func writeObject(t *testing.T, path string, gz bool, events []model.MXPGVKEvent) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path) //nolint:gosec // Test file.
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // Closed explicitly below.
+
+	var w io.WriteCloser = f
+	if gz {
+		w = gzip.NewWriter(f)
+	}
+	enc, err := json.NewMXPGVKEventEncoder(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
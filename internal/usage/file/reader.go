@@ -0,0 +1,122 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/upbound/up/internal/usage/encoding/json"
+	"github.com/upbound/up/internal/usage/event"
+	"github.com/upbound/up/internal/usage/event/reader"
+	"github.com/upbound/up/internal/usage/model"
+)
+
+var ErrEOF = event.ErrEOF
+
+// gzipMagic are the first bytes of a gzip file. Local files have no content
+// type, so gzipped objects are detected by their contents.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var _ event.Reader = &DirEventReader{}
+
+// DirEventReader reads usage events from the files in a directory. A missing
+// directory has no events.
+type DirEventReader struct {
+	Dir    string
+	reader *reader.MultiReader
+}
+
+func (r *DirEventReader) Read(ctx context.Context) (model.MXPGVKEvent, error) {
+	if r.reader == nil {
+		entries, err := os.ReadDir(r.Dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return model.MXPGVKEvent{}, err
+		}
+		readers := []event.Reader{}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			readers = append(readers, &FileEventReader{Path: filepath.Join(r.Dir, e.Name())})
+		}
+		r.reader = &reader.MultiReader{Readers: readers}
+	}
+	return r.reader.Read(ctx)
+}
+
+func (r *DirEventReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
+
+var _ event.Reader = &FileEventReader{}
+
+// FileEventReader reads usage events from a file.
+type FileEventReader struct {
+	Path    string
+	decoder *json.MXPGVKEventDecoder
+	closers []io.Closer
+}
+
+func (r *FileEventReader) Read(_ context.Context) (model.MXPGVKEvent, error) {
+	if r.decoder == nil {
+		f, err := os.Open(r.Path)
+		if err != nil {
+			return model.MXPGVKEvent{}, err
+		}
+		r.closers = append(r.closers, f)
+
+		br := bufio.NewReader(f)
+		var body io.Reader = br
+		if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return model.MXPGVKEvent{}, err
+			}
+			r.closers = append(r.closers, gz)
+			body = gz
+		}
+
+		decoder, err := json.NewMXPGVKEventDecoder(body)
+		if err != nil {
+			return model.MXPGVKEvent{}, err
+		}
+		r.decoder = decoder
+	}
+	if !r.decoder.More() {
+		return model.MXPGVKEvent{}, ErrEOF
+	}
+	return r.decoder.Decode()
+}
+
+func (r *FileEventReader) Close() error {
+	// Close closers in reverse.
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
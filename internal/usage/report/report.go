@@ -35,6 +35,9 @@ type Meta struct {
 	UpboundAccount string          `json:"account"`
 	TimeRange      usagetime.Range `json:"time_range"`
 	CollectedAt    time.Time       `json:"collected_at"`
+	// Aggregations are the names of the aggregators whose events are in the
+	// report.
+	Aggregations []string `json:"aggregations,omitempty"`
 }
 
 // MaxResourceCountPerGVKPerMXP reads events from i and writes aggregated events
@@ -42,13 +45,25 @@ type Meta struct {
 // aggregated event records the largest observed count of instances of a GVK on
 // an MXP during a window. The order of written events is not stable.
 func MaxResourceCountPerGVKPerMXP(ctx context.Context, i event.WindowIterator, w event.Writer) error {
+	return Aggregate(ctx, i, w, aggregate.MaxResourceCountPerGVKPerMXPName)
+}
+
+// Aggregate reads events from i and writes the events aggregated by each of the
+// named aggregators to w. Events are aggregated across each window of time
+// returned by i. The order of written events is not stable.
+func Aggregate(ctx context.Context, i event.WindowIterator, w event.Writer, aggregators ...string) error {
 	for i.More() {
 		r, window, err := i.Next()
 		if err != nil {
 			return errors.Wrap(err, errReadEvents)
 		}
 
-		ag := &aggregate.MaxResourceCountPerGVKPerMXP{}
+		ags := make([]aggregate.Aggregator, len(aggregators))
+		for j, name := range aggregators {
+			if ags[j], err = aggregate.New(name, aggregate.MRCountEventName, window); err != nil {
+				return err
+			}
+		}
 		for {
 			e, err := r.Read(ctx)
 			if errors.Is(err, event.ErrEOF) {
@@ -57,19 +72,23 @@ func MaxResourceCountPerGVKPerMXP(ctx context.Context, i event.WindowIterator, w
 			if err != nil {
 				return err
 			}
-			if err := ag.Add(e); err != nil {
-				return err
+			for _, ag := range ags {
+				if err := ag.Add(e); err != nil {
+					return err
+				}
 			}
 		}
 		if err := r.Close(); err != nil {
 			return errors.Wrap(err, errReadEvents)
 		}
 
-		for _, e := range ag.UpboundEvents() {
-			e.Timestamp = window.Start
-			e.TimestampEnd = window.End
-			if err := w.Write(e); err != nil {
-				return errors.Wrap(err, errWriteEvents)
+		for _, ag := range ags {
+			for _, e := range ag.UpboundEvents() {
+				e.Timestamp = window.Start
+				e.TimestampEnd = window.End
+				if err := w.Write(e); err != nil {
+					return errors.Wrap(err, errWriteEvents)
+				}
 			}
 		}
 	}
@@ -395,3 +395,89 @@ func TestMaxResourceCountPerGVKPerMXP(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregate(t *testing.T) {
+	window := usagetime.Range{
+		Start: time.Date(2006, 05, 04, 03, 0, 0, 0, time.UTC),
+		End:   time.Date(2006, 05, 04, 05, 0, 0, 0, time.UTC),
+	}
+	thing := func(name string, v float64) model.MXPGVKEvent {
+		return model.MXPGVKEvent{
+			Name:  name,
+			Value: v,
+			Tags: model.MXPGVKEventTags{
+				Group:   "example.com",
+				Version: "v1",
+				Kind:    "Thing",
+				MXPID:   "mxp1",
+			},
+		}
+	}
+	aggregated := func(name string, v float64) model.MXPGVKEvent {
+		e := thing(name, v)
+		e.Timestamp = window.Start
+		e.TimestampEnd = window.End
+		return e
+	}
+
+	type args struct {
+		iter        *usagetesting.MockWindowIterator
+		aggregators []string
+	}
+	type want struct {
+		events []model.MXPGVKEvent
+		err    error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"UnknownAggregator": {
+			reason: "Unknown aggregators should return an error.",
+			args: args{
+				iter: &usagetesting.MockWindowIterator{Windows: []usagetesting.Window{
+					{Reader: &usagetesting.MockReader{}, Window: window},
+				}},
+				aggregators: []string{"unknown"},
+			},
+			want: want{
+				err: fmt.Errorf(`unknown aggregator "unknown"`),
+			},
+		},
+		"MultipleAggregators": {
+			reason: "The events of each aggregator should be written for each window.",
+			args: args{
+				iter: &usagetesting.MockWindowIterator{Windows: []usagetesting.Window{
+					{
+						Reader: &usagetesting.MockReader{Reads: []usagetesting.ReadResult{
+							{Event: thing("kube_managedresource_uid", 2)},
+							{Event: thing("kube_managedresource_uid", 4)},
+						}},
+						Window: window,
+					},
+				}},
+				aggregators: []string{"max_resource_count_per_gvk_per_mxp", "resource_hours_per_gvk_per_mxp"},
+			},
+			want: want{
+				events: []model.MXPGVKEvent{
+					aggregated("max_resource_count_per_gvk_per_mxp", 4),
+					aggregated("resource_hours_per_gvk_per_mxp", 6),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := &usagetesting.MockWriter{}
+			err := Aggregate(context.Background(), tc.args.iter, w, tc.args.aggregators...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAggregate(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			usagetesting.SortEvents(w.Events)
+			if diff := cmp.Diff(tc.want.events, w.Events); diff != "" {
+				t.Errorf("\n%s\nAggregate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
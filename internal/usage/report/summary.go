@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/upbound/up/internal/usage/model"
+)
+
+// Summary contains the peak managed resource counts of a usage report.
+type Summary struct {
+	// Total is the peak managed resource count across all control planes.
+	Total int `json:"total"`
+	// ControlPlanes is the peak managed resource count per control plane.
+	ControlPlanes map[string]int `json:"control_planes"`
+	// GVKs is the peak managed resource count per GVK across all control
+	// planes. GVKs are formatted as kind.version.group.
+	GVKs map[string]int `json:"gvks"`
+	// APIGroups is the peak managed resource count per API group across all
+	// control planes.
+	APIGroups map[string]int `json:"api_groups"`
+}
+
+// SummaryWriter is an event.Writer that summarizes the aggregated events
+// written by MaxResourceCountPerGVKPerMXP. Counts are summed per window, and
+// the peak of each sum across all windows is recorded.
+type SummaryWriter struct {
+	windows map[time.Time]*windowCounts
+}
+
+type windowCounts struct {
+	total         int
+	controlPlanes map[string]int
+	gvks          map[string]int
+	apiGroups     map[string]int
+}
+
+// NewSummaryWriter returns a new SummaryWriter.
+func NewSummaryWriter() *SummaryWriter {
+	return &SummaryWriter{windows: map[time.Time]*windowCounts{}}
+}
+
+// Write adds an aggregated event to the summary.
+func (w *SummaryWriter) Write(e model.MXPGVKEvent) error {
+	wc, ok := w.windows[e.Timestamp]
+	if !ok {
+		wc = &windowCounts{
+			controlPlanes: map[string]int{},
+			gvks:          map[string]int{},
+			apiGroups:     map[string]int{},
+		}
+		w.windows[e.Timestamp] = wc
+	}
+	count := int(e.Value)
+	wc.total += count
+	wc.controlPlanes[e.Tags.MXPID] += count
+	wc.gvks[fmt.Sprintf("%s.%s.%s", e.Tags.Kind, e.Tags.Version, e.Tags.Group)] += count
+	wc.apiGroups[e.Tags.Group] += count
+	return nil
+}
+
+// Summary returns the peak counts of all events written so far.
+func (w *SummaryWriter) Summary() Summary {
+	s := Summary{
+		ControlPlanes: map[string]int{},
+		GVKs:          map[string]int{},
+		APIGroups:     map[string]int{},
+	}
+	for _, wc := range w.windows {
+		s.Total = max(s.Total, wc.total)
+		maxInto(s.ControlPlanes, wc.controlPlanes)
+		maxInto(s.GVKs, wc.gvks)
+		maxInto(s.APIGroups, wc.apiGroups)
+	}
+	return s
+}
+
+func maxInto(peaks, counts map[string]int) {
+	for k, v := range counts {
+		peaks[k] = max(peaks[k], v)
+	}
+}
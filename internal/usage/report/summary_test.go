@@ -0,0 +1,97 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/upbound/up/internal/usage/model"
+)
+
+func TestSummaryWriter(t *testing.T) {
+	hour1 := time.Date(2006, 05, 04, 03, 0, 0, 0, time.UTC)
+	hour2 := time.Date(2006, 05, 04, 04, 0, 0, 0, time.UTC)
+	event := func(ts time.Time, mxp, group, kind string, v float64) model.MXPGVKEvent {
+		return model.MXPGVKEvent{
+			Name:      "max_resource_count_per_gvk_per_mxp",
+			Timestamp: ts,
+			Value:     v,
+			Tags: model.MXPGVKEventTags{
+				Group:   group,
+				Version: "v1",
+				Kind:    kind,
+				MXPID:   mxp,
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		events []model.MXPGVKEvent
+		want   Summary
+	}{
+		"NoEvents": {
+			reason: "A summary of no events has no counts.",
+			want: Summary{
+				ControlPlanes: map[string]int{},
+				GVKs:          map[string]int{},
+				APIGroups:     map[string]int{},
+			},
+		},
+		"PeaksAcrossWindows": {
+			reason: "Counts are summed per window and the peak of each sum is recorded.",
+			events: []model.MXPGVKEvent{
+				event(hour1, "mxp1", "s3.aws.upbound.io", "Bucket", 3),
+				event(hour1, "mxp1", "ec2.aws.upbound.io", "VPC", 2),
+				event(hour1, "mxp2", "s3.aws.upbound.io", "Bucket", 1),
+				event(hour2, "mxp1", "s3.aws.upbound.io", "Bucket", 1),
+				event(hour2, "mxp2", "s3.aws.upbound.io", "Bucket", 4),
+				event(hour2, "mxp2", "ec2.aws.upbound.io", "Subnet", 2),
+			},
+			want: Summary{
+				Total: 7,
+				ControlPlanes: map[string]int{
+					"mxp1": 5,
+					"mxp2": 6,
+				},
+				GVKs: map[string]int{
+					"Bucket.v1.s3.aws.upbound.io":  5,
+					"VPC.v1.ec2.aws.upbound.io":    2,
+					"Subnet.v1.ec2.aws.upbound.io": 2,
+				},
+				APIGroups: map[string]int{
+					"s3.aws.upbound.io":  5,
+					"ec2.aws.upbound.io": 2,
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := NewSummaryWriter()
+			for _, e := range tc.events {
+				if err := w.Write(e); err != nil {
+					t.Fatalf("\n%s\nWrite(...): unexpected error: %v", tc.reason, err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, w.Summary()); diff != "" {
+				t.Errorf("\n%s\nSummary(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
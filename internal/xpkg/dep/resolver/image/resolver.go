@@ -26,6 +26,8 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/upbound/up/internal/signature"
 )
 
 const (
@@ -43,7 +45,8 @@ const (
 
 // Resolver --
 type Resolver struct {
-	f Fetcher
+	f        Fetcher
+	verifier *signature.Verifier
 }
 
 // Fetcher defines how we expect to intract with the Image repository.
@@ -62,6 +65,9 @@ func NewResolver(opts ...ResolverOption) *Resolver {
 	for _, o := range opts {
 		o(r)
 	}
+	if r.verifier != nil {
+		r.f = NewVerifyingFetcher(r.f, r.verifier)
+	}
 	return r
 }
 
@@ -75,6 +81,14 @@ func WithFetcher(f Fetcher) ResolverOption {
 	}
 }
 
+// WithVerifier modifies the Resolver to verify the signatures of the images
+// it fetches.
+func WithVerifier(v *signature.Verifier) ResolverOption {
+	return func(r *Resolver) {
+		r.verifier = v
+	}
+}
+
 // ResolveImage resolves the image corresponding to the given v1beta1.Dependency.
 func (r *Resolver) ResolveImage(ctx context.Context, dep v1beta1.Dependency) (string, v1.Image, error) {
 
@@ -0,0 +1,53 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/upbound/up/internal/signature"
+)
+
+// VerifyingFetcher is a Fetcher that verifies the signatures of the images it
+// fetches.
+type VerifyingFetcher struct {
+	Fetcher
+	verifier *signature.Verifier
+}
+
+// NewVerifyingFetcher returns a Fetcher that verifies the signatures of the
+// images fetched by f.
+func NewVerifyingFetcher(f Fetcher, v *signature.Verifier) *VerifyingFetcher {
+	return &VerifyingFetcher{Fetcher: f, verifier: v}
+}
+
+// Fetch fetches a package image once its signature is verified. Signatures are
+// verified against the digest of the manifest the reference points to, which
+// is the digest of the index for multi-platform packages.
+func (f *VerifyingFetcher) Fetch(ctx context.Context, ref name.Reference, secrets ...string) (v1.Image, error) {
+	d, err := f.Head(ctx, ref, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.verifier.Verify(ctx, ref, d.Digest); err != nil {
+		return nil, err
+	}
+	// NOTE: the image is fetched by digest, so that it cannot be changed
+	// between its verification and its fetch.
+	return f.Fetcher.Fetch(ctx, ref.Context().Digest(d.Digest.String()), secrets...)
+}
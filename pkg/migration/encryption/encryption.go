@@ -0,0 +1,233 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption encrypts and decrypts sensitive resources in an export
+// archive.
+package encryption
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Scheme is the scheme used to encrypt resources in an export archive.
+type Scheme string
+
+const (
+	// SchemeAge encrypts to one or more age X25519 recipients.
+	SchemeAge Scheme = "age"
+	// SchemeAgePassphrase encrypts with an age scrypt passphrase.
+	SchemeAgePassphrase Scheme = "age-passphrase"
+	// SchemePGP encrypts to one or more OpenPGP public keys.
+	SchemePGP Scheme = "pgp"
+)
+
+const (
+	agePublicKeyPrefix = "age1"
+	pgpArmorHeader     = "-----BEGIN PGP"
+)
+
+// Encrypter encrypts resource payloads.
+type Encrypter interface {
+	// Scheme returns the scheme used to encrypt.
+	Scheme() Scheme
+	// Encrypt returns the encrypted payload.
+	Encrypt(plaintext []byte) ([]byte, error)
+}
+
+// Decrypter decrypts resource payloads.
+type Decrypter interface {
+	// Decrypt returns the decrypted payload.
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// NewEncrypter returns an encrypter for the given recipient or passphrase. The
+// recipient is either an age public key, or the path to a file containing age
+// public keys or an armored PGP public key. Only one of recipient and
+// passphrase may be set.
+func NewEncrypter(recipient, passphrase string) (Encrypter, error) {
+	switch {
+	case recipient != "" && passphrase != "":
+		return nil, errors.New("only one of a recipient or a passphrase can be used for encryption")
+	case passphrase != "":
+		r, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create passphrase recipient")
+		}
+		return &ageEncrypter{scheme: SchemeAgePassphrase, recipients: []age.Recipient{r}}, nil
+	case strings.HasPrefix(recipient, agePublicKeyPrefix):
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse age recipient")
+		}
+		return &ageEncrypter{scheme: SchemeAge, recipients: []age.Recipient{r}}, nil
+	case recipient != "":
+		b, err := os.ReadFile(recipient) //nolint:gosec // Reading a user provided key file is intended.
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read recipient file %q", recipient)
+		}
+		if bytes.Contains(b, []byte(pgpArmorHeader)) {
+			keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot parse PGP public key from %q", recipient)
+			}
+			return &pgpEncrypter{keys: keys}, nil
+		}
+		rs, err := age.ParseRecipients(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse age recipients from %q", recipient)
+		}
+		return &ageEncrypter{scheme: SchemeAge, recipients: rs}, nil
+	default:
+		return nil, errors.New("a recipient or a passphrase is required for encryption")
+	}
+}
+
+// NewDecrypter returns a decrypter for the given scheme. The key is the path
+// to a file containing age identities or an armored PGP private key. The
+// passphrase is used for the age passphrase scheme, or to unlock an encrypted
+// PGP private key.
+func NewDecrypter(scheme Scheme, key, passphrase string) (Decrypter, error) {
+	switch scheme {
+	case SchemeAgePassphrase:
+		if passphrase == "" {
+			return nil, errors.Errorf("a passphrase is required to decrypt %q encrypted resources", scheme)
+		}
+		id, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create passphrase identity")
+		}
+		return &ageDecrypter{identities: []age.Identity{id}}, nil
+	case SchemeAge:
+		b, err := readKey(scheme, key)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := age.ParseIdentities(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse age identities from %q", key)
+		}
+		return &ageDecrypter{identities: ids}, nil
+	case SchemePGP:
+		b, err := readKey(scheme, key)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse PGP private key from %q", key)
+		}
+		for _, k := range keys {
+			if k.PrivateKey == nil || !k.PrivateKey.Encrypted {
+				continue
+			}
+			if passphrase == "" {
+				return nil, errors.Errorf("PGP private key in %q is encrypted, a passphrase is required", key)
+			}
+			if err := k.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, errors.Wrapf(err, "cannot unlock PGP private key in %q", key)
+			}
+		}
+		return &pgpDecrypter{keys: keys}, nil
+	default:
+		return nil, errors.Errorf("unknown encryption scheme %q", scheme)
+	}
+}
+
+func readKey(scheme Scheme, key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.Errorf("a decryption key is required to decrypt %q encrypted resources", scheme)
+	}
+	b, err := os.ReadFile(key) //nolint:gosec // Reading a user provided key file is intended.
+	return b, errors.Wrapf(err, "cannot read decryption key %q", key)
+}
+
+type ageEncrypter struct {
+	scheme     Scheme
+	recipients []age.Recipient
+}
+
+func (e *ageEncrypter) Scheme() Scheme {
+	return e.scheme
+}
+
+func (e *ageEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := age.Encrypt(buf, e.recipients...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with age")
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with age")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with age")
+	}
+	return buf.Bytes(), nil
+}
+
+type ageDecrypter struct {
+	identities []age.Identity
+}
+
+func (d *ageDecrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), d.identities...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt with age")
+	}
+	b, err := io.ReadAll(r)
+	return b, errors.Wrap(err, "cannot decrypt with age")
+}
+
+type pgpEncrypter struct {
+	keys openpgp.EntityList
+}
+
+func (e *pgpEncrypter) Scheme() Scheme {
+	return SchemePGP
+}
+
+func (e *pgpEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := openpgp.Encrypt(buf, e.keys, nil, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with PGP")
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with PGP")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "cannot encrypt with PGP")
+	}
+	return buf.Bytes(), nil
+}
+
+type pgpDecrypter struct {
+	keys openpgp.EntityList
+}
+
+func (d *pgpDecrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	md, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), d.keys, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt with PGP")
+	}
+	b, err := io.ReadAll(md.UnverifiedBody)
+	return b, errors.Wrap(err, "cannot decrypt with PGP")
+}
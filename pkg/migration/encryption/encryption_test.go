@@ -0,0 +1,139 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ageKey := filepath.Join(dir, "age.key")
+	if err := os.WriteFile(ageKey, []byte(id.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	pgpPub, pgpPriv := writePGPKeys(t, dir)
+
+	type args struct {
+		recipient  string
+		passphrase string
+		key        string
+	}
+	type want struct {
+		scheme Scheme
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"AgeRecipient": {
+			args: args{recipient: id.Recipient().String(), key: ageKey},
+			want: want{scheme: SchemeAge},
+		},
+		"AgePassphrase": {
+			args: args{passphrase: "correct horse battery staple"},
+			want: want{scheme: SchemeAgePassphrase},
+		},
+		"PGP": {
+			args: args{recipient: pgpPub, key: pgpPriv},
+			want: want{scheme: SchemePGP},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			plaintext := []byte("apiVersion: v1\nkind: Secret\n")
+
+			e, err := NewEncrypter(tc.args.recipient, tc.args.passphrase)
+			if err != nil {
+				t.Fatalf("NewEncrypter(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want.scheme, e.Scheme()); diff != "" {
+				t.Errorf("Scheme(): -want, +got:\n%s", diff)
+			}
+			ciphertext, err := e.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt(...): unexpected error: %v", err)
+			}
+			if bytes.Contains(ciphertext, plaintext) {
+				t.Errorf("Encrypt(...): ciphertext contains plaintext")
+			}
+
+			d, err := NewDecrypter(e.Scheme(), tc.args.key, tc.args.passphrase)
+			if err != nil {
+				t.Fatalf("NewDecrypter(...): unexpected error: %v", err)
+			}
+			got, err := d.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt(...): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(plaintext, got); diff != "" {
+				t.Errorf("Decrypt(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func writePGPKeys(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	e, err := openpgp.NewEntity("test", "", "test@example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &bytes.Buffer{}
+	w, err := armor.Encode(pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	priv := &bytes.Buffer{}
+	w, err = armor.Encode(priv, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	pubPath := filepath.Join(dir, "pgp.pub")
+	privPath := filepath.Join(dir, "pgp.key")
+	if err := os.WriteFile(pubPath, pub.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(privPath, priv.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return pubPath, privPath
+}
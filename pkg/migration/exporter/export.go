@@ -25,14 +25,12 @@ import (
 	"strings"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
-	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/spf13/afero"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/client-go/discovery"
@@ -41,14 +39,23 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	"github.com/upbound/up/pkg/migration"
-	"github.com/upbound/up/pkg/migration/category"
+	"github.com/upbound/up/pkg/migration/encryption"
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/transform"
 )
 
 const (
 	stepFailed = "Failed!"
 )
 
+var (
+	// encryptedResources are the group resources whose files are encrypted
+	// in the archive when encryption is enabled.
+	encryptedResources = []string{
+		"secrets",
+	}
+)
+
 // Options for the exporter.
 type Options struct {
 	// OutputArchive is the path to the archive file to be created.
@@ -64,8 +71,27 @@ type Options struct {
 	// Resource types to exclude from the export.
 	ExcludeResources []string // default: none
 
-	// PauseBeforeExport pauses all managed resources before starting the export process.
+	// PauseBeforeExport pauses the managed resources in the export scope before
+	// starting the export process.
 	PauseBeforeExport bool // default: false
+
+	// EncryptRecipient is an age public key, or the path to a file containing
+	// age public keys or an armored PGP public key, used to encrypt secrets in
+	// the archive.
+	EncryptRecipient string // default: none
+	// EncryptPassphrase is a passphrase used to encrypt secrets in the archive.
+	EncryptPassphrase string // default: none
+
+	// TransformRules is the path to a file with transformation rules applied
+	// to each exported resource.
+	TransformRules string // default: none
+
+	// Claim limits the export to the claim with the given "namespace/name",
+	// its composite resource and everything they need.
+	Claim string // default: none
+	// Composite limits the export to the composite resource with the given
+	// name and everything it needs.
+	Composite string // default: none
 }
 
 // ControlPlaneStateExporter exports the state of a Crossplane control plane.
@@ -75,6 +101,9 @@ type ControlPlaneStateExporter struct {
 	discoveryClient discovery.DiscoveryInterface
 	appsClient      appsv1.AppsV1Interface
 	resourceMapper  meta.RESTMapper
+	transformer     *transform.Transformer
+	owners          *OwnerGraphRecorder
+	scope           *Scope
 
 	options Options
 }
@@ -96,6 +125,14 @@ func NewControlPlaneStateExporter(crdClient apiextensionsclientset.Interface, dy
 func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolint:gocyclo // This is the high level export command, so it's expected to be a bit complex.
 
 	// TODO(turkenh): Check if we can use `afero.NewMemMapFs()` just like import and avoid the need for a temporary directory.
+	var encrypter encryption.Encrypter
+	if e.options.EncryptRecipient != "" || e.options.EncryptPassphrase != "" {
+		var err error
+		if encrypter, err = encryption.NewEncrypter(e.options.EncryptRecipient, e.options.EncryptPassphrase); err != nil {
+			return errors.Wrap(err, "cannot set up encryption")
+		}
+	}
+
 	fs := afero.Afero{Fs: afero.NewOsFs()}
 	// We are using a temporary directory to store the exported state before
 	// archiving it. This temporary directory will be deleted after the archive
@@ -108,23 +145,40 @@ func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolin
 		_ = fs.RemoveAll(tmpDir)
 	}()
 
-	if e.options.PauseBeforeExport {
-		pauseMsg := "Pausing all managed resources before export... "
-		s, _ := migration.DefaultSpinner.Start(pauseMsg)
-		cm := category.NewAPICategoryModifier(e.dynamicClient, e.discoveryClient)
+	if err := e.export(ctx, fs, tmpDir, encrypter); err != nil {
+		return err
+	}
 
-		// Modify all managed resources to add the "crossplane.io/paused: true" annotation.
-		count, err := cm.ModifyResources(ctx, "managed", func(u *unstructured.Unstructured) error {
-			xpmeta.AddAnnotations(u, map[string]string{"crossplane.io/paused": "true"})
-			return nil
-		})
-		if err != nil {
-			s.Fail(pauseMsg + stepFailed)
-			return errors.Wrap(err, "cannot pause managed resources")
+	// Archive the exported state.
+	archiveMsg := "Archiving exported state... "
+	s, _ := migration.DefaultSpinner.Start(archiveMsg)
+	if err = e.archive(ctx, fs, tmpDir, encrypter); err != nil {
+		s.Fail(archiveMsg + stepFailed)
+		return errors.Wrap(err, "cannot archive exported state")
+	}
+	s.Success(archiveMsg + fmt.Sprintf("archived to %q! 📦", e.options.OutputArchive))
+	//////////////////////
+
+	return nil
+}
+
+// ExportTo exports the state of the control plane into the root directory of
+// the given file system, in the same directory structure as an archive, but
+// without archiving or encrypting it.
+func (e *ControlPlaneStateExporter) ExportTo(ctx context.Context, fs afero.Afero, root string) error {
+	return e.export(ctx, fs, root, nil)
+}
+
+func (e *ControlPlaneStateExporter) export(ctx context.Context, fs afero.Afero, tmpDir string, encrypter encryption.Encrypter) error { // nolint:gocyclo // This is the high level export logic, so it's expected to be a bit complex.
+	if e.options.TransformRules != "" {
+		var err error
+		if e.transformer, err = transform.Load(e.options.TransformRules); err != nil {
+			return errors.Wrap(err, "cannot load transformation rules")
 		}
-		s.Success(pauseMsg + fmt.Sprintf("%d resources paused! ⏸️", count))
 	}
 
+	e.owners = NewOwnerGraphRecorder()
+
 	// Scan the control plane for types to export.
 	scanMsg := "Scanning control plane for types to export... "
 	s, _ := migration.DefaultSpinner.Start(scanMsg)
@@ -171,6 +225,41 @@ func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolin
 	s.Success(scanMsg + fmt.Sprintf("%d types found! 👀", len(exportList)))
 	//////////////////////
 
+	// Resolve the subtree of the claim or composite to export, if any.
+	if e.options.Claim != "" || e.options.Composite != "" {
+		resolveMsg := "Resolving resources to export... "
+		s, _ = migration.DefaultSpinner.Start(resolveMsg)
+		r := newSubtreeResolver(e.dynamicClient, e.resourceMapper, crdList)
+		var err error
+		if e.options.Claim != "" {
+			e.scope, err = r.ResolveClaim(ctx, e.options.Claim)
+		} else {
+			e.scope, err = r.ResolveComposite(ctx, e.options.Composite)
+		}
+		if err != nil {
+			s.Fail(resolveMsg + stepFailed)
+			return errors.Wrap(err, "cannot resolve resources to export")
+		}
+		s.Success(resolveMsg + fmt.Sprintf("%d resources in scope! 🎯", e.scope.Len()))
+	}
+	//////////////////////
+
+	if e.options.PauseBeforeExport {
+		pauseMsg := "Pausing managed resources before export... "
+		s, _ = migration.DefaultSpinner.Start(pauseMsg)
+
+		// Only pause the managed resources that will be exported, so that
+		// resources outside the export scope keep being reconciled.
+		p := NewResourcePauser(e.dynamicClient, NewUnstructuredFetcher(e.dynamicClient, e.options, WithScope(e.scope)))
+		count, err := p.PauseManagedResources(ctx, exportList)
+		if err != nil {
+			s.Fail(pauseMsg + stepFailed)
+			return errors.Wrap(err, "cannot pause managed resources")
+		}
+		s.Success(pauseMsg + fmt.Sprintf("%d resources paused! ⏸️", count))
+	}
+	//////////////////////
+
 	// Export Crossplane resources.
 	exportCRsMsg := fmt.Sprintf("Exporting %d Crossplane resources...", len(exportList))
 	s, _ = migration.DefaultSpinner.Start(exportCRsMsg)
@@ -265,21 +354,23 @@ func (e *ControlPlaneStateExporter) Export(ctx context.Context) error { // nolin
 	// the version and feature flags of Crossplane and number of resources exported per type.
 	// This metadata file is used during import to determine if the import is compatible with the
 	// current Crossplane version and feature flags and also enables manual inspection the exported state.
+	var enc *v1alpha1.EncryptionInfo
+	if encrypter != nil {
+		enc = &v1alpha1.EncryptionInfo{
+			Scheme:    string(encrypter.Scheme()),
+			Resources: encryptedResources,
+		}
+	}
 	me := NewPersistentMetadataExporter(e.appsClient, fs, tmpDir)
-	if err = me.ExportMetadata(ctx, e.options, nativeCounts, crCounts); err != nil {
+	if err := me.ExportMetadata(ctx, e.options, enc, e.transformer.Applied(), nativeCounts, crCounts); err != nil {
 		return errors.Wrap(err, "cannot write export metadata")
 	}
-	//////////////////////
 
-	// Archive the exported state.
-	archiveMsg := "Archiving exported state... "
-	s, _ = migration.DefaultSpinner.Start(archiveMsg)
-	if err = e.archive(ctx, fs, tmpDir); err != nil {
-		s.Fail(archiveMsg + stepFailed)
-		return errors.Wrap(err, "cannot archive exported state")
+	// Export the owner graph, so that owner references can be restored with
+	// the new UIDs after import.
+	if err := e.owners.Persist(fs, tmpDir); err != nil {
+		return errors.Wrap(err, "cannot write owner graph")
 	}
-	s.Success(archiveMsg + fmt.Sprintf("archived to %q! 📦", e.options.OutputArchive))
-	//////////////////////
 
 	return nil
 }
@@ -300,11 +391,13 @@ func (e *ControlPlaneStateExporter) exportCrossplaneResources(ctx context.Contex
 		}
 	}
 	exporter := NewUnstructuredExporter(
-		NewUnstructuredFetcher(e.dynamicClient, e.options),
+		NewUnstructuredFetcher(e.dynamicClient, e.options, WithScope(e.scope)),
 		NewFileSystemPersister(fs, tmpDir, &v1alpha1.TypeMeta{
 			Categories:            crd.Spec.Names.Categories,
 			WithStatusSubresource: sub,
-		}))
+		}),
+		WithTransformer(e.transformer),
+		WithOwnerGraphRecorder(e.owners))
 
 	// ExportResource will fetch all resources of the given GVR and store them in the
 	// well-known directory structure.
@@ -321,8 +414,10 @@ func (e *ControlPlaneStateExporter) exportNativeResource(ctx context.Context, r
 		return 0, errors.Wrapf(err, "cannot get GVR for %q", r)
 	}
 	exporter := NewUnstructuredExporter(
-		NewUnstructuredFetcher(e.dynamicClient, e.options),
-		NewFileSystemPersister(fs, tmpDir, nil))
+		NewUnstructuredFetcher(e.dynamicClient, e.options, WithScope(e.scope)),
+		NewFileSystemPersister(fs, tmpDir, nil),
+		WithTransformer(e.transformer),
+		WithOwnerGraphRecorder(e.owners))
 
 	count, err := exporter.ExportResources(ctx, gvr)
 	if err != nil {
@@ -398,7 +493,7 @@ func (e *ControlPlaneStateExporter) customResourceGVR(in apiextensionsv1.CustomR
 	return rm.Resource, nil
 }
 
-func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero, dir string) error {
+func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero, dir string, encrypter encryption.Encrypter) error { // nolint:gocyclo // Walking the directory with error handling for each step.
 	// Create the output file
 	out, err := fs.Create(e.options.OutputArchive)
 	if err != nil {
@@ -460,13 +555,6 @@ func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero,
 			return nil
 		}
 
-		// Open the file
-		f, err := os.Open(file)
-		if err != nil {
-			return errors.Wrapf(err, "cannot open file %q", file)
-		}
-		defer f.Close()
-
 		// Create a new tar header with the relative path
 		header, err := tar.FileInfoHeader(fi, relPath)
 		if err != nil {
@@ -474,6 +562,31 @@ func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero,
 		}
 		header.Name = relPath
 
+		if encrypter != nil && shouldEncrypt(relPath) {
+			b, err := os.ReadFile(file) //nolint:gosec // Reading files we exported to our own temporary directory.
+			if err != nil {
+				return errors.Wrapf(err, "cannot read file %q", file)
+			}
+			if b, err = encrypter.Encrypt(b); err != nil {
+				return errors.Wrapf(err, "cannot encrypt file %q", file)
+			}
+			header.Size = int64(len(b))
+			if err := tw.WriteHeader(header); err != nil {
+				return errors.Wrapf(err, "cannot write tar header for %q", file)
+			}
+			if _, err := tw.Write(b); err != nil {
+				return errors.Wrapf(err, "cannot write encrypted file data for %q", file)
+			}
+			return nil
+		}
+
+		// Open the file
+		f, err := os.Open(file)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open file %q", file)
+		}
+		defer f.Close()
+
 		// Write the header to the tar archive
 		if err := tw.WriteHeader(header); err != nil {
 			return errors.Wrapf(err, "cannot write tar header for %q", file)
@@ -491,6 +604,22 @@ func (e *ControlPlaneStateExporter) archive(ctx context.Context, fs afero.Afero,
 	return errors.Wrapf(err, "walking directory %q", dir)
 }
 
+// shouldEncrypt returns true if the file at the given path relative to the
+// export root holds a resource that must be encrypted. Type metadata files are
+// never encrypted.
+func shouldEncrypt(relPath string) bool {
+	parts := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+	if len(parts) != 2 || parts[1] == "metadata.yaml" {
+		return false
+	}
+	for _, gr := range encryptedResources {
+		if parts[0] == gr {
+			return true
+		}
+	}
+	return false
+}
+
 func fetchAllCRDs(ctx context.Context, kube apiextensionsclientset.Interface) ([]apiextensionsv1.CustomResourceDefinition, error) {
 	var crds []apiextensionsv1.CustomResourceDefinition
 
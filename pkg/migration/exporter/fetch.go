@@ -40,9 +40,22 @@ type UnstructuredFetcher struct {
 
 	includedNamespaces map[string]struct{}
 	excludedNamespaces map[string]struct{}
+
+	scope *Scope
+}
+
+// UnstructuredFetcherOption configures an UnstructuredFetcher.
+type UnstructuredFetcherOption func(*UnstructuredFetcher)
+
+// WithScope limits the fetched resources to the ones in the scope. A nil
+// scope does not limit the fetched resources.
+func WithScope(s *Scope) UnstructuredFetcherOption {
+	return func(e *UnstructuredFetcher) {
+		e.scope = s
+	}
 }
 
-func NewUnstructuredFetcher(kube dynamic.Interface, opts Options) *UnstructuredFetcher {
+func NewUnstructuredFetcher(kube dynamic.Interface, opts Options, fopts ...UnstructuredFetcherOption) *UnstructuredFetcher {
 	inc := make(map[string]struct{}, len(opts.IncludeNamespaces))
 	for _, ns := range opts.IncludeNamespaces {
 		inc[ns] = struct{}{}
@@ -52,13 +65,17 @@ func NewUnstructuredFetcher(kube dynamic.Interface, opts Options) *UnstructuredF
 		exc[ns] = struct{}{}
 	}
 
-	return &UnstructuredFetcher{
+	e := &UnstructuredFetcher{
 		kube:     kube,
 		pageSize: defaultPageSize,
 
 		includedNamespaces: inc,
 		excludedNamespaces: exc,
 	}
+	for _, o := range fopts {
+		o(e)
+	}
+	return e
 }
 
 func (e *UnstructuredFetcher) FetchResources(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
@@ -102,6 +119,11 @@ func (e *UnstructuredFetcher) namespaceInScope(namespace string) bool {
 }
 
 func (e *UnstructuredFetcher) shouldSkip(r unstructured.Unstructured) bool { // nolint:gocyclo // Relatively simple logic.
+	if e.scope != nil && !e.scope.Contains(r) {
+		// The export is limited to a claim or composite subtree.
+		return true
+	}
+
 	// Filter out namespaces that are not in the scope.
 	// - If the resource is a Namespace and its name is not in the scope, skip it.
 	// - If the resource is namespaced and its namespace is in the scope, skip it.
@@ -19,12 +19,14 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 	type args struct {
 		includedNamespaces map[string]struct{}
 		excludedNamespaces map[string]struct{}
+		scope              *Scope
 		r                  unstructured.Unstructured
 	}
 	type want struct {
@@ -263,6 +265,50 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 			},
 		},
 
+		"SkipOutOfScope": {
+			args: args{
+				scope: func() *Scope {
+					s := NewScope()
+					s.Add(schema.GroupKind{Group: "example.org", Kind: "XBucket"}, "", "in-scope")
+					return s
+				}(),
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind":       "XBucket",
+						"apiVersion": "example.org/v1",
+						"metadata": map[string]interface{}{
+							"name": "out-of-scope",
+						},
+					},
+				},
+			},
+			want: want{
+				skip: true,
+			},
+		},
+
+		"DontSkipInScope": {
+			args: args{
+				scope: func() *Scope {
+					s := NewScope()
+					s.Add(schema.GroupKind{Group: "example.org", Kind: "XBucket"}, "", "in-scope")
+					return s
+				}(),
+				r: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind":       "XBucket",
+						"apiVersion": "example.org/v1",
+						"metadata": map[string]interface{}{
+							"name": "in-scope",
+						},
+					},
+				},
+			},
+			want: want{
+				skip: false,
+			},
+		},
+
 		"DontSkipAnythingElse": {
 			args: args{
 				r: unstructured.Unstructured{
@@ -281,6 +327,7 @@ func TestUnstructuredFetcherShouldSkip(t *testing.T) {
 			e := &UnstructuredFetcher{
 				includedNamespaces: tc.args.includedNamespaces,
 				excludedNamespaces: tc.args.excludedNamespaces,
+				scope:              tc.args.scope,
 			}
 			if diff := cmp.Diff(e.shouldSkip(tc.args.r), tc.want.skip); diff != "" {
 				t.Errorf("shouldSkip() mismatch (-want +got):\n%s", diff)
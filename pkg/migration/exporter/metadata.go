@@ -46,7 +46,7 @@ func NewPersistentMetadataExporter(apps appsv1.AppsV1Interface, fs afero.Afero,
 	}
 }
 
-func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Options, native map[string]int, custom map[string]int) error {
+func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Options, enc *v1alpha1.EncryptionInfo, transformations map[string]int, native map[string]int, custom map[string]int) error {
 	xp, err := crossplane.CollectInfo(ctx, e.appsClient)
 	if err != nil {
 		return errors.Wrap(err, "cannot get Crossplane info")
@@ -68,6 +68,8 @@ func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Op
 			IncludedExtraResources: opts.IncludeExtraResources,
 			ExcludedResources:      opts.ExcludeResources,
 			PausedBeforeExport:     opts.PauseBeforeExport,
+			Claim:                  opts.Claim,
+			Composite:              opts.Composite,
 		},
 		Crossplane: *xp,
 		Stats: v1alpha1.ExportStats{
@@ -75,6 +77,8 @@ func (e *PersistentMetadataExporter) ExportMetadata(ctx context.Context, opts Op
 			NativeResources: native,
 			CustomResources: custom,
 		},
+		Transformations: transformations,
+		Encryption:      enc,
 	}
 	b, err := yaml.Marshal(&em)
 	if err != nil {
@@ -0,0 +1,124 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"path/filepath"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+// OwnerGraphRecorder records the owners of the exported resources before
+// their owner references are removed. Resources are recorded as they are
+// exported, after transformation, so that renamed resources and owners are
+// recorded under their exported names. A nil recorder records nothing.
+type OwnerGraphRecorder struct {
+	graph v1alpha1.OwnerGraph
+	// owners are the UIDs of the owners of each recorded resource, in the
+	// order of its owners in the graph.
+	owners [][]types.UID
+	// exported are the exported identities of every recorded resource by
+	// UID, which owner references are resolved against.
+	exported map[types.UID]v1alpha1.OwnerReference
+}
+
+// NewOwnerGraphRecorder returns a new, empty OwnerGraphRecorder.
+func NewOwnerGraphRecorder() *OwnerGraphRecorder {
+	return &OwnerGraphRecorder{exported: map[types.UID]v1alpha1.OwnerReference{}}
+}
+
+// Record records the exported resource and its owners, if it has any. The uid
+// and owner references are those of the resource on the exported control
+// plane, since they are removed before the resource is transformed.
+func (r *OwnerGraphRecorder) Record(u *unstructured.Unstructured, uid types.UID, refs []metav1.OwnerReference) {
+	if r == nil {
+		return
+	}
+	if uid != "" {
+		// Resources may be exported again when an export is retried.
+		if _, ok := r.exported[uid]; ok {
+			return
+		}
+		r.exported[uid] = v1alpha1.OwnerReference{
+			APIVersion: u.GetAPIVersion(),
+			Kind:       u.GetKind(),
+			Name:       u.GetName(),
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	owned := v1alpha1.OwnedResource{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+	uids := make([]types.UID, 0, len(refs))
+	for _, ref := range refs {
+		owned.Owners = append(owned.Owners, v1alpha1.OwnerReference{
+			APIVersion:         ref.APIVersion,
+			Kind:               ref.Kind,
+			Name:               ref.Name,
+			Controller:         ref.Controller,
+			BlockOwnerDeletion: ref.BlockOwnerDeletion,
+		})
+		uids = append(uids, ref.UID)
+	}
+	r.graph.Resources = append(r.graph.Resources, owned)
+	r.owners = append(r.owners, uids)
+}
+
+// resolve returns the owner graph with the owners that were exported
+// referenced by their exported names. Owners that were not exported are left
+// as they were on the exported control plane.
+func (r *OwnerGraphRecorder) resolve() v1alpha1.OwnerGraph {
+	g := v1alpha1.OwnerGraph{Resources: make([]v1alpha1.OwnedResource, 0, len(r.graph.Resources))}
+	for i, res := range r.graph.Resources {
+		owners := make([]v1alpha1.OwnerReference, 0, len(res.Owners))
+		for j, o := range res.Owners {
+			if e, ok := r.exported[r.owners[i][j]]; ok {
+				o.APIVersion, o.Kind, o.Name = e.APIVersion, e.Kind, e.Name
+			}
+			owners = append(owners, o)
+		}
+		res.Owners = owners
+		g.Resources = append(g.Resources, res)
+	}
+	return g
+}
+
+// Persist writes the recorded owner graph to "owners.yaml" in the root
+// directory. Nothing is written if no owners were recorded.
+func (r *OwnerGraphRecorder) Persist(fs afero.Afero, root string) error {
+	if r == nil || len(r.graph.Resources) == 0 {
+		return nil
+	}
+
+	g := r.resolve()
+	b, err := yaml.Marshal(&g)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal owner graph to yaml")
+	}
+	return errors.Wrap(fs.WriteFile(filepath.Join(root, "owners.yaml"), b, 0600), "cannot write owner graph")
+}
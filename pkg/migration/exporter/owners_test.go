@@ -0,0 +1,134 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/transform"
+)
+
+type fetcherFn func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+
+func (f fetcherFn) FetchResources(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	return f(ctx, gvr)
+}
+
+type persisterFn func(ctx context.Context, groupResource string, resources []unstructured.Unstructured) error
+
+func (f persisterFn) PersistResources(ctx context.Context, groupResource string, resources []unstructured.Unstructured) error {
+	return f(ctx, groupResource, resources)
+}
+
+func bucket(name, uid string, owners ...map[string]any) unstructured.Unstructured {
+	meta := map[string]any{"name": name, "namespace": "default", "uid": uid}
+	if len(owners) > 0 {
+		refs := make([]any, 0, len(owners))
+		for _, o := range owners {
+			refs = append(refs, o)
+		}
+		meta["ownerReferences"] = refs
+	}
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "Bucket",
+		"metadata":   meta,
+	}}
+}
+
+func TestOwnerGraphRecorder(t *testing.T) {
+	owner := map[string]any{"apiVersion": "example.org/v1", "kind": "Bucket", "name": "parent", "uid": "uid-parent"}
+	external := map[string]any{"apiVersion": "example.org/v1", "kind": "Store", "name": "store", "uid": "uid-store"}
+
+	cases := map[string]struct {
+		reason    string
+		resources []unstructured.Unstructured
+		rules     transform.Rules
+		want      v1alpha1.OwnerGraph
+	}{
+		"Unchanged": {
+			reason:    "Owners should be recorded under the names of the exported resources.",
+			resources: []unstructured.Unstructured{bucket("parent", "uid-parent"), bucket("child", "uid-child", owner)},
+			want: v1alpha1.OwnerGraph{Resources: []v1alpha1.OwnedResource{{
+				APIVersion: "example.org/v1", Kind: "Bucket", Namespace: "default", Name: "child",
+				Owners: []v1alpha1.OwnerReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "parent"}},
+			}}},
+		},
+		"Renamed": {
+			reason:    "Resources and owners renamed by transformation rules should be recorded under their new names.",
+			resources: []unstructured.Unstructured{bucket("child", "uid-child", owner), bucket("parent", "uid-parent")},
+			rules: transform.Rules{Rules: []transform.Rule{{
+				Name:       "rename",
+				Operations: []transform.Operation{{Type: transform.OperationReplace, Path: "metadata.name", Pattern: "^", Replacement: "new-"}},
+			}}},
+			want: v1alpha1.OwnerGraph{Resources: []v1alpha1.OwnedResource{{
+				APIVersion: "example.org/v1", Kind: "Bucket", Namespace: "default", Name: "new-child",
+				Owners: []v1alpha1.OwnerReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "new-parent"}},
+			}}},
+		},
+		"OwnerNotExported": {
+			reason:    "Owners that were not exported should be recorded as they were.",
+			resources: []unstructured.Unstructured{bucket("child", "uid-child", external)},
+			want: v1alpha1.OwnerGraph{Resources: []v1alpha1.OwnedResource{{
+				APIVersion: "example.org/v1", Kind: "Bucket", Namespace: "default", Name: "child",
+				Owners: []v1alpha1.OwnerReference{{APIVersion: "example.org/v1", Kind: "Store", Name: "store"}},
+			}}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr, err := transform.New(tc.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := NewOwnerGraphRecorder()
+			e := NewUnstructuredExporter(
+				fetcherFn(func(_ context.Context, _ schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+					return tc.resources, nil
+				}),
+				persisterFn(func(_ context.Context, _ string, _ []unstructured.Unstructured) error { return nil }),
+				WithTransformer(tr),
+				WithOwnerGraphRecorder(r),
+			)
+			if _, err := e.ExportResources(context.Background(), schema.GroupVersionResource{}); err != nil {
+				t.Fatal(err)
+			}
+
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := r.Persist(fs, "/"); err != nil {
+				t.Fatal(err)
+			}
+			b, err := fs.ReadFile("/owners.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := v1alpha1.OwnerGraph{}
+			if err := yaml.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nPersist(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
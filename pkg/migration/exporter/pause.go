@@ -0,0 +1,78 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	xpmeta "github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+const annotationPaused = "crossplane.io/paused"
+
+// ResourcePauser pauses the managed resources that will be exported.
+type ResourcePauser struct {
+	dynamicClient dynamic.Interface
+	fetcher       ResourceFetcher
+}
+
+// NewResourcePauser returns a new ResourcePauser that pauses the resources
+// returned by the given fetcher. The fetcher is expected to apply the same
+// scope and namespace filters as the export itself.
+func NewResourcePauser(dynamicClient dynamic.Interface, fetcher ResourceFetcher) *ResourcePauser {
+	return &ResourcePauser{
+		dynamicClient: dynamicClient,
+		fetcher:       fetcher,
+	}
+}
+
+// PauseManagedResources adds the "crossplane.io/paused: true" annotation to
+// the managed resources of the given CRDs, skipping CRDs that are not in the
+// "managed" category. It returns the number of paused resources.
+func (p *ResourcePauser) PauseManagedResources(ctx context.Context, crds []apiextensionsv1.CustomResourceDefinition) (int, error) {
+	count := 0
+	for _, crd := range crds {
+		if !hasCategory(crd, "managed") {
+			continue
+		}
+		gvr := schema.GroupVersionResource{Group: crd.Spec.Group, Version: storageVersion(crd), Resource: crd.Spec.Names.Plural}
+		resources, err := p.fetcher.FetchResources(ctx, gvr)
+		if err != nil {
+			return count, errors.Wrapf(err, "cannot fetch resources %s", crd.GetName())
+		}
+		for _, item := range resources {
+			if err := retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
+				u, err := p.dynamicClient.Resource(gvr).Namespace(item.GetNamespace()).Get(ctx, item.GetName(), v1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				xpmeta.AddAnnotations(u, map[string]string{annotationPaused: "true"})
+				_, err = p.dynamicClient.Resource(gvr).Namespace(u.GetNamespace()).Update(ctx, u, v1.UpdateOptions{})
+				return err
+			}); err != nil {
+				return count, errors.Wrapf(err, "cannot pause resource %s/%s", item.GetKind(), item.GetName())
+			}
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestPauseManagedResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "buckets"}
+	crd := func(categories ...string) apiextensionsv1.CustomResourceDefinition {
+		return apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: v1.ObjectMeta{Name: "buckets.example.org"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group:    "example.org",
+				Names:    apiextensionsv1.CustomResourceDefinitionNames{Plural: "buckets", Kind: "Bucket", Categories: categories},
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{Name: "v1", Storage: true}},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		reason  string
+		crds    []apiextensionsv1.CustomResourceDefinition
+		fetched []string
+		want    []string
+	}{
+		"OnlyFetchedResources": {
+			reason:  "Only the resources returned by the scoped fetcher should be paused.",
+			crds:    []apiextensionsv1.CustomResourceDefinition{crd("crossplane", "managed")},
+			fetched: []string{"in-scope"},
+			want:    []string{"in-scope"},
+		},
+		"NotManaged": {
+			reason:  "Resources of CRDs that are not in the managed category should not be paused.",
+			crds:    []apiextensionsv1.CustomResourceDefinition{crd("crossplane")},
+			fetched: []string{"in-scope", "out-of-scope"},
+			want:    []string{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			in, out := bucket("in-scope", "uid-1"), bucket("out-of-scope", "uid-2")
+			objs := []runtime.Object{&in, &out}
+			dyn := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "BucketList"}, objs...)
+
+			fetcher := fetcherFn(func(_ context.Context, _ schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+				out := make([]unstructured.Unstructured, 0, len(tc.fetched))
+				for _, n := range tc.fetched {
+					out = append(out, bucket(n, ""))
+				}
+				return out, nil
+			})
+			if _, err := NewResourcePauser(dyn, fetcher).PauseManagedResources(context.Background(), tc.crds); err != nil {
+				t.Fatal(err)
+			}
+
+			ul, err := dyn.Resource(gvr).Namespace("").List(context.Background(), v1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, u := range ul.Items {
+				if u.GetAnnotations()[annotationPaused] == "true" {
+					got = append(got, u.GetName())
+				}
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nPauseManagedResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/upbound/up/pkg/migration/transform"
 )
 
 type ResourceExporter interface {
@@ -29,15 +31,40 @@ type ResourceExporter interface {
 }
 
 type UnstructuredExporter struct {
-	fetcher   ResourceFetcher
-	persister ResourcePersister
+	fetcher     ResourceFetcher
+	persister   ResourcePersister
+	transformer *transform.Transformer
+	owners      *OwnerGraphRecorder
+}
+
+// UnstructuredExporterOption configures an UnstructuredExporter.
+type UnstructuredExporterOption func(e *UnstructuredExporter)
+
+// WithTransformer sets the transformer applied to each resource before it is
+// persisted.
+func WithTransformer(t *transform.Transformer) UnstructuredExporterOption {
+	return func(e *UnstructuredExporter) {
+		e.transformer = t
+	}
 }
 
-func NewUnstructuredExporter(f ResourceFetcher, p ResourcePersister) *UnstructuredExporter {
-	return &UnstructuredExporter{
+// WithOwnerGraphRecorder sets the recorder for the owners of each resource,
+// which are removed from the exported resource.
+func WithOwnerGraphRecorder(r *OwnerGraphRecorder) UnstructuredExporterOption {
+	return func(e *UnstructuredExporter) {
+		e.owners = r
+	}
+}
+
+func NewUnstructuredExporter(f ResourceFetcher, p ResourcePersister, opts ...UnstructuredExporterOption) *UnstructuredExporter {
+	e := &UnstructuredExporter{
 		fetcher:   f,
 		persister: p,
 	}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
 }
 
 func (e *UnstructuredExporter) ExportResources(ctx context.Context, gvr schema.GroupVersionResource) (int, error) {
@@ -47,9 +74,16 @@ func (e *UnstructuredExporter) ExportResources(ctx context.Context, gvr schema.G
 	}
 
 	for i := range resources {
+		// The UID and owner references are removed with the cluster specific
+		// data, but the owners are recorded once the resource is transformed.
+		uid, refs := resources[i].GetUID(), resources[i].GetOwnerReferences()
 		if err := cleanupClusterSpecificData(&resources[i]); err != nil {
 			return 0, errors.Wrap(err, "cannot cleanup cluster specific data")
 		}
+		if err := e.transformer.Transform(&resources[i]); err != nil {
+			return 0, errors.Wrap(err, "cannot transform resource")
+		}
+		e.owners.Record(&resources[i], uid, refs)
 	}
 
 	if err = e.persister.PersistResources(ctx, gvr.GroupResource().String(), resources); err != nil {
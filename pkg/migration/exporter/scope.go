@@ -0,0 +1,429 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	pkgGroup = "pkg.crossplane.io"
+	xpGroup  = "apiextensions.crossplane.io"
+)
+
+var (
+	gkNamespace           = schema.GroupKind{Kind: "Namespace"}
+	gkSecret              = schema.GroupKind{Kind: "Secret"}
+	gkXRD                 = schema.GroupKind{Group: xpGroup, Kind: "CompositeResourceDefinition"}
+	gkComposition         = schema.GroupKind{Group: xpGroup, Kind: "Composition"}
+	gkCompositionRevision = schema.GroupKind{Group: xpGroup, Kind: "CompositionRevision"}
+	gkFunction            = schema.GroupKind{Group: pkgGroup, Kind: "Function"}
+)
+
+// Scope is the set of resources to export when the export is limited to the
+// subtree of a claim or a composite resource.
+type Scope struct {
+	keys map[string]struct{}
+}
+
+// NewScope returns a new, empty Scope.
+func NewScope() *Scope {
+	return &Scope{keys: map[string]struct{}{}}
+}
+
+func scopeKey(gk schema.GroupKind, namespace, name string) string {
+	return gk.String() + "/" + namespace + "/" + name
+}
+
+// Add adds the resource with the given type, namespace and name to the scope.
+// It returns false if the resource was already in the scope.
+func (s *Scope) Add(gk schema.GroupKind, namespace, name string) bool {
+	k := scopeKey(gk, namespace, name)
+	if _, ok := s.keys[k]; ok {
+		return false
+	}
+	s.keys[k] = struct{}{}
+	return true
+}
+
+// Contains returns true if the resource is in the scope.
+func (s *Scope) Contains(u unstructured.Unstructured) bool {
+	_, ok := s.keys[scopeKey(u.GroupVersionKind().GroupKind(), u.GetNamespace(), u.GetName())]
+	return ok
+}
+
+// Len returns the number of resources in the scope.
+func (s *Scope) Len() int {
+	return len(s.keys)
+}
+
+// subtreeResolver resolves the resources in the subtree of a claim or a
+// composite resource, and the resources they need to function: connection
+// secrets, ProviderConfigs and their credentials, XRDs, Compositions and the
+// packages that provide them.
+type subtreeResolver struct {
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+
+	crds  map[schema.GroupKind]apiextensionsv1.CustomResourceDefinition
+	scope *Scope
+}
+
+func newSubtreeResolver(dynamicClient dynamic.Interface, mapper meta.RESTMapper, crds []apiextensionsv1.CustomResourceDefinition) *subtreeResolver {
+	byGK := make(map[schema.GroupKind]apiextensionsv1.CustomResourceDefinition, len(crds))
+	for _, crd := range crds {
+		byGK[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = crd
+	}
+	return &subtreeResolver{
+		dynamicClient: dynamicClient,
+		mapper:        mapper,
+		crds:          byGK,
+		scope:         NewScope(),
+	}
+}
+
+// ResolveClaim resolves the scope for the claim given as "namespace/name".
+func (r *subtreeResolver) ResolveClaim(ctx context.Context, claim string) (*Scope, error) {
+	ns, name, ok := strings.Cut(claim, "/")
+	if !ok || ns == "" || name == "" {
+		return nil, errors.Errorf("invalid claim %q, must be in namespace/name format", claim)
+	}
+	u, err := r.findByCategory(ctx, "claim", ns, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.scope, r.addClaim(ctx, u)
+}
+
+// ResolveComposite resolves the scope for the composite resource with the
+// given name.
+func (r *subtreeResolver) ResolveComposite(ctx context.Context, name string) (*Scope, error) {
+	u, err := r.findByCategory(ctx, "composite", "", name)
+	if err != nil {
+		return nil, err
+	}
+	return r.scope, r.addComposite(ctx, u)
+}
+
+// findByCategory looks up the resource with the given namespace and name
+// among all types in the category.
+func (r *subtreeResolver) findByCategory(ctx context.Context, category, ns, name string) (*unstructured.Unstructured, error) {
+	var found []*unstructured.Unstructured
+	for gk, crd := range r.crds {
+		if !hasCategory(crd, category) {
+			continue
+		}
+		u, err := r.get(ctx, gk, storageVersion(crd), ns, name)
+		if kerrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, u)
+	}
+
+	ref := name
+	if ns != "" {
+		ref = ns + "/" + name
+	}
+	switch len(found) {
+	case 0:
+		return nil, errors.Errorf("cannot find %s %q", category, ref)
+	case 1:
+		return found[0], nil
+	default:
+		kinds := make([]string, 0, len(found))
+		for _, u := range found {
+			kinds = append(kinds, u.GroupVersionKind().GroupKind().String())
+		}
+		return nil, errors.Errorf("%s %q is ambiguous, found kinds %s", category, ref, strings.Join(kinds, ", "))
+	}
+}
+
+func (r *subtreeResolver) addClaim(ctx context.Context, u *unstructured.Unstructured) error {
+	if !r.add(u) {
+		return nil
+	}
+	r.scope.Add(gkNamespace, "", u.GetNamespace())
+	p := fieldpath.Pave(u.Object)
+	if s, err := p.GetString("spec.writeConnectionSecretToRef.name"); err == nil {
+		r.scope.Add(gkSecret, u.GetNamespace(), s)
+	}
+	if err := r.addDefinition(ctx, u.GroupVersionKind().GroupKind()); err != nil {
+		return err
+	}
+
+	ref := &corev1.ObjectReference{}
+	if err := p.GetValueInto("spec.resourceRef", ref); err != nil {
+		// The composite resource was not created yet.
+		return nil //nolint:nilerr // Not having a composite resource is fine.
+	}
+	xr, err := r.getRef(ctx, ref.APIVersion, ref.Kind, "", ref.Name)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get composite resource of claim %q", u.GetName())
+	}
+	return r.addComposite(ctx, xr)
+}
+
+func (r *subtreeResolver) addComposite(ctx context.Context, u *unstructured.Unstructured) error { //nolint:gocyclo // Following each kind of reference of a composite.
+	if !r.add(u) {
+		return nil
+	}
+	p := fieldpath.Pave(u.Object)
+	r.addSecretRef(p, "spec.writeConnectionSecretToRef", u.GetNamespace())
+	if err := r.addDefinition(ctx, u.GroupVersionKind().GroupKind()); err != nil {
+		return err
+	}
+	if name, err := p.GetString("spec.compositionRef.name"); err == nil {
+		if err := r.addComposition(ctx, name); err != nil {
+			return err
+		}
+	}
+	if name, err := p.GetString("spec.compositionRevisionRef.name"); err == nil {
+		r.scope.Add(gkCompositionRevision, "", name)
+	}
+
+	var refs []corev1.ObjectReference
+	if err := p.GetValueInto("spec.resourceRefs", &refs); err != nil && !fieldpath.IsNotFound(err) {
+		return errors.Wrapf(err, "cannot get resource references of %q", u.GetName())
+	}
+	for _, ref := range refs {
+		c, err := r.getRef(ctx, ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		if kerrors.IsNotFound(err) {
+			// The composed resource was not created yet, or is being deleted.
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot get composed resource %s %q", ref.Kind, ref.Name)
+		}
+		crd, ok := r.crds[c.GroupVersionKind().GroupKind()]
+		switch {
+		case ok && hasCategory(crd, "composite"):
+			err = r.addComposite(ctx, c)
+		case ok && hasCategory(crd, "managed"):
+			err = r.addManaged(ctx, c, crd)
+		default:
+			r.add(c)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *subtreeResolver) addManaged(ctx context.Context, u *unstructured.Unstructured, crd apiextensionsv1.CustomResourceDefinition) error {
+	if !r.add(u) {
+		return nil
+	}
+	p := fieldpath.Pave(u.Object)
+	r.addSecretRef(p, "spec.writeConnectionSecretToRef", "")
+	if err := r.addPackageOwners(ctx, crd.GetOwnerReferences()); err != nil {
+		return err
+	}
+
+	name, err := p.GetString("spec.providerConfigRef.name")
+	if err != nil {
+		return nil //nolint:nilerr // Not all managed resources reference a ProviderConfig.
+	}
+	pcCRD, ok := r.providerConfigFor(crd)
+	if !ok {
+		return nil
+	}
+	pc, err := r.get(ctx, schema.GroupKind{Group: pcCRD.Spec.Group, Kind: pcCRD.Spec.Names.Kind}, storageVersion(pcCRD), "", name)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot get ProviderConfig %q", name)
+	}
+	if r.add(pc) {
+		r.addSecretRef(fieldpath.Pave(pc.Object), "spec.credentials.secretRef", "")
+	}
+	return nil
+}
+
+// providerConfigFor returns the CRD of the ProviderConfig type installed by
+// the same package as the managed resource CRD.
+func (r *subtreeResolver) providerConfigFor(mr apiextensionsv1.CustomResourceDefinition) (apiextensionsv1.CustomResourceDefinition, bool) {
+	owners := make(map[string]struct{}, len(mr.GetOwnerReferences()))
+	for _, ref := range mr.GetOwnerReferences() {
+		owners[string(ref.UID)] = struct{}{}
+	}
+	for _, crd := range r.crds {
+		if crd.Spec.Names.Kind != "ProviderConfig" {
+			continue
+		}
+		for _, ref := range crd.GetOwnerReferences() {
+			if _, ok := owners[string(ref.UID)]; ok {
+				return crd, true
+			}
+		}
+	}
+	return apiextensionsv1.CustomResourceDefinition{}, false
+}
+
+// addDefinition adds the XRD that defines the composite or claim type, and the
+// packages that installed it.
+func (r *subtreeResolver) addDefinition(ctx context.Context, gk schema.GroupKind) error {
+	crd, ok := r.crds[gk]
+	if !ok {
+		return nil
+	}
+	for _, ref := range crd.GetOwnerReferences() {
+		if ref.Kind != gkXRD.Kind || !strings.HasPrefix(ref.APIVersion, xpGroup+"/") {
+			continue
+		}
+		xrd, err := r.getRef(ctx, ref.APIVersion, ref.Kind, "", ref.Name)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get CompositeResourceDefinition %q", ref.Name)
+		}
+		if r.add(xrd) {
+			if err := r.addPackageOwners(ctx, xrd.GetOwnerReferences()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addComposition adds the Composition, the Functions used in its pipeline and
+// the packages that installed it.
+func (r *subtreeResolver) addComposition(ctx context.Context, name string) error {
+	c, err := r.get(ctx, gkComposition, "", "", name)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot get Composition %q", name)
+	}
+	if !r.add(c) {
+		return nil
+	}
+
+	var steps []struct {
+		FunctionRef struct {
+			Name string `json:"name"`
+		} `json:"functionRef"`
+	}
+	if err := fieldpath.Pave(c.Object).GetValueInto("spec.pipeline", &steps); err != nil && !fieldpath.IsNotFound(err) {
+		return errors.Wrapf(err, "cannot get pipeline of Composition %q", name)
+	}
+	for _, s := range steps {
+		r.scope.Add(gkFunction, "", s.FunctionRef.Name)
+	}
+	return r.addPackageOwners(ctx, c.GetOwnerReferences())
+}
+
+// addPackageOwners adds the packages among the owners, following package
+// revisions to the package that owns them.
+func (r *subtreeResolver) addPackageOwners(ctx context.Context, refs []v1.OwnerReference) error {
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref.APIVersion, pkgGroup+"/") {
+			continue
+		}
+		if !strings.HasSuffix(ref.Kind, "Revision") {
+			r.scope.Add(schema.GroupKind{Group: pkgGroup, Kind: ref.Kind}, "", ref.Name)
+			continue
+		}
+		rev, err := r.getRef(ctx, ref.APIVersion, ref.Kind, "", ref.Name)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot get package revision %q", ref.Name)
+		}
+		if err := r.addPackageOwners(ctx, rev.GetOwnerReferences()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSecretRef adds the Secret referenced at the path. The namespace of the
+// Secret defaults to the given namespace if the reference has none.
+func (r *subtreeResolver) addSecretRef(p *fieldpath.Paved, path, namespace string) {
+	name, err := p.GetString(path + ".name")
+	if err != nil {
+		return
+	}
+	if ns, err := p.GetString(path + ".namespace"); err == nil {
+		namespace = ns
+	}
+	if namespace == "" {
+		return
+	}
+	r.scope.Add(gkSecret, namespace, name)
+	r.scope.Add(gkNamespace, "", namespace)
+}
+
+// add adds the resource to the scope, returning false if it was already added.
+func (r *subtreeResolver) add(u *unstructured.Unstructured) bool {
+	return r.scope.Add(u.GroupVersionKind().GroupKind(), u.GetNamespace(), u.GetName())
+}
+
+func (r *subtreeResolver) getRef(ctx context.Context, apiVersion, kind, ns, name string) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse API version %q", apiVersion)
+	}
+	return r.get(ctx, gv.WithKind(kind).GroupKind(), gv.Version, ns, name)
+}
+
+func (r *subtreeResolver) get(ctx context.Context, gk schema.GroupKind, version, ns, name string) (*unstructured.Unstructured, error) {
+	var versions []string
+	if version != "" {
+		versions = append(versions, version)
+	}
+	rm, err := r.mapper.RESTMapping(gk, versions...)
+	if err != nil {
+		return nil, err
+	}
+	if rm.Scope.Name() != meta.RESTScopeNameNamespace {
+		ns = ""
+	}
+	return r.dynamicClient.Resource(rm.Resource).Namespace(ns).Get(ctx, name, v1.GetOptions{})
+}
+
+func hasCategory(crd apiextensionsv1.CustomResourceDefinition, category string) bool {
+	for _, c := range crd.Spec.Names.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func storageVersion(crd apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
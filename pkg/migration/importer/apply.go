@@ -19,6 +19,8 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -28,6 +30,7 @@ import (
 
 type ResourceApplier interface {
 	ApplyResources(ctx context.Context, resources []unstructured.Unstructured, applyStatus bool) error
+	ApplyChangedResources(ctx context.Context, resources []unstructured.Unstructured, applyStatus bool) (int, error)
 	ModifyResources(ctx context.Context, resources []unstructured.Unstructured, modify func(*unstructured.Unstructured) error) error
 }
 
@@ -78,6 +81,70 @@ func (a *UnstructuredResourceApplier) ApplyResources(ctx context.Context, resour
 	return nil
 }
 
+// ApplyChangedResources applies the resources that do not exist on the target,
+// or whose spec differs from the resource on the target. Only the spec fields
+// present in the archived resource are compared, so fields defaulted by the
+// API server are not considered a difference. It returns the number of applied
+// resources.
+func (a *UnstructuredResourceApplier) ApplyChangedResources(ctx context.Context, resources []unstructured.Unstructured, applyStatus bool) (int, error) {
+	changed := make([]unstructured.Unstructured, 0, len(resources))
+	for i := range resources {
+		rm, err := a.resourceMapper.RESTMapping(resources[i].GroupVersionKind().GroupKind(), resources[i].GroupVersionKind().Version)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot get REST mapping for resource %s/%s", resources[i].GetKind(), resources[i].GetName())
+		}
+		var current *unstructured.Unstructured
+		err = retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
+			current, err = a.dynamicClient.Resource(rm.Resource).Namespace(resources[i].GetNamespace()).Get(ctx, resources[i].GetName(), v1.GetOptions{})
+			if kerrors.IsNotFound(err) {
+				current = nil
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot get resource %s/%s", resources[i].GetKind(), resources[i].GetName())
+		}
+		if current == nil || !isSubset(resources[i].Object["spec"], current.Object["spec"]) {
+			changed = append(changed, resources[i])
+		}
+	}
+	return len(changed), a.ApplyResources(ctx, changed, applyStatus)
+}
+
+// isSubset returns true if every field set in want has the same value in got.
+// Fields of got that are not set in want, for example defaulted fields, are
+// ignored. Lists must have the same length and their items are compared in
+// order.
+func isSubset(want, got any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return len(w) == 0 && got == nil
+		}
+		for k, v := range w {
+			if !isSubset(v, g[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(w) != len(g) {
+			return len(w) == 0 && got == nil
+		}
+		for i := range w {
+			if !isSubset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(want, got)
+	}
+}
+
 func (a *UnstructuredResourceApplier) ModifyResources(ctx context.Context, resources []unstructured.Unstructured, modify func(*unstructured.Unstructured) error) error {
 	for i := range resources {
 		err := retry.OnError(retry.DefaultRetry, resource.IsAPIError, func() error {
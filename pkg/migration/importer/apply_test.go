@@ -0,0 +1,85 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsSubset(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		want   any
+		got    any
+		result bool
+	}{
+		"Equal": {
+			reason: "Equal specs should be a subset.",
+			want:   map[string]any{"region": "us-east-1", "size": int64(2)},
+			got:    map[string]any{"region": "us-east-1", "size": int64(2)},
+			result: true,
+		},
+		"DefaultedFields": {
+			reason: "Fields set by the API server but not in the archive should be ignored.",
+			want:   map[string]any{"forProvider": map[string]any{"region": "us-east-1"}},
+			got: map[string]any{
+				"forProvider":        map[string]any{"region": "us-east-1", "tags": map[string]any{"crossplane-kind": "bucket"}},
+				"deletionPolicy":     "Delete",
+				"managementPolicies": []any{"*"},
+			},
+			result: true,
+		},
+		"ChangedField": {
+			reason: "A field with a different value should not be a subset.",
+			want:   map[string]any{"forProvider": map[string]any{"region": "us-east-1"}},
+			got:    map[string]any{"forProvider": map[string]any{"region": "us-west-2"}},
+			result: false,
+		},
+		"MissingField": {
+			reason: "A field that is not set on the target should not be a subset.",
+			want:   map[string]any{"forProvider": map[string]any{"region": "us-east-1"}},
+			got:    map[string]any{"forProvider": map[string]any{}},
+			result: false,
+		},
+		"ListItemDefaults": {
+			reason: "Fields defaulted in list items should be ignored.",
+			want:   map[string]any{"rules": []any{map[string]any{"port": int64(80)}}},
+			got:    map[string]any{"rules": []any{map[string]any{"port": int64(80), "protocol": "TCP"}}},
+			result: true,
+		},
+		"ListLength": {
+			reason: "Lists of a different length should not be a subset.",
+			want:   map[string]any{"rules": []any{"a"}},
+			got:    map[string]any{"rules": []any{"a", "b"}},
+			result: false,
+		},
+		"NoSpec": {
+			reason: "A resource without a spec should be a subset of any spec.",
+			want:   nil,
+			got:    nil,
+			result: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := isSubset(tc.want, tc.got)
+			if diff := cmp.Diff(tc.result, got); diff != "" {
+				t.Errorf("\n%s\nisSubset(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/upbound/up/pkg/migration"
+)
+
+// ChangeType is the type of change an import would make to a resource.
+type ChangeType string
+
+const (
+	// ChangeCreate means the resource does not exist on the target and would
+	// be created.
+	ChangeCreate ChangeType = "Create"
+	// ChangeUpdate means the resource exists on the target and would be
+	// changed.
+	ChangeUpdate ChangeType = "Update"
+	// ChangeConflict means the resource exists on the target and importing it
+	// would take over fields owned by another manager, or would be rejected.
+	ChangeConflict ChangeType = "Conflict"
+)
+
+// ResourceChange is a change an import would make to a resource on the
+// target control plane.
+type ResourceChange struct {
+	// Type of the change.
+	Type ChangeType
+	// Current is the resource on the target, nil if it does not exist.
+	Current *unstructured.Unstructured
+	// Desired is the resource as it would be after import.
+	Desired *unstructured.Unstructured
+	// Reason explains why the resource is conflicting.
+	Reason string
+}
+
+// DryRun compares each resource in the archive with the target control plane
+// using a server-side dry-run apply, and returns the changes an import would
+// make. Resources that would not change are omitted. Nothing is changed on
+// the target control plane.
+func (im *ControlPlaneStateImporter) DryRun(ctx context.Context) ([]ResourceChange, error) {
+	unarchiveMsg := "Reading state from the archive... "
+	s, _ := migration.DefaultSpinner.Start(unarchiveMsg)
+
+	if im.fs == nil {
+		im.fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+
+		if err := im.unarchive(ctx, *im.fs); err != nil {
+			s.Fail(unarchiveMsg + stepFailed)
+			return nil, errors.Wrap(err, "cannot unarchive export archive")
+		}
+	}
+	s.Success(unarchiveMsg + "Done! 👀")
+
+	if err := im.loadTransformer(); err != nil {
+		return nil, err
+	}
+
+	grs, err := im.fs.ReadDir("/")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list group resources")
+	}
+	// Compare the base resources first, in the same order they are imported.
+	order := make([]string, 0, len(grs))
+	order = append(order, baseResources...)
+	for _, info := range grs {
+		if info.IsDir() && !isBaseResource(info.Name()) {
+			order = append(order, info.Name())
+		}
+	}
+
+	compareMsg := "Comparing with the target control plane... "
+	s, _ = migration.DefaultSpinner.Start(compareMsg)
+	r := NewFileSystemReader(*im.fs)
+	var changes []ResourceChange
+	for i, gr := range order {
+		if ok, _ := im.fs.DirExists(gr); !ok {
+			continue
+		}
+		s.UpdateText(fmt.Sprintf("(%d / %d) Comparing %s...", i+1, len(order), gr))
+
+		resources, _, err := r.ReadResources(gr)
+		if err != nil {
+			s.Fail(compareMsg + stepFailed)
+			return nil, errors.Wrapf(err, "cannot read %q resources", gr)
+		}
+		for j := range resources {
+			if err := im.transformer.Transform(&resources[j]); err != nil {
+				s.Fail(compareMsg + stepFailed)
+				return nil, errors.Wrapf(err, "cannot transform %q resources", gr)
+			}
+			c, err := im.compare(ctx, &resources[j])
+			if err != nil {
+				s.Fail(compareMsg + stepFailed)
+				return nil, errors.Wrapf(err, "cannot compare resource %s/%s", resources[j].GetKind(), resources[j].GetName())
+			}
+			if c != nil {
+				changes = append(changes, *c)
+			}
+		}
+	}
+	s.Success(compareMsg + fmt.Sprintf("%d resources would change! 🔍", len(changes)))
+
+	return changes, nil
+}
+
+// compare returns the change importing the resource would make, or nil if it
+// would not change.
+func (im *ControlPlaneStateImporter) compare(ctx context.Context, u *unstructured.Unstructured) (*ResourceChange, error) {
+	gvk := u.GroupVersionKind()
+	rm, err := im.resourceMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The type is not known to the target yet, it would be introduced by a
+		// package or an XRD during import.
+		return &ResourceChange{Type: ChangeCreate, Desired: u}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get REST mapping for %q", gvk)
+	}
+	ri := im.dynamicClient.Resource(rm.Resource).Namespace(u.GetNamespace())
+
+	current, err := ri.Get(ctx, u.GetName(), v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		current = nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "cannot get resource from the target control plane")
+	}
+
+	desired, err := ri.Apply(ctx, u.GetName(), u, v1.ApplyOptions{
+		FieldManager: "up-controlplane-migrator",
+		DryRun:       []string{v1.DryRunAll},
+	})
+	switch {
+	case current == nil && err != nil:
+		// The resource may depend on others, like its namespace, that would be
+		// created before it during import.
+		return &ResourceChange{Type: ChangeCreate, Desired: u}, nil
+	case current == nil:
+		return &ResourceChange{Type: ChangeCreate, Desired: withoutClusterSpecificData(desired)}, nil
+	case err != nil:
+		return &ResourceChange{Type: ChangeConflict, Current: withoutClusterSpecificData(current), Desired: u, Reason: err.Error()}, nil
+	}
+
+	current, desired = withoutClusterSpecificData(current), withoutClusterSpecificData(desired)
+	if equality.Semantic.DeepEqual(current.Object, desired.Object) {
+		return nil, nil
+	}
+	return &ResourceChange{Type: ChangeUpdate, Current: current, Desired: desired}, nil
+}
+
+// withoutClusterSpecificData returns a copy of the resource without the fields
+// that are specific to the cluster it lives in, or that are not imported.
+func withoutClusterSpecificData(u *unstructured.Unstructured) *unstructured.Unstructured {
+	c := u.DeepCopy()
+	paved := fieldpath.Pave(c.Object)
+	for _, f := range []string{"metadata.generateName", "metadata.selfLink", "metadata.uid", "metadata.resourceVersion", "metadata.generation", "metadata.creationTimestamp", "metadata.ownerReferences", "metadata.managedFields", "status"} {
+		// Deleting a field that does not exist is not an error.
+		_ = paved.DeleteField(f)
+	}
+	return c
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -44,7 +45,9 @@ import (
 	"github.com/upbound/up/pkg/migration"
 	"github.com/upbound/up/pkg/migration/category"
 	"github.com/upbound/up/pkg/migration/crossplane"
+	"github.com/upbound/up/pkg/migration/encryption"
 	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+	"github.com/upbound/up/pkg/migration/transform"
 )
 
 const (
@@ -80,6 +83,21 @@ type Options struct {
 	InputArchive string // default: xp-state.tar.gz
 	// UnpauseAfterImport indicates whether to unpause all managed resources after import.
 	UnpauseAfterImport bool // default: false
+	// DecryptKey is the path to a file containing age identities or an armored
+	// PGP private key, used to decrypt an encrypted archive.
+	DecryptKey string // default: none
+	// DecryptPassphrase is the passphrase used to decrypt an archive encrypted
+	// with a passphrase, or to unlock an encrypted PGP private key.
+	DecryptPassphrase string // default: none
+	// TransformRules is the path to a file with transformation rules applied
+	// to each imported resource.
+	TransformRules string // default: none
+	// ProgressFile is the path to the file recording the progress of the
+	// import.
+	ProgressFile string // default: <InputArchive>.progress.yaml
+	// Resume indicates whether to resume an interrupted import from the
+	// progress file, skipping the group resources that were already imported.
+	Resume bool // default: false
 }
 
 // ControlPlaneStateImporter is the importer for control plane state.
@@ -88,21 +106,40 @@ type ControlPlaneStateImporter struct {
 	discoveryClient discovery.DiscoveryInterface
 	appsClient      appsv1.AppsV1Interface
 	resourceMapper  meta.ResettableRESTMapper
+	transformer     *transform.Transformer
 
 	fs *afero.Afero
 
+	// dangling are the owner references that could not be restored.
+	dangling []DanglingOwnerReference
+
 	options Options
 }
 
+// ControlPlaneStateImporterOption configures a ControlPlaneStateImporter.
+type ControlPlaneStateImporterOption func(im *ControlPlaneStateImporter)
+
+// WithState imports the state from the given file system, in the directory
+// structure of an unarchived export, instead of reading the input archive.
+func WithState(fs afero.Afero) ControlPlaneStateImporterOption {
+	return func(im *ControlPlaneStateImporter) {
+		im.fs = &fs
+	}
+}
+
 // NewControlPlaneStateImporter creates a new importer for control plane state.
-func NewControlPlaneStateImporter(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, appsClient appsv1.AppsV1Interface, mapper meta.ResettableRESTMapper, opts Options) *ControlPlaneStateImporter {
-	return &ControlPlaneStateImporter{
+func NewControlPlaneStateImporter(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, appsClient appsv1.AppsV1Interface, mapper meta.ResettableRESTMapper, opts Options, iopts ...ControlPlaneStateImporterOption) *ControlPlaneStateImporter {
+	im := &ControlPlaneStateImporter{
 		dynamicClient:   dynamicClient,
 		discoveryClient: discoveryClient,
 		appsClient:      appsClient,
 		resourceMapper:  mapper,
 		options:         opts,
 	}
+	for _, o := range iopts {
+		o(im)
+	}
+	return im
 }
 
 // Import imports the control plane state.
@@ -128,7 +165,29 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 
 	// Pausing resource importer will import all resources.
 	// It will import all Claims, Composites and Managed resource with the `crossplane.io/paused` annotation set to `true`.
-	r := NewPausingResourceImporter(NewFileSystemReader(*im.fs), NewUnstructuredResourceApplier(im.dynamicClient, im.resourceMapper))
+	em, err := readExportMeta(*im.fs)
+	if err != nil {
+		return err
+	}
+	progressFile := im.options.ProgressFile
+	if progressFile == "" {
+		progressFile = im.options.InputArchive + progressFileSuffix
+	}
+	progressFS := afero.Afero{Fs: afero.NewOsFs()}
+	if im.options.InputArchive == "" {
+		// The state was not read from an archive, so there is nothing to
+		// resume from later. Track the progress in memory only.
+		progressFS = afero.Afero{Fs: afero.NewMemMapFs()}
+	}
+	progress, err := NewProgressTracker(progressFS, progressFile, em, im.options.Resume)
+	if err != nil {
+		return errors.Wrap(err, "cannot load import progress")
+	}
+
+	if err := im.loadTransformer(); err != nil {
+		return err
+	}
+	r := NewPausingResourceImporter(NewFileSystemReader(*im.fs), NewUnstructuredResourceApplier(im.dynamicClient, im.resourceMapper), WithTransformer(im.transformer))
 
 	// Import base resources which are defined with the `baseResources` variable.
 	// They could be considered as the custom or native resources that do not depend on any packages (e.g. Managed Resources) or XRDs (e.g. Claims/Composites).
@@ -136,12 +195,28 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 	importBaseMsg := "Importing base resources... "
 	s, _ = migration.DefaultSpinner.Start(importBaseMsg + fmt.Sprintf("0 / %d", len(baseResources)))
 	baseCounts := make(map[string]int, len(baseResources))
+	fixed := 0
 	for i, gr := range baseResources {
+		if progress.Imported(gr) {
+			// Already imported before the import was interrupted, only apply
+			// the resources that drifted from the archive again.
+			count, err := r.FixupResources(ctx, gr, false)
+			if err != nil {
+				s.Fail(importBaseMsg + stepFailed)
+				return errors.Wrapf(err, "cannot fix up %q resources", gr)
+			}
+			fixed += count
+			continue
+		}
 		count, err := r.ImportResources(ctx, gr, false)
 		if err != nil {
 			s.Fail(importBaseMsg + stepFailed)
 			return errors.Wrapf(err, "cannot import %q resources", gr)
 		}
+		if err := progress.MarkImported(gr); err != nil {
+			s.Fail(importBaseMsg + stepFailed)
+			return err
+		}
 		s.UpdateText(fmt.Sprintf("(%d / %d) Importing %s...", i, len(baseResources), gr))
 		baseCounts[gr] = count
 	}
@@ -149,7 +224,7 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 	for _, count := range baseCounts {
 		total += count
 	}
-	s.Success(importBaseMsg + fmt.Sprintf("%d resources imported! 📥", total))
+	s.Success(importBaseMsg + fmt.Sprintf("%d resources imported%s! 📥", total, fixedUp(fixed)))
 	//////////////////////////////////////////
 
 	// Wait for all XRDs and Packages to be ready before importing the resources that depend on them.
@@ -205,9 +280,11 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 		return errors.Wrap(err, "cannot list group resources")
 	}
 	remainingCounts := make(map[string]int, len(grs))
+	fixed = 0
 	for i, info := range grs {
-		if info.Name() == "export.yaml" {
-			// This is the top level export metadata file, so nothing to import.
+		if info.Name() == "export.yaml" || info.Name() == "owners.yaml" {
+			// These are the top level export metadata and owner graph files,
+			// so nothing to import.
 			continue
 		}
 		if !info.IsDir() {
@@ -219,10 +296,22 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 			continue
 		}
 
+		if progress.Imported(info.Name()) {
+			count, err := r.FixupResources(ctx, info.Name(), true)
+			if err != nil {
+				return errors.Wrapf(err, "cannot fix up %q resources", info.Name())
+			}
+			fixed += count
+			continue
+		}
+
 		count, err := r.ImportResources(ctx, info.Name(), true)
 		if err != nil {
 			return errors.Wrapf(err, "cannot import %q resources", info.Name())
 		}
+		if err := progress.MarkImported(info.Name()); err != nil {
+			return err
+		}
 		remainingCounts[info.Name()] = count
 		s.UpdateText(fmt.Sprintf("(%d / %d) Importing %s...", i, len(grs), info.Name()))
 	}
@@ -231,7 +320,24 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 		total += count
 	}
 
-	s.Success(importRemainingMsg + fmt.Sprintf("%d resources imported! 📥", total))
+	s.Success(importRemainingMsg + fmt.Sprintf("%d resources imported%s! 📥", total, fixedUp(fixed)))
+	//////////////////////////////////////////
+
+	// Owner references were removed at export, since they refer to owners by UID. Restore them from the owner graph
+	// of the export with the UIDs of the imported owners, before Claims/Composites are unpaused.
+	ownersMsg := "Restoring owner references... "
+	s, _ = migration.DefaultSpinner.Start(ownersMsg)
+	restored, dangling, err := im.restoreOwnerReferences(ctx)
+	if err != nil {
+		s.Fail(ownersMsg + stepFailed)
+		return errors.Wrap(err, "cannot restore owner references")
+	}
+	im.dangling = dangling
+	if len(dangling) > 0 {
+		s.Success(ownersMsg + fmt.Sprintf("%d owner references restored, %d could not be restored", restored, len(dangling)))
+	} else {
+		s.Success(ownersMsg + fmt.Sprintf("%d owner references restored! 🔗", restored))
+	}
 	//////////////////////////////////////////
 
 	// At this stage, all the resources are imported, but Claims/Composites and Managed resources are paused.
@@ -270,7 +376,22 @@ func (im *ControlPlaneStateImporter) Import(ctx context.Context) error { // noli
 	}
 	//////////////////////////////////////////
 
-	return nil
+	return progress.Done()
+}
+
+// DanglingOwnerReferences returns the owner references recorded in the export
+// that could not be restored by the last import.
+func (im *ControlPlaneStateImporter) DanglingOwnerReferences() []DanglingOwnerReference {
+	return im.dangling
+}
+
+// fixedUp returns a note about the number of resources that were applied
+// again while resuming an import.
+func fixedUp(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d resources fixed up", count)
 }
 
 func (im *ControlPlaneStateImporter) PreflightChecks(ctx context.Context) []error {
@@ -322,9 +443,18 @@ func contains(ss []string, s string) bool {
 }
 
 func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afero) error {
-	g, err := os.Open(im.options.InputArchive)
+	if err := Unarchive(ctx, im.options.InputArchive, fs); err != nil {
+		return err
+	}
+	return im.decrypt(fs)
+}
+
+// Unarchive extracts the export archive at the given path into the file
+// system. Encrypted resources are left encrypted.
+func Unarchive(ctx context.Context, archive string, fs afero.Afero) error {
+	g, err := os.Open(archive)
 	if err != nil {
-		return errors.Wrapf(err, "cannot open archive %q", im.options.InputArchive)
+		return errors.Wrapf(err, "cannot open archive %q", archive)
 	}
 	defer func() {
 		_ = g.Close()
@@ -332,7 +462,7 @@ func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afe
 
 	gr, err := gzip.NewReader(g)
 	if err != nil {
-		return errors.Wrapf(err, "cannot create gzip reader for %q", im.options.InputArchive)
+		return errors.Wrapf(err, "cannot create gzip reader for %q", archive)
 	}
 	defer gr.Close()
 
@@ -349,7 +479,7 @@ func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afe
 			break // End of archive
 		}
 		if err != nil {
-			return errors.Wrapf(err, "cannot read archive %q", im.options.InputArchive)
+			return errors.Wrapf(err, "cannot read archive %q", archive)
 		}
 
 		if hdr.FileInfo().IsDir() {
@@ -373,6 +503,77 @@ func (im *ControlPlaneStateImporter) unarchive(ctx context.Context, fs afero.Afe
 	return nil
 }
 
+// decrypt decrypts the resources in the unarchived state in place if the
+// export metadata records that they were encrypted.
+func (im *ControlPlaneStateImporter) decrypt(fs afero.Afero) error {
+	em, err := readExportMeta(fs)
+	if err != nil {
+		return err
+	}
+	if em.Encryption == nil {
+		return nil
+	}
+
+	d, err := encryption.NewDecrypter(encryption.Scheme(em.Encryption.Scheme), im.options.DecryptKey, im.options.DecryptPassphrase)
+	if err != nil {
+		return errors.Wrap(err, "archive is encrypted")
+	}
+
+	for _, gr := range em.Encryption.Resources {
+		if ok, _ := fs.DirExists(gr); !ok {
+			continue
+		}
+		err := fs.Walk(gr, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Base(path) == "metadata.yaml" {
+				return nil
+			}
+			b, err := fs.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "cannot read file %q", path)
+			}
+			if b, err = d.Decrypt(b); err != nil {
+				return errors.Wrapf(err, "cannot decrypt file %q", path)
+			}
+			return errors.Wrapf(fs.WriteFile(path, b, 0600), "cannot write file %q", path)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot decrypt %q resources", gr)
+		}
+	}
+
+	return nil
+}
+
+// readExportMeta reads the top level export metadata from the unarchived
+// state.
+func readExportMeta(fs afero.Afero) (*v1alpha1.ExportMeta, error) {
+	b, err := fs.ReadFile("export.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read export metadata")
+	}
+	em := &v1alpha1.ExportMeta{}
+	if err = yaml.Unmarshal(b, em); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal export metadata")
+	}
+	return em, nil
+}
+
+// loadTransformer loads the transformation rules, if any.
+func (im *ControlPlaneStateImporter) loadTransformer() error {
+	if im.options.TransformRules == "" || im.transformer != nil {
+		return nil
+	}
+	t, err := transform.Load(im.options.TransformRules)
+	if err != nil {
+		return errors.Wrap(err, "cannot load transformation rules")
+	}
+	im.transformer = t
+	return nil
+}
+
 func isBaseResource(gr string) bool {
 	for _, k := range baseResources {
 		if k == gr {
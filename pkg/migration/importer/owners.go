@@ -0,0 +1,201 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"gopkg.in/yaml.v3"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+// DanglingOwnerReference is a recorded owner reference that could not be
+// restored, since the owner or the owned resource does not exist on the target
+// control plane.
+type DanglingOwnerReference struct {
+	// Owned is the resource the reference could not be restored on.
+	Owned v1alpha1.OwnedResource
+	// Owner is the owner of the reference.
+	Owner v1alpha1.OwnerReference
+	// OwnedMissing is true if the owned resource is missing, rather than
+	// the owner.
+	OwnedMissing bool
+}
+
+func (d DanglingOwnerReference) String() string {
+	owned := d.Owned.Name
+	if d.Owned.Namespace != "" {
+		owned = d.Owned.Namespace + "/" + owned
+	}
+	if d.OwnedMissing {
+		return fmt.Sprintf("missing %s %s is owned by %s %s", d.Owned.Kind, owned, d.Owner.Kind, d.Owner.Name)
+	}
+	return fmt.Sprintf("%s %s is owned by missing %s %s", d.Owned.Kind, owned, d.Owner.Kind, d.Owner.Name)
+}
+
+// restoreOwnerReferences sets the owner references recorded in the owner graph
+// of the export on the imported resources, using the UIDs of the owners on the
+// target control plane. It returns the number of restored owner references and
+// the references whose owners do not exist.
+func (im *ControlPlaneStateImporter) restoreOwnerReferences(ctx context.Context) (int, []DanglingOwnerReference, error) {
+	b, err := im.fs.ReadFile("owners.yaml")
+	if errors.Is(err, os.ErrNotExist) {
+		// The export has no resources with owners.
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "cannot read owner graph")
+	}
+	graph := &v1alpha1.OwnerGraph{}
+	if err := yaml.Unmarshal(b, graph); err != nil {
+		return 0, nil, errors.Wrap(err, "cannot unmarshal owner graph")
+	}
+
+	restored := 0
+	var dangling []DanglingOwnerReference
+	for _, owned := range graph.Resources {
+		refs := make([]v1.OwnerReference, 0, len(owned.Owners))
+		for _, o := range owned.Owners {
+			uid, err := im.ownerUID(ctx, owned, o)
+			if kerrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				dangling = append(dangling, DanglingOwnerReference{Owned: owned, Owner: o})
+				continue
+			}
+			if err != nil {
+				return 0, nil, errors.Wrapf(err, "cannot get owner %s %q", o.Kind, o.Name)
+			}
+			refs = append(refs, v1.OwnerReference{
+				APIVersion:         o.APIVersion,
+				Kind:               o.Kind,
+				Name:               o.Name,
+				UID:                uid,
+				Controller:         o.Controller,
+				BlockOwnerDeletion: o.BlockOwnerDeletion,
+			})
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		n, err := im.setOwnerReferences(ctx, owned, refs)
+		if kerrors.IsNotFound(err) {
+			// The owned resource was not imported, e.g. since it was
+			// transformed.
+			for _, o := range owned.Owners {
+				dangling = append(dangling, DanglingOwnerReference{Owned: owned, Owner: o, OwnedMissing: true})
+			}
+			continue
+		}
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "cannot restore owner references of %s %q", owned.Kind, owned.Name)
+		}
+		restored += n
+	}
+
+	return restored, dangling, nil
+}
+
+// ownerUID returns the UID of the owner of the owned resource on the target.
+// Namespaced owners are in the namespace of the owned resource.
+func (im *ControlPlaneStateImporter) ownerUID(ctx context.Context, owned v1alpha1.OwnedResource, o v1alpha1.OwnerReference) (types.UID, error) {
+	gv, err := schema.ParseGroupVersion(o.APIVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot parse API version %q", o.APIVersion)
+	}
+	rm, err := im.resourceMapper.RESTMapping(gv.WithKind(o.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return "", err
+	}
+	ns := ""
+	if rm.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns = owned.Namespace
+	}
+	u, err := im.dynamicClient.Resource(rm.Resource).Namespace(ns).Get(ctx, o.Name, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return u.GetUID(), nil
+}
+
+// setOwnerReferences merges the owner references into the existing owner
+// references of the owned resource, and returns the number of references that
+// were added or changed. A NotFound error is returned if the owned resource
+// does not exist.
+func (im *ControlPlaneStateImporter) setOwnerReferences(ctx context.Context, owned v1alpha1.OwnedResource, refs []v1.OwnerReference) (int, error) {
+	gv, err := schema.ParseGroupVersion(owned.APIVersion)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse API version %q", owned.APIVersion)
+	}
+	rm, err := im.resourceMapper.RESTMapping(gv.WithKind(owned.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot get REST mapping for %q", owned.Kind)
+	}
+	ri := im.dynamicClient.Resource(rm.Resource).Namespace(owned.Namespace)
+
+	changed := 0
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		u, err := ri.Get(ctx, owned.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		var merged []v1.OwnerReference
+		merged, changed = mergeOwnerReferences(u.GetOwnerReferences(), refs)
+		if changed == 0 {
+			return nil
+		}
+		u.SetOwnerReferences(merged)
+		_, err = ri.Update(ctx, u, v1.UpdateOptions{})
+		return err
+	})
+	return changed, err
+}
+
+// mergeOwnerReferences merges the restored owner references into the existing
+// ones, replacing existing references to the same owner. It returns the merged
+// references and the number of restored references that were added or
+// changed.
+func mergeOwnerReferences(existing, restored []v1.OwnerReference) ([]v1.OwnerReference, int) {
+	merged := append([]v1.OwnerReference{}, existing...)
+	changed := 0
+	for _, r := range restored {
+		found := false
+		for i, e := range merged {
+			if e.APIVersion != r.APIVersion || e.Kind != r.Kind || e.Name != r.Name {
+				continue
+			}
+			found = true
+			if e.UID != r.UID {
+				merged[i] = r
+				changed++
+			}
+			break
+		}
+		if !found {
+			merged = append(merged, r)
+			changed++
+		}
+	}
+	return merged, changed
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestMergeOwnerReferences(t *testing.T) {
+	xr := func(uid string) v1.OwnerReference {
+		return v1.OwnerReference{APIVersion: "example.org/v1", Kind: "XBucket", Name: "xr", UID: types.UID("uid-" + uid)}
+	}
+	usage := v1.OwnerReference{APIVersion: "apiextensions.crossplane.io/v1alpha1", Kind: "Usage", Name: "u", UID: "uid-usage"}
+
+	type args struct {
+		existing []v1.OwnerReference
+		restored []v1.OwnerReference
+	}
+	type want struct {
+		merged  []v1.OwnerReference
+		changed int
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Add": {
+			reason: "Missing owner references should be added.",
+			args:   args{existing: []v1.OwnerReference{usage}, restored: []v1.OwnerReference{xr("new")}},
+			want:   want{merged: []v1.OwnerReference{usage, xr("new")}, changed: 1},
+		},
+		"Replace": {
+			reason: "Owner references to the same owner with a different UID should be replaced.",
+			args:   args{existing: []v1.OwnerReference{xr("old"), usage}, restored: []v1.OwnerReference{xr("new")}},
+			want:   want{merged: []v1.OwnerReference{xr("new"), usage}, changed: 1},
+		},
+		"Unchanged": {
+			reason: "Owner references that are already set should not be counted as changed.",
+			args:   args{existing: []v1.OwnerReference{xr("new")}, restored: []v1.OwnerReference{xr("new")}},
+			want:   want{merged: []v1.OwnerReference{xr("new")}, changed: 0},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			merged, changed := mergeOwnerReferences(tc.args.existing, tc.args.restored)
+			if diff := cmp.Diff(tc.want.merged, merged); diff != "" {
+				t.Errorf("\n%s\nmergeOwnerReferences(...): -want merged, +got merged:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.changed, changed); diff != "" {
+				t.Errorf("\n%s\nmergeOwnerReferences(...): -want changed, +got changed:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
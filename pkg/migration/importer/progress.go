@@ -0,0 +1,107 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"os"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+// progressFileSuffix is appended to the input archive path to get the default
+// progress file path.
+const progressFileSuffix = ".progress.yaml"
+
+// ProgressTracker keeps track of the group resources that were fully imported
+// in a progress file, so that an interrupted import can be resumed.
+type ProgressTracker struct {
+	fs   afero.Afero
+	path string
+
+	progress v1alpha1.ImportProgress
+	imported map[string]struct{}
+}
+
+// NewProgressTracker returns a tracker for the progress file at path, for the
+// export created at the given time. If resume is true, the existing progress
+// is loaded from the file, otherwise the import starts from scratch.
+func NewProgressTracker(fs afero.Afero, path string, em *v1alpha1.ExportMeta, resume bool) (*ProgressTracker, error) {
+	t := &ProgressTracker{
+		fs:       fs,
+		path:     path,
+		progress: v1alpha1.ImportProgress{ExportedAt: em.ExportedAt},
+		imported: map[string]struct{}{},
+	}
+	if !resume {
+		return t, nil
+	}
+
+	b, err := fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing was imported yet.
+		return t, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read progress file %q", path)
+	}
+	p := v1alpha1.ImportProgress{}
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal progress file %q", path)
+	}
+	if !p.ExportedAt.Equal(em.ExportedAt) {
+		return nil, errors.Errorf("progress file %q belongs to a different export, exported at %s", path, p.ExportedAt)
+	}
+
+	t.progress = p
+	for _, gr := range p.Imported {
+		t.imported[gr] = struct{}{}
+	}
+	return t, nil
+}
+
+// Imported returns true if the group resource was already fully imported.
+func (t *ProgressTracker) Imported(gr string) bool {
+	_, ok := t.imported[gr]
+	return ok
+}
+
+// MarkImported records the group resource as fully imported and writes the
+// progress file.
+func (t *ProgressTracker) MarkImported(gr string) error {
+	if t.Imported(gr) {
+		return nil
+	}
+	t.imported[gr] = struct{}{}
+	t.progress.Imported = append(t.progress.Imported, gr)
+
+	b, err := yaml.Marshal(&t.progress)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal import progress to yaml")
+	}
+	return errors.Wrapf(t.fs.WriteFile(t.path, b, 0600), "cannot write progress file %q", t.path)
+}
+
+// Done removes the progress file once the import is complete.
+func (t *ProgressTracker) Done() error {
+	err := t.fs.Remove(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return errors.Wrapf(err, "cannot remove progress file %q", t.path)
+}
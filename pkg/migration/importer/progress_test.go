@@ -0,0 +1,109 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+func TestProgressTracker(t *testing.T) {
+	exportedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	path := "xp-state.tar.gz.progress.yaml"
+
+	type args struct {
+		previous   []string
+		exportedAt time.Time
+		resume     bool
+	}
+	type want struct {
+		imported map[string]bool
+		err      bool
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Resume": {
+			reason: "Resuming should skip the group resources recorded in the progress file.",
+			args:   args{previous: []string{"namespaces", "secrets"}, exportedAt: exportedAt, resume: true},
+			want:   want{imported: map[string]bool{"namespaces": true, "secrets": true, "configmaps": false}},
+		},
+		"NoResume": {
+			reason: "Not resuming should ignore the progress file.",
+			args:   args{previous: []string{"namespaces"}, exportedAt: exportedAt},
+			want:   want{imported: map[string]bool{"namespaces": false}},
+		},
+		"NoProgressFile": {
+			reason: "Resuming without a progress file should start from scratch.",
+			args:   args{resume: true},
+			want:   want{imported: map[string]bool{"namespaces": false}},
+		},
+		"DifferentExport": {
+			reason: "Resuming with the progress of a different export should fail.",
+			args:   args{previous: []string{"namespaces"}, exportedAt: exportedAt.Add(time.Hour), resume: true},
+			want:   want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			em := &v1alpha1.ExportMeta{ExportedAt: exportedAt}
+			if tc.args.previous != nil {
+				prev, err := NewProgressTracker(fs, path, &v1alpha1.ExportMeta{ExportedAt: tc.args.exportedAt}, false)
+				if err != nil {
+					t.Fatalf("NewProgressTracker(...): unexpected error: %v", err)
+				}
+				for _, gr := range tc.args.previous {
+					if err := prev.MarkImported(gr); err != nil {
+						t.Fatalf("MarkImported(...): unexpected error: %v", err)
+					}
+				}
+			}
+
+			p, err := NewProgressTracker(fs, path, em, tc.args.resume)
+			if tc.want.err {
+				if err == nil {
+					t.Errorf("\n%s\nNewProgressTracker(...): expected error", tc.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("\n%s\nNewProgressTracker(...): unexpected error: %v", tc.reason, err)
+			}
+
+			got := make(map[string]bool, len(tc.want.imported))
+			for gr := range tc.want.imported {
+				got[gr] = p.Imported(gr)
+			}
+			if diff := cmp.Diff(tc.want.imported, got); diff != "" {
+				t.Errorf("\n%s\nImported(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if err := p.Done(); err != nil {
+				t.Errorf("\n%s\nDone(): unexpected error: %v", tc.reason, err)
+			}
+			if ok, _ := fs.Exists(path); ok {
+				t.Errorf("\n%s\nDone(): progress file was not removed", tc.reason)
+			}
+		})
+	}
+}
@@ -19,28 +19,86 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/upbound/up/pkg/migration/transform"
 )
 
 type ResourceImporter interface {
 	ImportResources(ctx context.Context, gr string, restoreStatus bool) (int, error)
+	FixupResources(ctx context.Context, gr string, restoreStatus bool) (int, error)
 }
 
 type PausingResourceImporter struct {
-	reader  ResourceReader
-	applier ResourceApplier
+	reader      ResourceReader
+	applier     ResourceApplier
+	transformer *transform.Transformer
+}
+
+// PausingResourceImporterOption configures a PausingResourceImporter.
+type PausingResourceImporterOption func(im *PausingResourceImporter)
+
+// WithTransformer sets the transformer applied to each resource before it is
+// applied.
+func WithTransformer(t *transform.Transformer) PausingResourceImporterOption {
+	return func(im *PausingResourceImporter) {
+		im.transformer = t
+	}
 }
 
-func NewPausingResourceImporter(r ResourceReader, a ResourceApplier) *PausingResourceImporter {
-	return &PausingResourceImporter{
+func NewPausingResourceImporter(r ResourceReader, a ResourceApplier, opts ...PausingResourceImporterOption) *PausingResourceImporter {
+	im := &PausingResourceImporter{
 		reader:  r,
 		applier: a,
 	}
+	for _, o := range opts {
+		o(im)
+	}
+	return im
 }
 
 func (im *PausingResourceImporter) ImportResources(ctx context.Context, gr string, restoreStatus bool) (int, error) {
+	resources, applyStatus, err := im.prepareResources(gr, restoreStatus)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = im.applier.ApplyResources(ctx, resources, applyStatus); err != nil {
+		return 0, errors.Wrapf(err, "cannot apply %q resources", gr)
+	}
+
+	return len(resources), nil
+}
+
+// FixupResources applies the resources of an already imported group resource
+// again if they are missing on the target, or if their spec differs from the
+// archive. It returns the number of resources applied again.
+func (im *PausingResourceImporter) FixupResources(ctx context.Context, gr string, restoreStatus bool) (int, error) {
+	resources, applyStatus, err := im.prepareResources(gr, restoreStatus)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := im.applier.ApplyChangedResources(ctx, resources, applyStatus)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot fix up %q resources", gr)
+	}
+
+	return count, nil
+}
+
+// prepareResources reads, transforms and pauses the resources of the group
+// resource, and returns whether their status should be applied.
+func (im *PausingResourceImporter) prepareResources(gr string, restoreStatus bool) ([]unstructured.Unstructured, bool, error) {
 	resources, typeMeta, err := im.reader.ReadResources(gr)
 	if err != nil {
-		return 0, errors.Wrapf(err, "cannot get %q resources", gr)
+		return nil, false, errors.Wrapf(err, "cannot get %q resources", gr)
+	}
+
+	for i := range resources {
+		if err := im.transformer.Transform(&resources[i]); err != nil {
+			return nil, false, errors.Wrapf(err, "cannot transform %q resources", gr)
+		}
 	}
 
 	hasSubresource := false
@@ -61,9 +119,5 @@ func (im *PausingResourceImporter) ImportResources(ctx context.Context, gr strin
 		}
 	}
 
-	if err = im.applier.ApplyResources(ctx, resources, restoreStatus && hasSubresource); err != nil {
-		return 0, errors.Wrapf(err, "cannot apply %q resources", gr)
-	}
-
-	return len(resources), nil
+	return resources, restoreStatus && hasSubresource, nil
 }
@@ -0,0 +1,320 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inspector inspects and validates unarchived exports.
+package inspector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+const (
+	xrdResource = "compositeresourcedefinitions.apiextensions.crossplane.io"
+)
+
+var (
+	// packageResources are the group resources of Crossplane packages.
+	packageResources = []string{
+		"providers.pkg.crossplane.io",
+		"configurations.pkg.crossplane.io",
+		"functions.pkg.crossplane.io",
+	}
+)
+
+// Report is the result of inspecting an export.
+type Report struct {
+	// Meta is the metadata of the export.
+	Meta *v1alpha1.ExportMeta
+	// Problems are the inconsistencies found in the export.
+	Problems []string
+}
+
+// Inspect reads the metadata of the unarchived export in the file system and
+// validates its contents:
+// - every exported type has resources, as many as recorded in the stats,
+// - every claim type is defined by an exported XRD,
+// - every package has a valid package reference.
+// Encrypted resources are only counted, never read.
+func Inspect(fs afero.Afero) (*Report, error) {
+	b, err := fs.ReadFile("export.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read export metadata")
+	}
+	em := &v1alpha1.ExportMeta{}
+	if err := yaml.Unmarshal(b, em); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal export metadata")
+	}
+
+	i := &inspection{fs: fs, meta: em, encrypted: map[string]bool{}}
+	if em.Encryption != nil {
+		for _, gr := range em.Encryption.Resources {
+			i.encrypted[gr] = true
+		}
+	}
+	if err := i.checkTypes(); err != nil {
+		return nil, err
+	}
+	if err := i.checkClaims(); err != nil {
+		return nil, err
+	}
+	if err := i.checkPackages(); err != nil {
+		return nil, err
+	}
+
+	return &Report{Meta: em, Problems: i.problems}, nil
+}
+
+type inspection struct {
+	fs        afero.Afero
+	meta      *v1alpha1.ExportMeta
+	encrypted map[string]bool
+
+	// claims are the group resources with the claim category.
+	claims   []string
+	problems []string
+}
+
+func (i *inspection) problemf(format string, args ...any) {
+	i.problems = append(i.problems, fmt.Sprintf(format, args...))
+}
+
+// checkTypes compares the resources of each exported type with its type
+// metadata and the export stats.
+func (i *inspection) checkTypes() error {
+	infos, err := i.fs.ReadDir("/")
+	if err != nil {
+		return errors.Wrap(err, "cannot list exported types")
+	}
+
+	found := map[string]bool{}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		gr := info.Name()
+		found[gr] = true
+
+		files, err := i.resourceFiles(gr)
+		if err != nil {
+			return err
+		}
+		tm, err := i.typeMeta(gr)
+		if err != nil {
+			i.problemf("Type %q has invalid metadata: %v", gr, err)
+		}
+		if tm != nil && len(files) == 0 {
+			i.problemf("Type %q has metadata but no resources", gr)
+		}
+		if tm != nil && contains(tm.Categories, "claim") {
+			i.claims = append(i.claims, gr)
+		}
+		if want, ok := i.count(gr); ok && want != len(files) {
+			i.problemf("Type %q has %d resources, but %d were exported", gr, len(files), want)
+		}
+	}
+
+	for _, stats := range []map[string]int{i.meta.Stats.NativeResources, i.meta.Stats.CustomResources} {
+		for _, gr := range sortedKeys(stats) {
+			if stats[gr] > 0 && !found[gr] {
+				i.problemf("Type %q is missing, but %d resources were exported", gr, stats[gr])
+			}
+		}
+	}
+	return nil
+}
+
+// checkClaims makes sure every claim type is defined by an exported XRD.
+func (i *inspection) checkClaims() error {
+	if len(i.claims) == 0 {
+		return nil
+	}
+
+	xrds, err := i.resources(xrdResource)
+	if err != nil {
+		return err
+	}
+	defined := map[schema.GroupKind]bool{}
+	for _, xrd := range xrds {
+		p := fieldpath.Pave(xrd.Object)
+		group, _ := p.GetString("spec.group")
+		kind, _ := p.GetString("spec.claimNames.kind")
+		defined[schema.GroupKind{Group: group, Kind: kind}] = true
+	}
+
+	for _, gr := range i.claims {
+		claims, err := i.resources(gr)
+		if err != nil {
+			return err
+		}
+		for _, c := range claims {
+			if gk := c.GroupVersionKind().GroupKind(); !defined[gk] {
+				i.problemf("Claim %s/%s of kind %q has no exported CompositeResourceDefinition", c.GetNamespace(), c.GetName(), gk)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPackages makes sure every package has a valid package reference.
+func (i *inspection) checkPackages() error {
+	for _, gr := range packageResources {
+		pkgs, err := i.resources(gr)
+		if err != nil {
+			return err
+		}
+		for _, pkg := range pkgs {
+			ref, err := fieldpath.Pave(pkg.Object).GetString("spec.package")
+			if err != nil {
+				i.problemf("%s %q has no package", pkg.GetKind(), pkg.GetName())
+				continue
+			}
+			if _, err := name.ParseReference(ref); err != nil {
+				i.problemf("%s %q has an invalid package %q: %v", pkg.GetKind(), pkg.GetName(), ref, err)
+			}
+		}
+	}
+	return nil
+}
+
+// count returns the number of exported resources of the type recorded in the
+// export stats.
+func (i *inspection) count(gr string) (int, bool) {
+	if c, ok := i.meta.Stats.CustomResources[gr]; ok {
+		return c, true
+	}
+	c, ok := i.meta.Stats.NativeResources[gr]
+	return c, ok
+}
+
+func (i *inspection) typeMeta(gr string) (*v1alpha1.TypeMeta, error) {
+	b, err := i.fs.ReadFile(filepath.Join(gr, "metadata.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		// Native resources have no type metadata.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tm := &v1alpha1.TypeMeta{}
+	if err := yaml.Unmarshal(b, tm); err != nil {
+		return nil, err
+	}
+	return tm, nil
+}
+
+// resourceFiles returns the paths of the resource files of the type.
+func (i *inspection) resourceFiles(gr string) ([]string, error) {
+	var files []string
+	err := i.fs.Walk(gr, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Base(path) == "metadata.yaml" || !strings.HasSuffix(path, ".yaml") {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, errors.Wrapf(err, "cannot walk directory for resource group %q", gr)
+}
+
+// resources reads the resources of the type. Types that were not exported,
+// or whose resources are encrypted, have no resources.
+func (i *inspection) resources(gr string) ([]unstructured.Unstructured, error) {
+	if i.encrypted[gr] {
+		return nil, nil
+	}
+	if ok, _ := i.fs.DirExists(gr); !ok {
+		return nil, nil
+	}
+	files, err := i.resourceFiles(gr)
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]unstructured.Unstructured, 0, len(files))
+	for _, f := range files {
+		b, err := i.fs.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read file %q", f)
+		}
+		var u unstructured.Unstructured
+		if err := k8syaml.Unmarshal(b, &u); err != nil {
+			i.problemf("File %q is not a valid resource: %v", f, err)
+			continue
+		}
+		rs = append(rs, u)
+	}
+	return rs, nil
+}
+
+// CheckCompatibility compares the Crossplane of the export with the Crossplane
+// of the target control plane, and returns the reasons they are incompatible.
+func CheckCompatibility(exported, target v1alpha1.CrossplaneInfo) []string {
+	if target.Version == "" {
+		return []string{"Crossplane was not found on the target control plane"}
+	}
+
+	var warnings []string
+	ev, eErr := version.ParseGeneric(exported.Version)
+	tv, tErr := version.ParseGeneric(target.Version)
+	switch {
+	case eErr != nil || tErr != nil:
+		warnings = append(warnings, fmt.Sprintf("Cannot compare exported Crossplane version %q with target version %q", exported.Version, target.Version))
+	case ev.Major() != tv.Major():
+		warnings = append(warnings, fmt.Sprintf("Target Crossplane version %q has a different major version than exported version %q", target.Version, exported.Version))
+	case tv.LessThan(ev):
+		warnings = append(warnings, fmt.Sprintf("Target Crossplane version %q is older than exported version %q", target.Version, exported.Version))
+	}
+
+	for _, ff := range exported.FeatureFlags {
+		if !contains(target.FeatureFlags, ff) {
+			warnings = append(warnings, fmt.Sprintf("Feature flag %q was set in the exported control plane but is not set in the target control plane", ff))
+		}
+	}
+	return warnings
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
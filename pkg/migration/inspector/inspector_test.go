@@ -0,0 +1,226 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/spf13/afero"
+
+	"github.com/upbound/up/pkg/migration/meta/v1alpha1"
+)
+
+const (
+	exportYAML = `version: v1alpha1
+stats:
+  total: 4
+  nativeResources:
+    secrets: 1
+  customResources:
+    compositeresourcedefinitions.apiextensions.crossplane.io: 1
+    providers.pkg.crossplane.io: 1
+    buckets.example.org: 1
+encryption:
+  scheme: age
+  resources:
+    - secrets
+`
+	xrdYAML = `apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: xbuckets.example.org
+spec:
+  group: example.org
+  claimNames:
+    kind: Bucket
+`
+	claimYAML = `apiVersion: example.org/v1
+kind: Bucket
+metadata:
+  name: my-bucket
+  namespace: default
+`
+	providerYAML = `apiVersion: pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-aws
+spec:
+  package: xpkg.upbound.io/upbound/provider-aws:v1.0.0
+`
+	claimMetaYAML = `categories:
+  - claim
+`
+)
+
+func TestInspect(t *testing.T) {
+	type want struct {
+		problems []string
+		err      bool
+	}
+	cases := map[string]struct {
+		reason string
+		files  map[string]string
+		want   want
+	}{
+		"Valid": {
+			reason: "A consistent export should have no problems, and encrypted secrets should not be read.",
+			files:  map[string]string{},
+			want:   want{},
+		},
+		"MissingXRD": {
+			reason: "Claims without an exported XRD should be reported.",
+			files: map[string]string{
+				"compositeresourcedefinitions.apiextensions.crossplane.io/cluster/xbuckets.example.org.yaml": strings.ReplaceAll(xrdYAML, "kind: Bucket", "kind: Database"),
+			},
+			want: want{problems: []string{
+				`Claim default/my-bucket of kind "Bucket.example.org" has no exported CompositeResourceDefinition`,
+			}},
+		},
+		"InvalidPackage": {
+			reason: "Packages with an invalid package reference should be reported.",
+			files: map[string]string{
+				"providers.pkg.crossplane.io/cluster/provider-aws.yaml": `apiVersion: pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-aws
+spec:
+  package: "xpkg.upbound.io/upbound/provider-aws:@@"
+`,
+			},
+			want: want{problems: []string{
+				`Provider "provider-aws" has an invalid package "xpkg.upbound.io/upbound/provider-aws:@@": could not parse reference: xpkg.upbound.io/upbound/provider-aws:@@`,
+			}},
+		},
+		"CountMismatch": {
+			reason: "Types with a different number of resources than exported should be reported.",
+			files: map[string]string{
+				"buckets.example.org/namespaces/default/other-bucket.yaml": claimYAML,
+			},
+			want: want{problems: []string{
+				`Type "buckets.example.org" has 2 resources, but 1 were exported`,
+			}},
+		},
+		"MissingType": {
+			reason: "Exported types without resources in the archive should be reported.",
+			files: map[string]string{
+				"export.yaml": strings.Replace(exportYAML, "    buckets.example.org: 1\n", "    buckets.example.org: 1\n    functions.pkg.crossplane.io: 2\n", 1),
+			},
+			want: want{problems: []string{
+				`Type "functions.pkg.crossplane.io" is missing, but 2 resources were exported`,
+			}},
+		},
+		"NoExportMeta": {
+			reason: "An archive without export metadata is not an export.",
+			files: map[string]string{
+				"export.yaml": "",
+			},
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			files := map[string]string{
+				"export.yaml":                           exportYAML,
+				"secrets/namespaces/default/creds.yaml": "age-encryption.org/v1 not yaml",
+				"compositeresourcedefinitions.apiextensions.crossplane.io/metadata.yaml":                     "{}\n",
+				"compositeresourcedefinitions.apiextensions.crossplane.io/cluster/xbuckets.example.org.yaml": xrdYAML,
+				"providers.pkg.crossplane.io/metadata.yaml":                                                  "{}\n",
+				"providers.pkg.crossplane.io/cluster/provider-aws.yaml":                                      providerYAML,
+				"buckets.example.org/metadata.yaml":                                                          claimMetaYAML,
+				"buckets.example.org/namespaces/default/my-bucket.yaml":                                      claimYAML,
+			}
+			for f, c := range tc.files {
+				files[f] = c
+			}
+			fs := afero.Afero{Fs: afero.NewMemMapFs()}
+			for f, c := range files {
+				if f == "export.yaml" && c == "" {
+					continue
+				}
+				if err := fs.WriteFile(f, []byte(c), 0600); err != nil {
+					t.Fatalf("WriteFile(...): unexpected error: %v", err)
+				}
+			}
+
+			r, err := Inspect(fs)
+			if tc.want.err {
+				if err == nil {
+					t.Errorf("\n%s\nInspect(...): expected error", tc.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("\n%s\nInspect(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.problems, r.Problems, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nInspect(...): -want problems, +got problems:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	type args struct {
+		exported v1alpha1.CrossplaneInfo
+		target   v1alpha1.CrossplaneInfo
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"Compatible": {
+			reason: "A newer target version with the same feature flags should be compatible.",
+			args: args{
+				exported: v1alpha1.CrossplaneInfo{Version: "v1.14.5", FeatureFlags: []string{"--enable-usages"}},
+				target:   v1alpha1.CrossplaneInfo{Version: "1.15.0-up.1", FeatureFlags: []string{"--enable-usages"}},
+			},
+		},
+		"Older": {
+			reason: "An older target version should be incompatible.",
+			args: args{
+				exported: v1alpha1.CrossplaneInfo{Version: "v1.15.0"},
+				target:   v1alpha1.CrossplaneInfo{Version: "v1.14.5"},
+			},
+			want: []string{`Target Crossplane version "v1.14.5" is older than exported version "v1.15.0"`},
+		},
+		"MissingFeatureFlag": {
+			reason: "Feature flags missing on the target should be incompatible.",
+			args: args{
+				exported: v1alpha1.CrossplaneInfo{Version: "v1.15.0", FeatureFlags: []string{"--enable-usages"}},
+				target:   v1alpha1.CrossplaneInfo{Version: "v1.15.0"},
+			},
+			want: []string{`Feature flag "--enable-usages" was set in the exported control plane but is not set in the target control plane`},
+		},
+		"NoCrossplane": {
+			reason: "A target without Crossplane should be incompatible.",
+			args: args{
+				exported: v1alpha1.CrossplaneInfo{Version: "v1.15.0"},
+			},
+			want: []string{"Crossplane was not found on the target control plane"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CheckCompatibility(tc.args.exported, tc.args.target)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nCheckCompatibility(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
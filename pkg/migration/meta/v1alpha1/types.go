@@ -20,6 +20,7 @@ import (
 
 // Directory structure for export:
 // export.yaml (with ExportMeta below)
+// owners.yaml (with OwnerGraph below)
 // <groupResource>/<cluster or namespace>/<?namespace>/<name>.yaml
 // <groupResource>/metadata.yaml (with TypeMeta below)
 
@@ -65,6 +66,20 @@ type ExportOptions struct {
 	ExcludedResources []string `json:"excludedResources,omitempty" yaml:"excludedResources,omitempty"`
 	// PausedBeforeExport stores whether the resources were paused before the export.
 	PausedBeforeExport bool `json:"pausedBeforeExport,omitempty" yaml:"pausedBeforeExport,omitempty"`
+	// Claim is the "namespace/name" of the claim the export was limited to.
+	Claim string `json:"claim,omitempty" yaml:"claim,omitempty"`
+	// Composite is the name of the composite resource the export was limited
+	// to.
+	Composite string `json:"composite,omitempty" yaml:"composite,omitempty"`
+}
+
+// EncryptionInfo is the information about the encryption of an export.
+type EncryptionInfo struct {
+	// Scheme is the scheme used to encrypt the resources, e.g. "age",
+	// "age-passphrase" or "pgp".
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// Resources are the group resources whose files are encrypted.
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
 }
 
 // ExportMeta is the top level metadata for an export.
@@ -80,4 +95,59 @@ type ExportMeta struct {
 	Crossplane CrossplaneInfo `json:"crossplane,omitempty" yaml:"crossplane,omitempty"`
 	// Stats are the statistics about the exported resources.
 	Stats ExportStats `json:"stats,omitempty" yaml:"stats,omitempty"`
+	// Transformations are the transformation rules applied to the exported
+	// resources, with the number of resources each rule was applied to.
+	Transformations map[string]int `json:"transformations,omitempty" yaml:"transformations,omitempty"`
+	// Encryption is the information about the encryption of the export. The
+	// export is not encrypted if nil.
+	Encryption *EncryptionInfo `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+}
+
+// ImportProgress is the progress of an import. It is written while importing
+// so that an interrupted import can be resumed.
+type ImportProgress struct {
+	// ExportedAt is the time at which the imported export was created. It is
+	// used to make sure the progress belongs to the same export.
+	ExportedAt time.Time `json:"exportedAt,omitempty" yaml:"exportedAt,omitempty"`
+	// Imported are the group resources that were fully imported.
+	Imported []string `json:"imported,omitempty" yaml:"imported,omitempty"`
+}
+
+// OwnerReference is a reference to the owner of a resource, without the UID of
+// the owner on the exported control plane.
+type OwnerReference struct {
+	// APIVersion of the owner.
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	// Kind of the owner.
+	Kind string `json:"kind" yaml:"kind"`
+	// Name of the owner. A namespaced owner is in the namespace of the owned
+	// resource.
+	Name string `json:"name" yaml:"name"`
+	// Controller indicates whether the owner is the managing controller.
+	Controller *bool `json:"controller,omitempty" yaml:"controller,omitempty"`
+	// BlockOwnerDeletion indicates whether the owner cannot be deleted before
+	// the owned resource.
+	BlockOwnerDeletion *bool `json:"blockOwnerDeletion,omitempty" yaml:"blockOwnerDeletion,omitempty"`
+}
+
+// OwnedResource is an exported resource with its owners.
+type OwnedResource struct {
+	// APIVersion of the owned resource.
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	// Kind of the owned resource.
+	Kind string `json:"kind" yaml:"kind"`
+	// Namespace of the owned resource, empty if cluster scoped.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Name of the owned resource.
+	Name string `json:"name" yaml:"name"`
+	// Owners of the resource.
+	Owners []OwnerReference `json:"owners" yaml:"owners"`
+}
+
+// OwnerGraph is the graph of owner references between the exported resources.
+// Owner references are removed from the exported resources since they refer
+// to owners by UID, which changes on import.
+type OwnerGraph struct {
+	// Resources are the exported resources that have owners.
+	Resources []OwnedResource `json:"resources,omitempty" yaml:"resources,omitempty"`
 }
@@ -0,0 +1,263 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transform rewrites resources with user defined rules while they are
+// exported or imported.
+package transform
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Rules file structure:
+//
+// rules:
+// - name: use-upbound-registry
+//   match:
+//     group: pkg.crossplane.io
+//     kind: Provider
+//   operations:
+//   - op: replace
+//     path: spec.package
+//     pattern: ^xpkg.crossplane.io/
+//     replacement: xpkg.upbound.io/
+
+// OperationType is the type of an operation.
+type OperationType string
+
+const (
+	// OperationSet sets the field at the path to the value.
+	OperationSet OperationType = "set"
+	// OperationDelete deletes the field at the path.
+	OperationDelete OperationType = "delete"
+	// OperationReplace replaces the matches of the pattern in the string field
+	// at the path with the replacement.
+	OperationReplace OperationType = "replace"
+)
+
+// Rules are the transformation rules loaded from a rules file.
+type Rules struct {
+	// Rules are applied to each resource in order.
+	Rules []Rule `json:"rules"`
+}
+
+// Rule transforms the resources it matches.
+type Rule struct {
+	// Name of the rule, used to report where it was applied.
+	Name string `json:"name"`
+	// Match selects the resources the rule applies to. Matches every resource
+	// if empty.
+	Match Match `json:"match,omitempty"`
+	// Operations are applied to each matched resource in order.
+	Operations []Operation `json:"operations"`
+}
+
+// Match selects resources by their type, namespace and name. Empty fields
+// match any value.
+type Match struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Operation changes a field of a resource. Paths use the field path syntax,
+// e.g. "spec.forProvider.tags[*].value", where `[*]` matches every key or
+// index.
+type Operation struct {
+	Type        OperationType `json:"op"`
+	Path        string        `json:"path"`
+	Value       any           `json:"value,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	Replacement string        `json:"replacement,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// A Transformer applies rules to resources and keeps track of how many
+// resources each rule was applied to. A nil Transformer applies no rules.
+type Transformer struct {
+	rules   []Rule
+	applied map[string]int
+}
+
+// Load reads the rules file at path and returns a transformer for it.
+func Load(path string) (*Transformer, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // Reading a user provided rules file is intended.
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read transformation rules %q", path)
+	}
+	r := Rules{}
+	if err := yaml.UnmarshalStrict(b, &r); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal transformation rules %q", path)
+	}
+	return New(r)
+}
+
+// New validates the rules and returns a transformer for them.
+func New(r Rules) (*Transformer, error) {
+	t := &Transformer{
+		rules:   make([]Rule, 0, len(r.Rules)),
+		applied: make(map[string]int, len(r.Rules)),
+	}
+	names := make(map[string]struct{}, len(r.Rules))
+	for _, rule := range r.Rules {
+		if rule.Name == "" {
+			return nil, errors.New("transformation rules must have a name")
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, errors.Errorf("duplicate transformation rule %q", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		for i, op := range rule.Operations {
+			if err := op.validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid operation %d in transformation rule %q", i, rule.Name)
+			}
+			if op.Type == OperationReplace {
+				rule.Operations[i].re = regexp.MustCompile(op.Pattern)
+			}
+			rule.Operations[i].Value = normalize(op.Value)
+		}
+		t.rules = append(t.rules, rule)
+	}
+	return t, nil
+}
+
+func (op Operation) validate() error {
+	if _, err := fieldpath.Parse(op.Path); err != nil {
+		return errors.Wrapf(err, "cannot parse path %q", op.Path)
+	}
+	switch op.Type {
+	case OperationSet:
+		if op.Value == nil {
+			return errors.Errorf("%q operation requires a value", op.Type)
+		}
+	case OperationDelete:
+	case OperationReplace:
+		if _, err := regexp.Compile(op.Pattern); err != nil {
+			return errors.Wrapf(err, "cannot compile pattern %q", op.Pattern)
+		}
+	default:
+		return errors.Errorf("unknown operation %q", op.Type)
+	}
+	return nil
+}
+
+// Transform applies every matching rule to the resource in place.
+func (t *Transformer) Transform(u *unstructured.Unstructured) error {
+	if t == nil {
+		return nil
+	}
+	for _, rule := range t.rules {
+		if !rule.Match.matches(u) {
+			continue
+		}
+		paved := fieldpath.Pave(u.Object)
+		for _, op := range rule.Operations {
+			if err := op.apply(paved); err != nil {
+				return errors.Wrapf(err, "cannot apply transformation rule %q to %s %q", rule.Name, u.GetKind(), u.GetName())
+			}
+		}
+		t.applied[rule.Name]++
+	}
+	return nil
+}
+
+// Applied returns the number of resources each rule was applied to. Rules that
+// were never applied are omitted.
+func (t *Transformer) Applied() map[string]int {
+	if t == nil {
+		return nil
+	}
+	applied := make(map[string]int, len(t.applied))
+	for k, v := range t.applied {
+		applied[k] = v
+	}
+	return applied
+}
+
+func (m Match) matches(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return matchesValue(m.Group, gvk.Group) &&
+		matchesValue(m.Version, gvk.Version) &&
+		matchesValue(m.Kind, gvk.Kind) &&
+		matchesValue(m.Namespace, u.GetNamespace()) &&
+		matchesValue(m.Name, u.GetName())
+}
+
+func matchesValue(want, got string) bool {
+	return want == "" || want == got
+}
+
+func (op Operation) apply(p *fieldpath.Paved) error {
+	if op.Type == OperationSet && !strings.Contains(op.Path, "[*]") && !strings.Contains(op.Path, ".*") {
+		return errors.Wrapf(p.SetValue(op.Path, op.Value), "cannot set %q", op.Path)
+	}
+
+	paths, err := p.ExpandWildcards(op.Path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot expand %q", op.Path)
+	}
+	for _, path := range paths {
+		switch op.Type {
+		case OperationSet:
+			err = p.SetValue(path, op.Value)
+		case OperationDelete:
+			err = p.DeleteField(path)
+		case OperationReplace:
+			var s string
+			if s, err = p.GetString(path); err == nil {
+				err = p.SetString(path, op.re.ReplaceAllString(s, op.Replacement))
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot %s %q", op.Type, path)
+		}
+	}
+	return nil
+}
+
+// normalize converts the integral numbers decoded from a rules file to
+// integers, like the numbers of a resource read from the API server.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < math.MaxInt64 {
+			return int64(val)
+		}
+		return val
+	case map[string]any:
+		for k := range val {
+			val[k] = normalize(val[k])
+		}
+		return val
+	case []any:
+		for i := range val {
+			val[i] = normalize(val[i])
+		}
+		return val
+	default:
+		return v
+	}
+}
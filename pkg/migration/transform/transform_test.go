@@ -0,0 +1,138 @@
+// Copyright 2024 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestTransform(t *testing.T) {
+	provider := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "pkg.crossplane.io/v1",
+			"kind":       "Provider",
+			"metadata":   map[string]any{"name": "provider-aws"},
+			"spec": map[string]any{
+				"package": "xpkg.crossplane.io/crossplane-contrib/provider-aws:v0.1.0",
+				"tags":    []any{map[string]any{"key": "a", "value": "x"}, map[string]any{"key": "b", "value": "y"}},
+			},
+		}}
+	}
+
+	type want struct {
+		obj     map[string]any
+		applied map[string]int
+		err     bool
+	}
+	cases := map[string]struct {
+		reason string
+		rules  string
+		want   want
+	}{
+		"NoMatch": {
+			reason: "Rules that do not match the resource should not change it.",
+			rules: `
+rules:
+- name: other
+  match:
+    kind: Function
+  operations:
+  - op: delete
+    path: spec.package
+`,
+			want: want{obj: provider().Object, applied: map[string]int{}},
+		},
+		"Operations": {
+			reason: "Matching rules should apply each of their operations in order.",
+			rules: `
+rules:
+- name: registry
+  match:
+    group: pkg.crossplane.io
+    kind: Provider
+  operations:
+  - op: replace
+    path: spec.package
+    pattern: ^xpkg.crossplane.io/
+    replacement: xpkg.upbound.io/
+  - op: set
+    path: spec.revisionHistoryLimit
+    value: 3
+  - op: set
+    path: spec.tags[*].value
+    value: z
+  - op: delete
+    path: metadata.name
+`,
+			want: want{
+				obj: map[string]any{
+					"apiVersion": "pkg.crossplane.io/v1",
+					"kind":       "Provider",
+					"metadata":   map[string]any{},
+					"spec": map[string]any{
+						"package":              "xpkg.upbound.io/crossplane-contrib/provider-aws:v0.1.0",
+						"revisionHistoryLimit": int64(3),
+						"tags":                 []any{map[string]any{"key": "a", "value": "z"}, map[string]any{"key": "b", "value": "z"}},
+					},
+				},
+				applied: map[string]int{"registry": 1},
+			},
+		},
+		"UnknownOperation": {
+			reason: "Unknown operations should be rejected.",
+			rules: `
+rules:
+- name: bad
+  operations:
+  - op: rename
+    path: metadata.name
+`,
+			want: want{err: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := Rules{}
+			if err := yaml.UnmarshalStrict([]byte(tc.rules), &r); err != nil {
+				t.Fatalf("\n%s\nUnmarshalStrict(...): unexpected error: %v", tc.reason, err)
+			}
+			tr, err := New(r)
+			if tc.want.err {
+				if err == nil {
+					t.Errorf("\n%s\nNew(...): expected error", tc.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("\n%s\nNew(...): unexpected error: %v", tc.reason, err)
+			}
+
+			u := provider()
+			if err := tr.Transform(u); err != nil {
+				t.Fatalf("\n%s\nTransform(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.obj, u.Object); diff != "" {
+				t.Errorf("\n%s\nTransform(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.applied, tr.Applied()); diff != "" {
+				t.Errorf("\n%s\nApplied(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}